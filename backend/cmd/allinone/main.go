@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/crypto-trading-bot/internal/api"
+	"github.com/crypto-trading-bot/internal/bot"
+	"github.com/crypto-trading-bot/internal/config"
+	"github.com/crypto-trading-bot/internal/events"
+	"github.com/crypto-trading-bot/internal/exchange"
+	"github.com/crypto-trading-bot/internal/logger"
+	"github.com/crypto-trading-bot/internal/marketdata"
+	_ "github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// allinone runs market data, the trading bot and the API gateway in a single
+// process connected by an in-memory event bus. It needs only PostgreSQL and
+// is intended for local development.
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Initialize logger
+	lgr := logger.NewLogger(cfg.Logging.Level, cfg.Logging.Format)
+	lgr.Info("Starting all-in-one Trading Bot...")
+
+	// Connect to database
+	db, err := sql.Open("postgres", cfg.Database.URL)
+	if err != nil {
+		lgr.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		lgr.Fatalf("Failed to ping database: %v", err)
+	}
+
+	// In-memory bus replaces NATS
+	bus := events.NewMemoryBus(events.DeliveryAsync, lgr)
+	defer bus.Close()
+
+	// Create the exchange connectors: orders go to the paper exchange in
+	// paper mode, and prices come from it only when they are simulated
+	var exch, priceExch exchange.Exchange
+	var paperExch *exchange.PaperExchange
+	if cfg.IsPaperTrading() {
+		lgr.Info("Using Paper Trading exchange")
+		paperExch, err = exchange.NewPersistentPaperExchange(
+			context.Background(),
+			"paper",
+			decimal.NewFromFloat(cfg.Trading.PaperInitialBalanceUSD),
+			db,
+			lgr,
+		)
+		if err != nil {
+			lgr.Fatalf("Failed to create paper exchange: %v", err)
+		}
+
+		fillConfig, err := exchange.ParseFillModelConfig(cfg.Trading.PaperFillModel)
+		if err != nil {
+			lgr.Fatalf("Invalid paper fill model: %v", err)
+		}
+		paperExch.SetFillModel(exchange.NewSimulatedFills(fillConfig, nil))
+		exch = paperExch
+	}
+
+	if cfg.UsesSimulatedPrices() {
+		lgr.Info("Using simulated prices")
+		priceExch = paperExch
+	} else {
+		lgr.Info("Using Coinbase exchange")
+		priceExch = exchange.NewCoinbaseExchange(
+			cfg.Coinbase.APIKey,
+			cfg.Coinbase.APISecret,
+			cfg.Coinbase.APIPassphrase,
+			cfg.Coinbase.UseSandbox,
+			lgr,
+		)
+		if exch == nil {
+			exch = priceExch
+		}
+
+		// Paper orders execute at the live prices market data publishes
+		if paperExch != nil {
+			if _, err := paperExch.FollowPriceFeed(bus); err != nil {
+				lgr.Fatalf("Failed to start paper price feed: %v", err)
+			}
+		}
+	}
+
+	// Create context for graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Start trading bot first so it is subscribed before prices flow
+	tradingBot, err := bot.New(cfg, db, bus, exch, lgr)
+	if err != nil {
+		lgr.Fatalf("Failed to create trading bot: %v", err)
+	}
+	if err := tradingBot.Start(ctx); err != nil {
+		lgr.Fatalf("Failed to start trading bot: %v", err)
+	}
+
+	// Start market data service
+	symbols := cfg.Strategy.Symbols
+	mds := marketdata.NewMarketDataService(db, priceExch, bus, symbols, lgr)
+	if err := mds.Start(ctx); err != nil {
+		lgr.Fatalf("Failed to start market data service: %v", err)
+	}
+
+	// If using simulated prices, simulate price updates
+	if cfg.UsesSimulatedPrices() {
+		go marketdata.SimulatePriceUpdates(ctx, paperExch, symbols, lgr)
+	}
+
+	// Start API gateway
+	server := api.NewServer(cfg, db, bus, lgr)
+	go func() {
+		if err := server.Run(); err != nil {
+			lgr.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// Wait for interrupt signal
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	lgr.Info("All-in-one Trading Bot is running. Press Ctrl+C to stop.")
+
+	<-sigChan
+
+	lgr.Info("Shutting down all-in-one Trading Bot...")
+	cancel()
+	if paperExch != nil {
+		if err := paperExch.Close(); err != nil {
+			lgr.WithError(err).Error("Failed to save paper exchange state")
+		}
+	} else {
+		exch.Close()
+	}
+	if priceExch != exch {
+		priceExch.Close()
+	}
+
+	lgr.Info("All-in-one Trading Bot stopped")
+}
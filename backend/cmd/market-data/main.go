@@ -4,11 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"log"
-	"math/rand"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/crypto-trading-bot/internal/config"
 	"github.com/crypto-trading-bot/internal/events"
@@ -17,7 +15,6 @@ import (
 	"github.com/crypto-trading-bot/internal/marketdata"
 	_ "github.com/lib/pq"
 	"github.com/shopspring/decimal"
-	"github.com/sirupsen/logrus"
 )
 
 func main() {
@@ -49,15 +46,37 @@ func main() {
 	}
 	defer natsClient.Close()
 
-	// Create exchange connector
-	var exch exchange.Exchange
+	// In paper mode this service hosts the paper venue the trading bot
+	// places its orders on
+	var paperExch *exchange.PaperExchange
 	if cfg.IsPaperTrading() {
 		lgr.Info("Using Paper Trading exchange")
-		exch = exchange.NewPaperExchange(
+		paperExch, err = exchange.NewPersistentPaperExchange(
+			context.Background(),
 			"paper",
-			decimal.NewFromFloat(10000), // $10,000 initial balance
+			decimal.NewFromFloat(cfg.Trading.PaperInitialBalanceUSD),
+			db,
 			lgr,
 		)
+		if err != nil {
+			lgr.Fatalf("Failed to create paper exchange: %v", err)
+		}
+
+		fillConfig, err := exchange.ParseFillModelConfig(cfg.Trading.PaperFillModel)
+		if err != nil {
+			lgr.Fatalf("Invalid paper fill model: %v", err)
+		}
+		paperExch.SetFillModel(exchange.NewSimulatedFills(fillConfig, nil))
+		if err := exchange.NewPaperVenue(paperExch, natsClient, lgr).Start(); err != nil {
+			lgr.Fatalf("Failed to start paper venue: %v", err)
+		}
+	}
+
+	// Create the exchange connector prices are read from
+	var exch exchange.Exchange
+	if cfg.UsesSimulatedPrices() {
+		lgr.Info("Using simulated prices")
+		exch = paperExch
 	} else {
 		lgr.Info("Using Coinbase exchange")
 		exch = exchange.NewCoinbaseExchange(
@@ -67,10 +86,17 @@ func main() {
 			cfg.Coinbase.UseSandbox,
 			lgr,
 		)
+
+		// Paper orders execute at the live prices this service publishes
+		if paperExch != nil {
+			if _, err := paperExch.FollowPriceFeed(natsClient); err != nil {
+				lgr.Fatalf("Failed to start paper price feed: %v", err)
+			}
+		}
 	}
 
 	// Create market data service
-	symbols := []string{cfg.Strategy.Symbol}
+	symbols := cfg.Strategy.Symbols
 	mds := marketdata.NewMarketDataService(db, exch, natsClient, symbols, lgr)
 
 	// Create context for graceful shutdown
@@ -82,9 +108,9 @@ func main() {
 		lgr.Fatalf("Failed to start market data service: %v", err)
 	}
 
-	// If using paper exchange, simulate price updates
-	if cfg.IsPaperTrading() {
-		go simulatePriceUpdates(ctx, exch.(*exchange.PaperExchange), symbols[0], lgr)
+	// If using simulated prices, simulate price updates
+	if cfg.UsesSimulatedPrices() {
+		go marketdata.SimulatePriceUpdates(ctx, paperExch, symbols, lgr)
 	}
 
 	// Wait for interrupt signal
@@ -97,30 +123,14 @@ func main() {
 
 	lgr.Info("Shutting down Market Data Service...")
 	cancel()
-	exch.Close()
-
-	lgr.Info("Market Data Service stopped")
-}
-
-// simulatePriceUpdates simulates price updates for paper trading
-func simulatePriceUpdates(ctx context.Context, paperExch *exchange.PaperExchange, symbol string, lgr *logrus.Logger) {
-	// Start with a base price (e.g., BTC at $45,000)
-	currentPrice := decimal.NewFromFloat(45000.0)
-
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			// Simulate price movement (random walk with small changes)
-			change := (rand.Float64() - 0.5) * 50 // +/- $25
-			currentPrice = currentPrice.Add(decimal.NewFromFloat(change))
-
-			// Update paper exchange
-			paperExch.UpdatePrice(symbol, currentPrice)
+	if exch != exchange.Exchange(paperExch) {
+		exch.Close()
+	}
+	if paperExch != nil {
+		if err := paperExch.Close(); err != nil {
+			lgr.WithError(err).Error("Failed to save paper exchange state")
 		}
 	}
+
+	lgr.Info("Market Data Service stopped")
 }
@@ -0,0 +1,836 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/config"
+	"github.com/crypto-trading-bot/internal/events"
+	"github.com/crypto-trading-bot/internal/risk"
+	"github.com/crypto-trading-bot/internal/strategy"
+	"github.com/crypto-trading-bot/internal/valuation"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// Errors returned by helpers, mapped to HTTP status codes by errorStatus
+var (
+	errNotFound   = errors.New("not found")
+	errBadRequest = errors.New("bad request")
+)
+
+// errorStatus returns the HTTP status code for a helper error
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, errNotFound):
+		return 404
+	case errors.Is(err, errBadRequest):
+		return 400
+	default:
+		return 500
+	}
+}
+
+// Helper functions
+
+func getOverview(ctx context.Context, db *sql.DB, valuer *valuation.Service, lgr *logrus.Logger) map[string]interface{} {
+	overview := map[string]interface{}{
+		"currency":        valuer.Currency(),
+		"portfolio_value": 10000.0,
+		"unrealized_pnl":  0.0,
+		"gross_exposure":  0.0,
+		"net_exposure":    0.0,
+		"daily_pnl":       0.0,
+		"total_pnl":       0.0,
+		"open_positions":  0,
+		"total_trades":    0,
+		"win_rate":        0.0,
+	}
+
+	// Mark balances and open trades to market
+	portfolio, err := valuer.Value(ctx)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to value portfolio")
+	} else {
+		overview["portfolio_value"] = portfolio.PortfolioValue
+		overview["unrealized_pnl"] = portfolio.UnrealizedPnL
+		overview["long_exposure"] = portfolio.LongExposure
+		overview["short_exposure"] = portfolio.ShortExposure
+		overview["gross_exposure"] = portfolio.GrossExposure
+		overview["net_exposure"] = portfolio.NetExposure
+		overview["unpriced"] = portfolio.Unpriced
+	}
+
+	// Get daily P&L
+	if dailyPnL, err := valuer.RealizedPnL(ctx, valuer.StartOfTradingDay(time.Now())); err != nil {
+		lgr.WithError(err).Error("Failed to get daily PnL")
+	} else {
+		overview["daily_pnl"] = dailyPnL.InexactFloat64()
+	}
+
+	// Get total P&L
+	if totalPnL, err := valuer.RealizedPnL(ctx, time.Time{}); err != nil {
+		lgr.WithError(err).Error("Failed to get total PnL")
+	} else {
+		overview["total_pnl"] = totalPnL.InexactFloat64()
+	}
+
+	// Get open positions
+	var openPositions int
+	db.QueryRow("SELECT COUNT(*) FROM trades WHERE exit_time IS NULL").Scan(&openPositions)
+	overview["open_positions"] = openPositions
+
+	// Get total trades
+	var totalTrades int
+	db.QueryRow("SELECT COUNT(*) FROM trades").Scan(&totalTrades)
+	overview["total_trades"] = totalTrades
+
+	// Get win rate
+	var winningTrades int
+	db.QueryRow("SELECT COUNT(*) FROM trades WHERE pnl > 0").Scan(&winningTrades)
+	if totalTrades > 0 {
+		overview["win_rate"] = float64(winningTrades) / float64(totalTrades) * 100
+	}
+
+	return overview
+}
+
+func getTrades(db *sql.DB, lgr *logrus.Logger) []map[string]interface{} {
+	rows, err := db.Query(`
+		SELECT id, symbol, side, entry_price, exit_price, quantity, pnl, pnl_percent, 
+		       entry_time, exit_time, exit_reason
+		FROM trades
+		ORDER BY entry_time DESC
+		LIMIT 50
+	`)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get trades")
+		return []map[string]interface{}{}
+	}
+	defer rows.Close()
+
+	trades := []map[string]interface{}{}
+	for rows.Next() {
+		var id uuid.UUID
+		var symbol, side string
+		var entryPrice, quantity decimal.Decimal
+		var exitPrice, pnl, pnlPercent decimal.NullDecimal
+		var entryTime time.Time
+		var exitTime *time.Time
+		var exitReason *string
+
+		rows.Scan(&id, &symbol, &side, &entryPrice, &exitPrice, &quantity, &pnl, &pnlPercent,
+			&entryTime, &exitTime, &exitReason)
+
+		trade := map[string]interface{}{
+			"id":          id.String(),
+			"symbol":      symbol,
+			"side":        side,
+			"entry_price": entryPrice.String(),
+			"quantity":    quantity.String(),
+			"entry_time":  entryTime,
+			"status":      "open",
+		}
+
+		if exitPrice.Valid {
+			trade["exit_price"] = exitPrice.Decimal.String()
+			trade["status"] = "closed"
+		}
+		if pnl.Valid {
+			pnlFloat, _ := pnl.Decimal.Float64()
+			trade["pnl"] = pnlFloat
+		}
+		if pnlPercent.Valid {
+			pnlPctFloat, _ := pnlPercent.Decimal.Float64()
+			trade["pnl_percent"] = pnlPctFloat
+		}
+		if exitTime != nil {
+			trade["exit_time"] = *exitTime
+		}
+		if exitReason != nil {
+			trade["exit_reason"] = *exitReason
+		}
+
+		trades = append(trades, trade)
+	}
+
+	return trades
+}
+
+func getOrders(db *sql.DB, lgr *logrus.Logger) []map[string]interface{} {
+	rows, err := db.Query(`
+		SELECT id, symbol, side, type, quantity, status, created_at
+		FROM orders
+		ORDER BY created_at DESC
+		LIMIT 50
+	`)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get orders")
+		return []map[string]interface{}{}
+	}
+	defer rows.Close()
+
+	orders := []map[string]interface{}{}
+	for rows.Next() {
+		var id uuid.UUID
+		var symbol, side, orderType, status string
+		var quantity decimal.Decimal
+		var createdAt time.Time
+
+		rows.Scan(&id, &symbol, &side, &orderType, &quantity, &status, &createdAt)
+
+		orders = append(orders, map[string]interface{}{
+			"id":         id.String(),
+			"symbol":     symbol,
+			"side":       side,
+			"type":       orderType,
+			"quantity":   quantity.String(),
+			"status":     status,
+			"created_at": createdAt,
+		})
+	}
+
+	return orders
+}
+
+// getOrderHistory returns the status transitions of an order, oldest first
+func getOrderHistory(db *sql.DB, id string, lgr *logrus.Logger) ([]map[string]interface{}, error) {
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid order ID", errBadRequest)
+	}
+
+	var exists bool
+	if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1)`, orderID).Scan(&exists); err != nil {
+		lgr.WithError(err).Error("Failed to get order")
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: order %s", errNotFound, orderID)
+	}
+
+	rows, err := db.Query(`
+		SELECT from_status, to_status, source, reason, filled_quantity, created_at
+		FROM order_events
+		WHERE order_id = $1
+		ORDER BY created_at, id
+	`, orderID)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get order history")
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []map[string]interface{}{}
+	for rows.Next() {
+		var fromStatus, reason *string
+		var toStatus, source string
+		var filledQuantity decimal.NullDecimal
+		var createdAt time.Time
+
+		rows.Scan(&fromStatus, &toStatus, &source, &reason, &filledQuantity, &createdAt)
+
+		event := map[string]interface{}{
+			"from_status": fromStatus,
+			"to_status":   toStatus,
+			"source":      source,
+			"reason":      reason,
+			"created_at":  createdAt,
+		}
+		if filledQuantity.Valid {
+			event["filled_quantity"] = filledQuantity.Decimal.String()
+		}
+		history = append(history, event)
+	}
+
+	return history, nil
+}
+
+// getOrderFills returns the executions of an order with their slippage
+// from the order's limit or signal price
+func getOrderFills(db *sql.DB, id string, lgr *logrus.Logger) ([]map[string]interface{}, error) {
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid order ID", errBadRequest)
+	}
+
+	var orderPrice decimal.NullDecimal
+	err = db.QueryRow(`SELECT price FROM orders WHERE id = $1`, orderID).Scan(&orderPrice)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: order %s", errNotFound, orderID)
+	}
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get order")
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT exchange_trade_id, side, price, quantity, fee, fee_currency, liquidity, executed_at
+		FROM fills
+		WHERE order_id = $1
+		ORDER BY executed_at, id
+	`, orderID)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get order fills")
+		return nil, err
+	}
+	defer rows.Close()
+
+	fills := []map[string]interface{}{}
+	for rows.Next() {
+		var tradeID, side, feeCurrency, liquidity string
+		var price, quantity, fee decimal.Decimal
+		var executedAt time.Time
+
+		rows.Scan(&tradeID, &side, &price, &quantity, &fee, &feeCurrency, &liquidity, &executedAt)
+
+		fill := map[string]interface{}{
+			"exchange_trade_id": tradeID,
+			"side":              side,
+			"price":             price.String(),
+			"quantity":          quantity.String(),
+			"fee":               fee.String(),
+			"fee_currency":      feeCurrency,
+			"liquidity":         liquidity,
+			"executed_at":       executedAt,
+		}
+
+		// Positive slippage is a worse price than expected
+		if orderPrice.Valid && orderPrice.Decimal.IsPositive() {
+			slippage := price.Sub(orderPrice.Decimal).Div(orderPrice.Decimal).Mul(decimal.NewFromInt(100))
+			if side == "SELL" {
+				slippage = slippage.Neg()
+			}
+			fill["slippage_percent"] = slippage.Round(4).InexactFloat64()
+		}
+
+		fills = append(fills, fill)
+	}
+
+	return fills, nil
+}
+
+func getBalances(db *sql.DB, lgr *logrus.Logger) []map[string]interface{} {
+	rows, err := db.Query("SELECT currency, available, locked, total FROM balances")
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get balances")
+		return []map[string]interface{}{}
+	}
+	defer rows.Close()
+
+	balances := []map[string]interface{}{}
+	for rows.Next() {
+		var currency string
+		var available, locked, total decimal.Decimal
+
+		rows.Scan(&currency, &available, &locked, &total)
+
+		avail, _ := available.Float64()
+		lock, _ := locked.Float64()
+		tot, _ := total.Float64()
+
+		balances = append(balances, map[string]interface{}{
+			"currency":  currency,
+			"available": avail,
+			"locked":    lock,
+			"total":     tot,
+		})
+	}
+
+	return balances
+}
+
+func getStrategy(db *sql.DB, lgr *logrus.Logger) map[string]interface{} {
+	var id uuid.UUID
+	var name, strategyType string
+	var symbol *string
+	var isActive bool
+	var config json.RawMessage
+	var pausedUntil *time.Time
+	var pauseReason *string
+
+	err := db.QueryRow(`
+		SELECT id, name, type, symbol, is_active, config, paused_until, pause_reason
+		FROM strategies
+		ORDER BY created_at DESC
+		LIMIT 1
+	`).Scan(&id, &name, &strategyType, &symbol, &isActive, &config, &pausedUntil, &pauseReason)
+
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get strategy")
+		return map[string]interface{}{
+			"id":        "",
+			"name":      "unknown",
+			"type":      "unknown",
+			"is_active": false,
+		}
+	}
+
+	return strategyToMap(id, name, strategyType, symbol, isActive, config, pausedUntil, pauseReason)
+}
+
+func getStrategies(db *sql.DB, lgr *logrus.Logger) []map[string]interface{} {
+	rows, err := db.Query(`
+		SELECT id, name, type, symbol, is_active, config, paused_until, pause_reason
+		FROM strategies
+		ORDER BY symbol, created_at
+	`)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get strategies")
+		return []map[string]interface{}{}
+	}
+	defer rows.Close()
+
+	strategies := []map[string]interface{}{}
+	for rows.Next() {
+		var id uuid.UUID
+		var name, strategyType string
+		var symbol *string
+		var isActive bool
+		var config json.RawMessage
+		var pausedUntil *time.Time
+		var pauseReason *string
+
+		rows.Scan(&id, &name, &strategyType, &symbol, &isActive, &config, &pausedUntil, &pauseReason)
+
+		strategies = append(strategies, strategyToMap(id, name, strategyType, symbol, isActive, config, pausedUntil, pauseReason))
+	}
+
+	return strategies
+}
+
+func strategyToMap(
+	id uuid.UUID,
+	name, strategyType string,
+	symbol *string,
+	isActive bool,
+	config json.RawMessage,
+	pausedUntil *time.Time,
+	pauseReason *string,
+) map[string]interface{} {
+	strategy := map[string]interface{}{
+		"id":        id.String(),
+		"name":      name,
+		"type":      strategyType,
+		"is_active": isActive,
+		"config":    config,
+	}
+	if symbol != nil {
+		strategy["symbol"] = *symbol
+	}
+	// Paused by a circuit breaker until the given time
+	if pausedUntil != nil {
+		strategy["paused_until"] = *pausedUntil
+		strategy["pause_reason"] = pauseReason
+	}
+	return strategy
+}
+
+func updateStrategyConfig(
+	db *sql.DB,
+	bus events.Bus,
+	id string,
+	body []byte,
+	lgr *logrus.Logger,
+) (strategy.MeanReversionParams, error) {
+	strategyID, err := uuid.Parse(id)
+	if err != nil {
+		return strategy.MeanReversionParams{}, fmt.Errorf("%w: invalid strategy ID", errBadRequest)
+	}
+
+	var strategyType string
+	var configJSON []byte
+	err = db.QueryRow(`
+		SELECT type, config FROM strategies WHERE id = $1
+	`, strategyID).Scan(&strategyType, &configJSON)
+	if err == sql.ErrNoRows {
+		return strategy.MeanReversionParams{}, fmt.Errorf("%w: strategy %s", errNotFound, strategyID)
+	}
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get strategy config")
+		return strategy.MeanReversionParams{}, err
+	}
+
+	if strategyType != "mean_reversion" {
+		return strategy.MeanReversionParams{}, fmt.Errorf("%w: unsupported strategy type %s", errBadRequest, strategyType)
+	}
+
+	// Apply the request on top of the current config
+	current, err := strategy.ParseMeanReversionParams(configJSON)
+	if err != nil {
+		lgr.WithError(err).WithField("strategy_id", strategyID).Warn("Stored strategy config is invalid, using defaults")
+		current = strategy.DefaultMeanReversionParams()
+	}
+
+	params, err := current.WithOverrides(body)
+	if err != nil {
+		return strategy.MeanReversionParams{}, fmt.Errorf("%w: %v", errBadRequest, err)
+	}
+
+	updatedJSON, err := json.Marshal(params)
+	if err != nil {
+		return strategy.MeanReversionParams{}, err
+	}
+
+	if _, err := db.Exec("UPDATE strategies SET config = $2 WHERE id = $1", strategyID, updatedJSON); err != nil {
+		lgr.WithError(err).Error("Failed to update strategy config")
+		return strategy.MeanReversionParams{}, err
+	}
+
+	// Notify the running bot so it reloads without a restart
+	updateEvent := &events.StrategyConfigUpdatedEvent{
+		StrategyID: strategyID.String(),
+		Config:     updatedJSON,
+	}
+	if err := bus.Publish(events.EventTypeStrategyConfigUpdated, updateEvent); err != nil {
+		lgr.WithError(err).Error("Failed to publish strategy config update")
+		return strategy.MeanReversionParams{}, fmt.Errorf("config saved but not applied to the running bot: %w", err)
+	}
+
+	lgr.WithField("strategy_id", strategyID).Info("Strategy config updated")
+	return params, nil
+}
+
+// Toggle modes for disabling a strategy
+const (
+	toggleModePauseEntries   = "pause_entries"
+	toggleModeClosePositions = "close_positions"
+)
+
+// toggleStrategy enables or disables every strategy
+func toggleStrategy(db *sql.DB, bus events.Bus, enabled bool, lgr *logrus.Logger) error {
+	rows, err := db.Query("UPDATE strategies SET is_active = $1, close_positions = false RETURNING id", enabled)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to toggle strategy")
+		return err
+	}
+	defer rows.Close()
+
+	var strategyIDs []uuid.UUID
+	for rows.Next() {
+		var strategyID uuid.UUID
+		if err := rows.Scan(&strategyID); err != nil {
+			return err
+		}
+		strategyIDs = append(strategyIDs, strategyID)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, strategyID := range strategyIDs {
+		if err := publishStrategyToggled(bus, strategyID, enabled, false, lgr); err != nil {
+			return err
+		}
+	}
+
+	lgr.WithField("enabled", enabled).Info("Strategy toggled")
+	return nil
+}
+
+// toggleStrategyByID enables or disables a single strategy. When disabling,
+// mode selects whether open positions are kept or closed; a close is stored
+// with the strategy until a bot has acted on it.
+func toggleStrategyByID(db *sql.DB, bus events.Bus, id string, enabled bool, mode string, lgr *logrus.Logger) error {
+	strategyID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%w: invalid strategy ID", errBadRequest)
+	}
+
+	if mode == "" {
+		mode = toggleModePauseEntries
+	}
+	if mode != toggleModePauseEntries && mode != toggleModeClosePositions {
+		return fmt.Errorf("%w: mode must be %s or %s", errBadRequest, toggleModePauseEntries, toggleModeClosePositions)
+	}
+
+	closePositions := !enabled && mode == toggleModeClosePositions
+	result, err := db.Exec(`
+		UPDATE strategies SET is_active = $2, close_positions = $3 WHERE id = $1
+	`, strategyID, enabled, closePositions)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to toggle strategy")
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("%w: strategy %s", errNotFound, strategyID)
+	}
+
+	if err := publishStrategyToggled(bus, strategyID, enabled, closePositions, lgr); err != nil {
+		return err
+	}
+
+	lgr.WithFields(logrus.Fields{
+		"strategy_id": strategyID,
+		"enabled":     enabled,
+		"mode":        mode,
+	}).Info("Strategy toggled")
+	return nil
+}
+
+// publishStrategyToggled notifies running bots of a strategy toggle
+func publishStrategyToggled(bus events.Bus, strategyID uuid.UUID, enabled, closePositions bool, lgr *logrus.Logger) error {
+	toggleEvent := &events.StrategyToggledEvent{
+		StrategyID:     strategyID.String(),
+		Enabled:        enabled,
+		ClosePositions: closePositions,
+	}
+	if err := bus.Publish(events.EventTypeStrategyToggled, toggleEvent); err != nil {
+		lgr.WithError(err).Error("Failed to publish strategy toggle")
+		return fmt.Errorf("strategy state saved but not applied to the running bot: %w", err)
+	}
+	return nil
+}
+
+func getKillSwitchStatus(db *sql.DB, lgr *logrus.Logger) map[string]interface{} {
+	var value json.RawMessage
+	err := db.QueryRow("SELECT value FROM system_config WHERE key = 'kill_switch'").Scan(&value)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get kill switch status")
+		return map[string]interface{}{
+			"enabled": false,
+		}
+	}
+
+	var status map[string]interface{}
+	json.Unmarshal(value, &status)
+	return status
+}
+
+func enableKillSwitch(db *sql.DB, reason string, lgr *logrus.Logger) error {
+	_, err := db.Exec(`
+		UPDATE system_config
+		SET value = jsonb_build_object('enabled', true, 'reason', $1, 'timestamp', to_jsonb(NOW()))
+		WHERE key = 'kill_switch'
+	`, reason)
+
+	if err != nil {
+		lgr.WithError(err).Error("Failed to enable kill switch")
+		return err
+	}
+
+	lgr.WithField("reason", reason).Warn("Kill switch enabled via API")
+	return nil
+}
+
+func disableKillSwitch(db *sql.DB, lgr *logrus.Logger) error {
+	_, err := db.Exec(`
+		UPDATE system_config
+		SET value = jsonb_build_object('enabled', false, 'reason', null, 'timestamp', to_jsonb(NOW()))
+		WHERE key = 'kill_switch'
+	`)
+
+	if err != nil {
+		lgr.WithError(err).Error("Failed to disable kill switch")
+		return err
+	}
+
+	lgr.Info("Kill switch disabled via API")
+	return nil
+}
+
+// getRiskLimits returns the global risk limits and per-strategy overrides
+func getRiskLimits(db *sql.DB, base *config.RiskConfig, lgr *logrus.Logger) (*risk.LimitsSnapshot, error) {
+	snapshot, err := risk.LoadLimits(context.Background(), db, base)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get risk limits")
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// updateRiskLimits applies a partial update of the global limits, or of a
+// strategy's overrides, and notifies the running bots
+func updateRiskLimits(
+	db *sql.DB,
+	bus events.Bus,
+	base *config.RiskConfig,
+	body []byte,
+	changedBy string,
+	lgr *logrus.Logger,
+) ([]risk.LimitChange, error) {
+	var req struct {
+		StrategyID string                     `json:"strategy_id"`
+		Limits     map[string]json.RawMessage `json:"limits"`
+		Reset      []string                   `json:"reset"` // Strategy overrides to remove
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("%w: %v", errBadRequest, err)
+	}
+
+	var strategyID *uuid.UUID
+	if req.StrategyID != "" {
+		id, err := uuid.Parse(req.StrategyID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid strategy ID", errBadRequest)
+		}
+		strategyID = &id
+	}
+
+	changes, err := risk.UpdateLimits(context.Background(), db, base, strategyID, req.Limits, req.Reset, changedBy)
+	switch {
+	case errors.Is(err, risk.ErrInvalidLimits):
+		return nil, fmt.Errorf("%w: %v", errBadRequest, err)
+	case errors.Is(err, risk.ErrStrategyNotFound):
+		return nil, fmt.Errorf("%w: %v", errNotFound, err)
+	case err != nil:
+		lgr.WithError(err).Error("Failed to update risk limits")
+		return nil, err
+	}
+
+	if len(changes) == 0 {
+		return changes, nil
+	}
+
+	updateEvent := &events.RiskLimitsUpdatedEvent{
+		StrategyID: req.StrategyID,
+		ChangedBy:  changedBy,
+	}
+	if err := bus.Publish(events.EventTypeRiskLimits, updateEvent); err != nil {
+		lgr.WithError(err).Error("Failed to publish risk limits update")
+		return nil, fmt.Errorf("risk limits saved but not applied to the running bot: %w", err)
+	}
+
+	lgr.WithFields(logrus.Fields{
+		"strategy_id": req.StrategyID,
+		"changed_by":  changedBy,
+		"changes":     len(changes),
+	}).Warn("Risk limits updated via API")
+	return changes, nil
+}
+
+// getVaR returns the VaR and expected shortfall of the open positions at the
+// given confidence, or at the runtime limits' confidence when zero
+func getVaR(db *sql.DB, base *config.RiskConfig, confidence float64, lgr *logrus.Logger) (*risk.VaRReport, error) {
+	ctx := context.Background()
+
+	snapshot, err := risk.LoadLimits(ctx, db, base)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get risk limits")
+		return nil, err
+	}
+	cfg := snapshot.Global.Apply(base)
+	if confidence == 0 {
+		confidence = cfg.VaRConfidence
+	}
+
+	positions, err := risk.LoadPositions(ctx, db)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get positions")
+		return nil, err
+	}
+
+	report, err := risk.ComputeVaR(ctx, db, positions, confidence, cfg.VaRHorizonMinutes, cfg.VaRLookback)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to compute VaR")
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// getRiskLimitChanges returns the audit trail of risk limit changes
+func getRiskLimitChanges(db *sql.DB, lgr *logrus.Logger) []map[string]interface{} {
+	rows, err := db.Query(`
+		SELECT strategy_id, field, old_value, new_value, changed_by, changed_at
+		FROM risk_limit_changes
+		ORDER BY changed_at DESC
+		LIMIT 100
+	`)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get risk limit changes")
+		return []map[string]interface{}{}
+	}
+	defer rows.Close()
+
+	changes := []map[string]interface{}{}
+	for rows.Next() {
+		var strategyID *uuid.UUID
+		var field, changedBy string
+		var oldValue, newValue json.RawMessage
+		var changedAt time.Time
+
+		rows.Scan(&strategyID, &field, &oldValue, &newValue, &changedBy, &changedAt)
+
+		change := map[string]interface{}{
+			"field":      field,
+			"old_value":  oldValue,
+			"new_value":  newValue,
+			"changed_by": changedBy,
+			"changed_at": changedAt,
+		}
+		if strategyID != nil {
+			change["strategy_id"] = strategyID.String()
+		}
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+func getRiskEvents(db *sql.DB, lgr *logrus.Logger) []map[string]interface{} {
+	rows, err := db.Query(`
+		SELECT event_type, description, action_taken, timestamp
+		FROM risk_events
+		ORDER BY timestamp DESC
+		LIMIT 50
+	`)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get risk events")
+		return []map[string]interface{}{}
+	}
+	defer rows.Close()
+
+	events := []map[string]interface{}{}
+	for rows.Next() {
+		var eventType, description, actionTaken string
+		var timestamp time.Time
+
+		rows.Scan(&eventType, &description, &actionTaken, &timestamp)
+
+		events = append(events, map[string]interface{}{
+			"event_type":   eventType,
+			"description":  description,
+			"action_taken": actionTaken,
+			"timestamp":    timestamp,
+		})
+	}
+
+	return events
+}
+
+func getLogs(db *sql.DB, lgr *logrus.Logger) []map[string]interface{} {
+	rows, err := db.Query(`
+		SELECT level, component, message, timestamp
+		FROM logs
+		ORDER BY timestamp DESC
+		LIMIT 100
+	`)
+	if err != nil {
+		lgr.WithError(err).Error("Failed to get logs")
+		return []map[string]interface{}{}
+	}
+	defer rows.Close()
+
+	logs := []map[string]interface{}{}
+	for rows.Next() {
+		var level, component, message string
+		var timestamp time.Time
+
+		rows.Scan(&level, &component, &message, &timestamp)
+
+		logs = append(logs, map[string]interface{}{
+			"level":     level,
+			"component": component,
+			"message":   message,
+			"timestamp": timestamp,
+		})
+	}
+
+	return logs
+}
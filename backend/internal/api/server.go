@@ -0,0 +1,308 @@
+package api
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/config"
+	"github.com/crypto-trading-bot/internal/currency"
+	"github.com/crypto-trading-bot/internal/events"
+	"github.com/crypto-trading-bot/internal/valuation"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Server is the HTTP API gateway backing the dashboard
+type Server struct {
+	cfg    *config.Config
+	db     *sql.DB
+	bus    events.Bus
+	logger *logrus.Logger
+	router *gin.Engine
+}
+
+// NewServer creates a new API server with all routes registered
+func NewServer(cfg *config.Config, db *sql.DB, bus events.Bus, logger *logrus.Logger) *Server {
+	// Set Gin mode
+	if cfg.Logging.Level == "debug" {
+		gin.SetMode(gin.DebugMode)
+	} else {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	s := &Server{
+		cfg:    cfg,
+		db:     db,
+		bus:    bus,
+		logger: logger,
+		router: gin.Default(),
+	}
+
+	s.registerRoutes()
+
+	return s
+}
+
+// Run starts the HTTP server and blocks until it stops
+func (s *Server) Run() error {
+	port := ":" + s.cfg.API.Port
+	s.logger.WithField("port", port).Info("API Gateway started")
+
+	return s.router.Run(port)
+}
+
+// registerRoutes registers middleware and API routes
+func (s *Server) registerRoutes() {
+	db := s.db
+	bus := s.bus
+	lgr := s.logger
+	router := s.router
+	converter := currency.NewConverter(db, s.cfg.Portfolio.Stablecoins)
+	valuer := valuation.NewService(db, bus, converter, s.cfg.Portfolio.ReportingCurrency, s.cfg.Risk.TradingDayLocation(), lgr)
+
+	// CORS middleware
+	router.Use(func(c *gin.Context) {
+		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-User")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	})
+
+	// Health check
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"status":    "healthy",
+			"timestamp": time.Now(),
+		})
+	})
+
+	// API v1 routes
+	v1 := router.Group("/api/v1")
+	{
+		// Get overview/dashboard stats
+		v1.GET("/overview", func(c *gin.Context) {
+			overview := getOverview(c.Request.Context(), db, valuer, lgr)
+			c.JSON(200, overview)
+		})
+
+		// Get trades
+		v1.GET("/trades", func(c *gin.Context) {
+			trades := getTrades(db, lgr)
+			c.JSON(200, trades)
+		})
+
+		// Get orders
+		v1.GET("/orders", func(c *gin.Context) {
+			orders := getOrders(db, lgr)
+			c.JSON(200, orders)
+		})
+
+		// Status transitions of an order
+		v1.GET("/orders/:id/history", func(c *gin.Context) {
+			history, err := getOrderHistory(db, c.Param("id"), lgr)
+			if err != nil {
+				c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(200, history)
+		})
+
+		// Executions of an order
+		v1.GET("/orders/:id/fills", func(c *gin.Context) {
+			fills, err := getOrderFills(db, c.Param("id"), lgr)
+			if err != nil {
+				c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(200, fills)
+		})
+
+		// Get balances
+		v1.GET("/balances", func(c *gin.Context) {
+			balances := getBalances(db, lgr)
+			c.JSON(200, balances)
+		})
+
+		// Get strategy status
+		v1.GET("/strategy", func(c *gin.Context) {
+			strategy := getStrategy(db, lgr)
+			c.JSON(200, strategy)
+		})
+
+		// List strategy instances (one per symbol)
+		v1.GET("/strategies", func(c *gin.Context) {
+			strategies := getStrategies(db, lgr)
+			c.JSON(200, strategies)
+		})
+
+		// Update strategy parameters
+		v1.PUT("/strategies/:id/config", func(c *gin.Context) {
+			body, err := c.GetRawData()
+			if err != nil {
+				c.JSON(400, gin.H{"error": err.Error()})
+				return
+			}
+
+			params, err := updateStrategyConfig(db, bus, c.Param("id"), body, lgr)
+			if err != nil {
+				c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(200, gin.H{"success": true, "config": params})
+		})
+
+		// Toggle strategy
+		v1.POST("/strategy/toggle", func(c *gin.Context) {
+			var req struct {
+				Enabled bool `json:"enabled"`
+			}
+			if err := c.BindJSON(&req); err != nil {
+				c.JSON(400, gin.H{"error": err.Error()})
+				return
+			}
+
+			err := toggleStrategy(db, bus, req.Enabled, lgr)
+			if err != nil {
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(200, gin.H{"success": true, "enabled": req.Enabled})
+		})
+
+		// Enable or disable a single strategy instance
+		v1.POST("/strategies/:id/toggle", func(c *gin.Context) {
+			var req struct {
+				Enabled bool   `json:"enabled"`
+				Mode    string `json:"mode"`
+			}
+			if err := c.BindJSON(&req); err != nil {
+				c.JSON(400, gin.H{"error": err.Error()})
+				return
+			}
+
+			err := toggleStrategyByID(db, bus, c.Param("id"), req.Enabled, req.Mode, lgr)
+			if err != nil {
+				c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(200, gin.H{"success": true, "enabled": req.Enabled})
+		})
+
+		// Kill switch endpoints
+		v1.GET("/kill-switch", func(c *gin.Context) {
+			status := getKillSwitchStatus(db, lgr)
+			c.JSON(200, status)
+		})
+
+		v1.POST("/kill-switch/enable", func(c *gin.Context) {
+			var req struct {
+				Reason string `json:"reason"`
+			}
+			if err := c.BindJSON(&req); err != nil {
+				c.JSON(400, gin.H{"error": err.Error()})
+				return
+			}
+
+			err := enableKillSwitch(db, req.Reason, lgr)
+			if err != nil {
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(200, gin.H{"success": true})
+		})
+
+		v1.POST("/kill-switch/disable", func(c *gin.Context) {
+			err := disableKillSwitch(db, lgr)
+			if err != nil {
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(200, gin.H{"success": true})
+		})
+
+		// Runtime risk limits
+		v1.GET("/risk/limits", func(c *gin.Context) {
+			limits, err := getRiskLimits(db, &s.cfg.Risk, lgr)
+			if err != nil {
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(200, limits)
+		})
+
+		v1.PUT("/risk/limits", func(c *gin.Context) {
+			body, err := c.GetRawData()
+			if err != nil {
+				c.JSON(400, gin.H{"error": err.Error()})
+				return
+			}
+
+			// Recorded in the audit trail
+			changedBy := c.GetHeader("X-User")
+			if changedBy == "" {
+				changedBy = "api"
+			}
+
+			changes, err := updateRiskLimits(db, bus, &s.cfg.Risk, body, changedBy, lgr)
+			if err != nil {
+				c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(200, gin.H{"success": true, "changes": changes})
+		})
+
+		// Portfolio VaR and expected shortfall, optionally at ?confidence=0.95
+		v1.GET("/risk/var", func(c *gin.Context) {
+			var confidence float64
+			if value := c.Query("confidence"); value != "" {
+				parsed, err := strconv.ParseFloat(value, 64)
+				if err != nil || parsed <= 0.5 || parsed >= 1 {
+					c.JSON(400, gin.H{"error": "confidence must be between 0.5 and 1"})
+					return
+				}
+				confidence = parsed
+			}
+
+			report, err := getVaR(db, &s.cfg.Risk, confidence, lgr)
+			if err != nil {
+				c.JSON(500, gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(200, report)
+		})
+
+		v1.GET("/risk/limits/changes", func(c *gin.Context) {
+			changes := getRiskLimitChanges(db, lgr)
+			c.JSON(200, changes)
+		})
+
+		// Get risk events
+		v1.GET("/risk-events", func(c *gin.Context) {
+			events := getRiskEvents(db, lgr)
+			c.JSON(200, events)
+		})
+
+		// Get logs
+		v1.GET("/logs", func(c *gin.Context) {
+			logs := getLogs(db, lgr)
+			c.JSON(200, logs)
+		})
+	}
+}
@@ -0,0 +1,570 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/config"
+	"github.com/crypto-trading-bot/internal/currency"
+	"github.com/crypto-trading-bot/internal/events"
+	"github.com/crypto-trading-bot/internal/exchange"
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/crypto-trading-bot/internal/order"
+	"github.com/crypto-trading-bot/internal/position"
+	"github.com/crypto-trading-bot/internal/risk"
+	"github.com/crypto-trading-bot/internal/strategy"
+	"github.com/crypto-trading-bot/internal/valuation"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// valuationInterval is how often the portfolio valuation is published
+const valuationInterval = 30 * time.Second
+
+// Bot wires the strategy, risk manager and order manager to the event bus
+type Bot struct {
+	cfg          *config.Config
+	db           *sql.DB
+	bus          events.Bus
+	exchange     exchange.Exchange
+	logger       *logrus.Logger
+	riskManager  *risk.RiskManager
+	orderManager *order.OrderManager
+	converter    *currency.Converter
+	valuation    *valuation.Service
+	strategies   map[string]*strategy.MeanReversionStrategy // keyed by symbol
+}
+
+// New creates a new trading bot, registering one strategy instance per
+// configured symbol in the database
+func New(
+	cfg *config.Config,
+	db *sql.DB,
+	bus events.Bus,
+	exch exchange.Exchange,
+	logger *logrus.Logger,
+) (*Bot, error) {
+	if cfg.IsPaperTrading() {
+		// Initialize paper exchange balance in database
+		initializePaperBalance(db, cfg, logger)
+	}
+
+	ledger := position.NewLedger(db, position.Method(cfg.Trading.LotMethod), logger)
+	converter := currency.NewConverter(db, cfg.Portfolio.Stablecoins)
+
+	b := &Bot{
+		cfg:          cfg,
+		db:           db,
+		bus:          bus,
+		exchange:     exch,
+		logger:       logger,
+		riskManager:  risk.NewRiskManager(&cfg.Risk, db, bus, exch, converter, logger),
+		orderManager: order.NewOrderManager(db, exch, bus, ledger, logger),
+		converter:    converter,
+		valuation:    valuation.NewService(db, bus, converter, cfg.Portfolio.ReportingCurrency, cfg.Risk.TradingDayLocation(), logger),
+		strategies:   make(map[string]*strategy.MeanReversionStrategy, len(cfg.Strategy.Symbols)),
+	}
+
+	for _, symbol := range cfg.Strategy.Symbols {
+		// Create or get strategy
+		strategyID, err := getOrCreateStrategy(db, cfg, symbol, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get/create strategy for %s: %w", symbol, err)
+		}
+
+		// Load strategy parameters from its config
+		params, isActive, err := loadStrategy(db, strategyID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config for strategy %s: %w", strategyID, err)
+		}
+
+		logger.WithFields(logrus.Fields{
+			"strategy_id": strategyID,
+			"symbol":      symbol,
+			"is_active":   isActive,
+		}).Info("Strategy loaded")
+
+		meanReversionStrategy := strategy.NewMeanReversionStrategy(
+			strategyID,
+			symbol,
+			db,
+			bus,
+			exch,
+			b.riskManager,
+			cfg,
+			params,
+			logger,
+		)
+		meanReversionStrategy.SetActive(isActive)
+
+		// Load price history
+		if err := meanReversionStrategy.LoadPriceHistory(context.Background(), 100); err != nil {
+			logger.WithError(err).WithField("symbol", symbol).Warn("Failed to load price history, will build as prices arrive")
+		}
+
+		b.strategies[symbol] = meanReversionStrategy
+	}
+
+	return b, nil
+}
+
+// Start subscribes the bot to the bus and starts risk monitoring
+func (b *Bot) Start(ctx context.Context) error {
+	// Subscribe to price updates
+	_, err := b.bus.Subscribe(string(events.EventTypePriceUpdate), func(event *events.Event) error {
+		var priceUpdate events.PriceUpdateEvent
+		if err := json.Unmarshal(event.Data, &priceUpdate); err != nil {
+			b.logger.WithError(err).Error("Failed to unmarshal price update")
+			return err
+		}
+
+		b.converter.OnPriceUpdate(priceUpdate.Symbol, decimal.NewFromFloat(priceUpdate.Price))
+
+		// Enforce stops of open trades on every tick
+		if err := b.riskManager.OnPriceUpdate(ctx, priceUpdate.Symbol, decimal.NewFromFloat(priceUpdate.Price), priceUpdate.Time); err != nil {
+			b.logger.WithError(err).WithField("symbol", priceUpdate.Symbol).Error("Failed to check stops")
+		}
+
+		// Pass to the strategy instance for this symbol. Exits are always
+		// evaluated; the strategy gates new entries on its active flag.
+		meanReversionStrategy, exists := b.strategies[priceUpdate.Symbol]
+		if !exists {
+			return nil
+		}
+		return meanReversionStrategy.OnPriceUpdate(ctx, &priceUpdate)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to price updates: %w", err)
+	}
+
+	// Subscribe to trade signals
+	_, err = b.bus.QueueSubscribe(string(events.EventTypeTradeSignal), "trading-bot", func(event *events.Event) error {
+		return b.handleTradeSignal(ctx, event)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to trade signals: %w", err)
+	}
+
+	// Subscribe to strategy config updates
+	_, err = b.bus.Subscribe(string(events.EventTypeStrategyConfigUpdated), func(event *events.Event) error {
+		return b.handleStrategyConfigUpdated(event)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to strategy config updates: %w", err)
+	}
+
+	// Subscribe to strategy enable/disable toggles
+	_, err = b.bus.Subscribe(string(events.EventTypeStrategyToggled), func(event *events.Event) error {
+		return b.handleStrategyToggled(ctx, event)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to strategy toggles: %w", err)
+	}
+
+	// Load runtime risk limits and subscribe to their changes
+	if err := b.reloadRiskLimits(ctx); err != nil {
+		return fmt.Errorf("failed to load risk limits: %w", err)
+	}
+
+	_, err = b.bus.Subscribe(string(events.EventTypeRiskLimits), func(event *events.Event) error {
+		var update events.RiskLimitsUpdatedEvent
+		if err := json.Unmarshal(event.Data, &update); err != nil {
+			b.logger.WithError(err).Error("Failed to unmarshal risk limits update")
+			return err
+		}
+
+		b.logger.WithFields(logrus.Fields{
+			"strategy_id": update.StrategyID,
+			"changed_by":  update.ChangedBy,
+		}).Info("Risk limits updated")
+
+		return b.reloadRiskLimits(ctx)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to risk limit updates: %w", err)
+	}
+
+	// Subscribe to kill switch events
+	_, err = b.bus.Subscribe(string(events.EventTypeKillSwitch), func(event *events.Event) error {
+		var killSwitch events.KillSwitchEvent
+		if err := json.Unmarshal(event.Data, &killSwitch); err != nil {
+			b.logger.WithError(err).Error("Failed to unmarshal kill switch event")
+			return err
+		}
+
+		if killSwitch.Enabled {
+			b.logger.WithField("reason", killSwitch.Reason).Warn("Kill switch activated!")
+		} else {
+			b.logger.Info("Kill switch deactivated")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to kill switch events: %w", err)
+	}
+
+	// Resync balances after every fill
+	_, err = b.bus.Subscribe(string(events.EventTypeOrderFilled), func(event *events.Event) error {
+		if err := b.riskManager.SyncBalances(ctx); err != nil {
+			b.logger.WithError(err).Error("Failed to sync balances")
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to order fills: %w", err)
+	}
+
+	if err := b.riskManager.SyncBalances(ctx); err != nil {
+		b.logger.WithError(err).Error("Failed to sync balances")
+	}
+
+	// Start risk monitoring goroutine
+	go b.runRiskMonitor(ctx)
+
+	// Publish the marked-to-market portfolio
+	go b.valuation.Run(ctx, valuationInterval)
+
+	for symbol, meanReversionStrategy := range b.strategies {
+		if !meanReversionStrategy.IsActive() {
+			b.logger.WithField("symbol", symbol).Warn("Strategy is DISABLED. Enable it from the dashboard to open new positions.")
+		}
+	}
+
+	b.logger.WithFields(logrus.Fields{
+		"mode":    b.cfg.Trading.Mode,
+		"symbols": b.cfg.Strategy.Symbols,
+	}).Info("Bot configuration")
+
+	return nil
+}
+
+// handleTradeSignal validates a trade signal and places the order
+func (b *Bot) handleTradeSignal(ctx context.Context, event *events.Event) error {
+	var signal events.TradeSignalEvent
+	if err := json.Unmarshal(event.Data, &signal); err != nil {
+		b.logger.WithError(err).Error("Failed to unmarshal trade signal")
+		return err
+	}
+
+	b.logger.WithFields(logrus.Fields{
+		"signal_id": signal.ID,
+		"symbol":    signal.Symbol,
+		"side":      signal.Side,
+		"intent":    signal.Intent,
+		"reason":    signal.Reason,
+	}).Info("Received trade signal")
+
+	// Parse strategy ID
+	strategyID, err := uuid.Parse(signal.StrategyID)
+	if err != nil {
+		b.logger.WithError(err).Error("Invalid strategy ID")
+		return err
+	}
+
+	intent, err := models.ParsePositionIntent(signal.Intent, models.OrderSide(signal.Side))
+	if err != nil {
+		b.logger.WithError(err).Error("Invalid trade signal")
+		return err
+	}
+
+	// Build models.TradeSignal for risk validation
+	signalModel := &models.TradeSignal{
+		StrategyID:    strategyID,
+		Symbol:        signal.Symbol,
+		Side:          models.OrderSide(signal.Side),
+		Intent:        intent,
+		Quantity:      decimal.NewFromFloat(signal.Quantity),
+		StopLossPrice: decimal.NewFromFloat(signal.StopLossPrice),
+		Indicators:    signal.Indicators,
+	}
+
+	// Validate with risk manager
+	if err := b.riskManager.ValidateTradeSignal(ctx, signalModel); err != nil {
+		b.logger.WithError(err).Warn("Trade signal rejected by risk manager")
+		return nil // Don't return error - just skip the trade
+	}
+
+	// Place the quantity adjusted to the exchange's step size
+	signal.Quantity = signalModel.Quantity.InexactFloat64()
+
+	// Place order
+	if err := b.orderManager.PlaceOrder(ctx, &signal); err != nil {
+		b.logger.WithError(err).Error("Failed to place order")
+		return err
+	}
+
+	return nil
+}
+
+// handleStrategyConfigUpdated hot-reloads the parameters of a running strategy
+func (b *Bot) handleStrategyConfigUpdated(event *events.Event) error {
+	var update events.StrategyConfigUpdatedEvent
+	if err := json.Unmarshal(event.Data, &update); err != nil {
+		b.logger.WithError(err).Error("Failed to unmarshal strategy config update")
+		return err
+	}
+
+	strategyID, err := uuid.Parse(update.StrategyID)
+	if err != nil {
+		return fmt.Errorf("invalid strategy ID: %w", err)
+	}
+
+	meanReversionStrategy := b.strategyByID(strategyID)
+	if meanReversionStrategy == nil {
+		return nil // Strategy is run by another bot instance
+	}
+
+	params, err := strategy.ParseMeanReversionParams(update.Config)
+	if err != nil {
+		return fmt.Errorf("rejected config for strategy %s: %w", strategyID, err)
+	}
+
+	return meanReversionStrategy.UpdateParams(params)
+}
+
+// handleStrategyToggled enables or disables a running strategy, optionally
+// closing its open positions when it is disabled
+func (b *Bot) handleStrategyToggled(ctx context.Context, event *events.Event) error {
+	var toggle events.StrategyToggledEvent
+	if err := json.Unmarshal(event.Data, &toggle); err != nil {
+		b.logger.WithError(err).Error("Failed to unmarshal strategy toggle")
+		return err
+	}
+
+	strategyID, err := uuid.Parse(toggle.StrategyID)
+	if err != nil {
+		return fmt.Errorf("invalid strategy ID: %w", err)
+	}
+
+	meanReversionStrategy := b.strategyByID(strategyID)
+	if meanReversionStrategy == nil {
+		return nil // Strategy is run by another bot instance
+	}
+
+	meanReversionStrategy.SetActive(toggle.Enabled)
+
+	b.logger.WithFields(logrus.Fields{
+		"strategy_id":     strategyID,
+		"symbol":          meanReversionStrategy.Symbol(),
+		"enabled":         toggle.Enabled,
+		"close_positions": toggle.ClosePositions,
+	}).Info("Strategy toggled")
+
+	if !toggle.Enabled && toggle.ClosePositions {
+		return b.closeRequestedPositions(ctx, strategyID)
+	}
+
+	return nil
+}
+
+// closeRequestedPositions closes the open trades of a disabled strategy if a
+// close was requested and no one has acted on it yet. The request is claimed
+// before closing so the toggle event and the periodic sync don't both close.
+func (b *Bot) closeRequestedPositions(ctx context.Context, strategyID uuid.UUID) error {
+	result, err := b.db.ExecContext(ctx, `
+		UPDATE strategies SET close_positions = false
+		WHERE id = $1 AND close_positions AND NOT is_active
+	`, strategyID)
+	if err != nil {
+		return fmt.Errorf("failed to claim close request for strategy %s: %w", strategyID, err)
+	}
+	if claimed, _ := result.RowsAffected(); claimed == 0 {
+		return nil
+	}
+
+	if err := b.riskManager.CloseOpenTrades(ctx, strategyID, "Strategy disabled", models.ExitReasonManual); err != nil {
+		// Put the request back for the next sync
+		if _, resetErr := b.db.ExecContext(ctx, `
+			UPDATE strategies SET close_positions = true WHERE id = $1 AND NOT is_active
+		`, strategyID); resetErr != nil {
+			b.logger.WithError(resetErr).WithField("strategy_id", strategyID).Error("Failed to restore close request")
+		}
+		return fmt.Errorf("failed to close trades for strategy %s: %w", strategyID, err)
+	}
+
+	return nil
+}
+
+// syncStrategyStates refreshes each strategy's active flag from the database,
+// covering toggles missed while the bot was disconnected from the bus, and
+// acts on close requests stored with disabled strategies
+func (b *Bot) syncStrategyStates(ctx context.Context) {
+	for _, meanReversionStrategy := range b.strategies {
+		var isActive, closePositions bool
+		err := b.db.QueryRowContext(ctx, `
+			SELECT is_active, close_positions FROM strategies WHERE id = $1
+		`, meanReversionStrategy.StrategyID()).Scan(&isActive, &closePositions)
+		if err != nil {
+			b.logger.WithError(err).WithField("symbol", meanReversionStrategy.Symbol()).Error("Failed to load strategy state")
+			continue
+		}
+
+		if meanReversionStrategy.IsActive() != isActive {
+			b.logger.WithFields(logrus.Fields{
+				"symbol":  meanReversionStrategy.Symbol(),
+				"enabled": isActive,
+			}).Info("Strategy state changed in database")
+			meanReversionStrategy.SetActive(isActive)
+		}
+
+		if !isActive && closePositions {
+			if err := b.closeRequestedPositions(ctx, meanReversionStrategy.StrategyID()); err != nil {
+				b.logger.WithError(err).WithField("symbol", meanReversionStrategy.Symbol()).Error("Failed to close positions of disabled strategy")
+			}
+		}
+	}
+}
+
+// reloadRiskLimits loads the runtime risk limits and hands each strategy its own
+func (b *Bot) reloadRiskLimits(ctx context.Context) error {
+	if err := b.riskManager.ReloadLimits(ctx); err != nil {
+		return err
+	}
+
+	for _, meanReversionStrategy := range b.strategies {
+		meanReversionStrategy.SetRiskConfig(b.riskManager.Limits(meanReversionStrategy.StrategyID()))
+	}
+
+	return nil
+}
+
+// strategyByID returns the strategy instance with the given ID, or nil
+func (b *Bot) strategyByID(strategyID uuid.UUID) *strategy.MeanReversionStrategy {
+	for _, meanReversionStrategy := range b.strategies {
+		if meanReversionStrategy.StrategyID() == strategyID {
+			return meanReversionStrategy
+		}
+	}
+	return nil
+}
+
+// runRiskMonitor periodically checks open trades, circuit breakers, strategy
+// states, unresolved orders and balances
+func (b *Bot) runRiskMonitor(ctx context.Context) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.riskManager.CheckOpenTrades(ctx); err != nil {
+				b.logger.WithError(err).Error("Failed to check open trades")
+			}
+			if err := b.riskManager.CheckCircuitBreakers(ctx); err != nil {
+				b.logger.WithError(err).Error("Failed to check circuit breakers")
+			}
+			b.syncStrategyStates(ctx)
+			if err := b.orderManager.RecoverPendingOrders(ctx); err != nil {
+				b.logger.WithError(err).Error("Failed to recover pending orders")
+			}
+			if err := b.orderManager.ReconcileOpenOrders(ctx); err != nil {
+				b.logger.WithError(err).Error("Failed to reconcile open orders")
+			}
+			if err := b.riskManager.SyncBalances(ctx); err != nil {
+				b.logger.WithError(err).Error("Failed to sync balances")
+			}
+		}
+	}
+}
+
+// getOrCreateStrategy gets or creates the strategy for a symbol in the database
+func getOrCreateStrategy(db *sql.DB, cfg *config.Config, symbol string, lgr *logrus.Logger) (uuid.UUID, error) {
+	// Try to get existing strategy
+	var strategyID uuid.UUID
+	err := db.QueryRow(`
+		SELECT id FROM strategies WHERE name = $1 AND symbol = $2
+	`, "mean-reversion", symbol).Scan(&strategyID)
+
+	if err == sql.ErrNoRows {
+		// Adopt a strategy created before strategies were per symbol
+		err = db.QueryRow(`
+			UPDATE strategies SET symbol = $2
+			WHERE id = (
+				SELECT id FROM strategies
+				WHERE name = $1 AND symbol IS NULL
+				ORDER BY created_at
+				LIMIT 1
+			)
+			RETURNING id
+		`, "mean-reversion", symbol).Scan(&strategyID)
+	}
+
+	if err == sql.ErrNoRows {
+		// Create new strategy
+		strategyID = uuid.New()
+		configJSON, _ := json.Marshal(strategy.DefaultMeanReversionParams())
+
+		_, err = db.Exec(`
+			INSERT INTO strategies (id, name, type, symbol, config, is_active)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, strategyID, "mean-reversion", "mean_reversion", symbol, configJSON, cfg.Strategy.Enabled)
+
+		if err != nil {
+			return uuid.Nil, err
+		}
+
+		lgr.WithField("symbol", symbol).Info("Created new mean reversion strategy")
+	} else if err != nil {
+		return uuid.Nil, err
+	}
+
+	return strategyID, nil
+}
+
+// loadStrategy reads and validates the parameters stored in a strategy's
+// config along with whether the strategy is active
+func loadStrategy(db *sql.DB, strategyID uuid.UUID) (strategy.MeanReversionParams, bool, error) {
+	var configJSON []byte
+	var isActive bool
+	err := db.QueryRow(`
+		SELECT config, is_active FROM strategies WHERE id = $1
+	`, strategyID).Scan(&configJSON, &isActive)
+	if err != nil {
+		return strategy.MeanReversionParams{}, false, err
+	}
+
+	params, err := strategy.ParseMeanReversionParams(configJSON)
+	return params, isActive, err
+}
+
+// initializePaperBalance initializes paper trading balance
+func initializePaperBalance(db *sql.DB, cfg *config.Config, lgr *logrus.Logger) {
+	// Get or create exchange
+	var exchangeID uuid.UUID
+	err := db.QueryRow(`
+		SELECT id FROM exchanges WHERE name = 'paper' AND is_paper_trading = true
+	`).Scan(&exchangeID)
+
+	if err == sql.ErrNoRows {
+		exchangeID = uuid.New()
+		_, err = db.Exec(`
+			INSERT INTO exchanges (id, name, api_key_encrypted, api_secret_encrypted, is_paper_trading, is_active)
+			VALUES ($1, 'paper', 'none', 'none', true, true)
+		`, exchangeID)
+
+		if err != nil {
+			lgr.WithError(err).Error("Failed to create paper exchange")
+			return
+		}
+	}
+
+	// Initialize USD balance until the first balance sync
+	_, err = db.Exec(`
+		INSERT INTO balances (exchange_id, currency, available, locked)
+		VALUES ($1, 'USD', $2, 0)
+		ON CONFLICT (exchange_id, currency) DO NOTHING
+	`, exchangeID, cfg.Trading.PaperInitialBalanceUSD)
+
+	if err != nil {
+		lgr.WithError(err).Error("Failed to initialize paper balance")
+	} else {
+		lgr.WithField("balance_usd", cfg.Trading.PaperInitialBalanceUSD).Info("Paper trading balance initialized")
+	}
+}
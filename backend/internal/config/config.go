@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -11,14 +12,15 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	Database DatabaseConfig
-	NATS     NATSConfig
-	Coinbase CoinbaseConfig
-	Trading  TradingConfig
-	Risk     RiskConfig
-	Strategy StrategyConfig
-	API      APIConfig
-	Logging  LoggingConfig
+	Database  DatabaseConfig
+	NATS      NATSConfig
+	Coinbase  CoinbaseConfig
+	Trading   TradingConfig
+	Risk      RiskConfig
+	Strategy  StrategyConfig
+	Portfolio PortfolioConfig
+	API       APIConfig
+	Logging   LoggingConfig
 }
 
 // DatabaseConfig holds database connection configuration
@@ -41,26 +43,95 @@ type CoinbaseConfig struct {
 
 // TradingConfig holds trading mode configuration
 type TradingConfig struct {
-	Mode string // "paper" or "live"
+	Mode      string // "paper" or "live"
+	LotMethod string // How exits are matched to open lots: FIFO, LIFO or AVERAGE
+
+	// USD the paper venue starts with; its state is kept in the database
+	// afterwards
+	PaperInitialBalanceUSD float64
+
+	// Prices paper orders execute at: "simulated" random walks, or
+	// "coinbase" market data followed from the price feed
+	PaperPriceSource string
+
+	// JSON fill model of the paper venue: default spread, impact, latency,
+	// volume cap and queue parameters and per-symbol overrides. Empty uses
+	// the default model.
+	PaperFillModel string
 }
 
 // RiskConfig holds risk management parameters
 type RiskConfig struct {
-	MaxPositionSizeUSD    float64
+	MaxPositionSizeUSD    float64 // Per entry order
 	MaxOpenPositions      int
 	DailyLossLimitPercent float64
 	StopLossPercent       float64
 	MaxHoldTimeHours      int
-	MinBalanceUSD         float64
+	MinBalanceUSD         float64 // USD cash to keep after an entry
+
+	// Notional of a position across all its tranches, at entry prices;
+	// zero means the max position size
+	MaxPositionNotionalUSD float64
+
+	// Pre-trade checks against the latest tick; zero disables a check
+	MaxPriceDeviationPercent float64 // Signal price vs market price
+	MaxPriceAgeSeconds       int
+
+	// Entry orders per minute; zero disables a limit
+	MaxOrdersPerMinuteStrategy int
+	MaxOrdersPerMinuteExchange int
+
+	// Timezone whose midnight starts the trading day for daily loss limits
+	TradingDayTimezone string
+
+	// Circuit breakers pausing a single strategy; zero disables a breaker
+	MaxDrawdownPercent        float64 // Peak-to-trough of realized equity
+	DrawdownCooldownHours     int
+	LossStreakLength          int // Consecutive losing trades
+	LossStreakCooldownMinutes int
+
+	// Max loss if an entry's stop-loss is hit, as a percentage of equity
+	MaxRiskPerTradePercent float64
+
+	// Portfolio limits across strategies; zero disables a limit
+	MaxGrossExposureUSD      float64
+	MaxSymbolExposureUSD     float64
+	MaxAssetSharePercent     float64 // Of equity, per base currency
+	MaxCorrelatedExposureUSD float64
+	CorrelationThreshold     float64 // Positions correlated above this count together
+	CorrelationLookback      int     // Number of 1m candles
+
+	// Portfolio VaR from 1m returns; a max VaR of zero disables the pre-trade check
+	MaxVaRUSD         float64
+	VaRMethod         string  // "historical" or "parametric"
+	VaRConfidence     float64 // e.g. 0.99
+	VaRHorizonMinutes int
+	VaRLookback       int // Number of 1m candles
+
+	// Trailing stop applied to every open trade: "none", "percent" or "atr"
+	TrailingStopType        string
+	TrailingStopPercent     float64
+	TrailingStopATRMultiple float64
+	TrailingStopATRPeriod   int
+
+	// Exchange balances that differ from the balances expected from fills by
+	// more than this percentage raise a drift risk event
+	BalanceDriftPercent float64
 }
 
 // StrategyConfig holds strategy configuration
 type StrategyConfig struct {
 	Enabled   bool
-	Symbol    string
+	Symbols   []string // One strategy instance runs per symbol
 	Timeframe string
 }
 
+// PortfolioConfig holds portfolio valuation configuration
+type PortfolioConfig struct {
+	ReportingCurrency string   // Currency the portfolio is valued and reported in
+	Stablecoins       []string // Valued at par with USD
+}
+
 // APIConfig holds API server configuration
 type APIConfig struct {
 	Port      string
@@ -92,7 +163,12 @@ func Load() (*Config, error) {
 			UseSandbox:    getEnvBool("COINBASE_USE_SANDBOX", true),
 		},
 		Trading: TradingConfig{
-			Mode: getEnv("TRADING_MODE", "paper"),
+			Mode:      getEnv("TRADING_MODE", "paper"),
+			LotMethod: getEnv("TRADING_LOT_METHOD", "FIFO"),
+
+			PaperInitialBalanceUSD: getEnvFloat("TRADING_PAPER_INITIAL_BALANCE_USD", 10000),
+			PaperPriceSource:       getEnv("TRADING_PAPER_PRICE_SOURCE", "simulated"),
+			PaperFillModel:         getEnv("TRADING_PAPER_FILL_MODEL", ""),
 		},
 		Risk: RiskConfig{
 			MaxPositionSizeUSD:    getEnvFloat("RISK_MAX_POSITION_SIZE_USD", 100.0),
@@ -101,12 +177,53 @@ func Load() (*Config, error) {
 			StopLossPercent:       getEnvFloat("RISK_STOP_LOSS_PERCENT", 2.0),
 			MaxHoldTimeHours:      getEnvInt("RISK_MAX_HOLD_TIME_HOURS", 24),
 			MinBalanceUSD:         getEnvFloat("RISK_MIN_BALANCE_USD", 50.0),
+
+			MaxPositionNotionalUSD: getEnvFloat("RISK_MAX_POSITION_NOTIONAL_USD", 0),
+
+			MaxPriceDeviationPercent: getEnvFloat("RISK_MAX_PRICE_DEVIATION_PERCENT", 0),
+			MaxPriceAgeSeconds:       getEnvInt("RISK_MAX_PRICE_AGE_SECONDS", 0),
+
+			MaxOrdersPerMinuteStrategy: getEnvInt("RISK_MAX_ORDERS_PER_MINUTE_STRATEGY", 0),
+			MaxOrdersPerMinuteExchange: getEnvInt("RISK_MAX_ORDERS_PER_MINUTE_EXCHANGE", 0),
+
+			TradingDayTimezone: getEnv("RISK_TRADING_DAY_TIMEZONE", "UTC"),
+
+			MaxDrawdownPercent:        getEnvFloat("RISK_MAX_DRAWDOWN_PERCENT", 0),
+			DrawdownCooldownHours:     getEnvInt("RISK_DRAWDOWN_COOLDOWN_HOURS", 24),
+			LossStreakLength:          getEnvInt("RISK_LOSS_STREAK_LENGTH", 0),
+			LossStreakCooldownMinutes: getEnvInt("RISK_LOSS_STREAK_COOLDOWN_MINUTES", 60),
+
+			MaxRiskPerTradePercent: getEnvFloat("RISK_MAX_RISK_PER_TRADE_PERCENT", 0),
+
+			MaxGrossExposureUSD:      getEnvFloat("RISK_MAX_GROSS_EXPOSURE_USD", 0),
+			MaxSymbolExposureUSD:     getEnvFloat("RISK_MAX_SYMBOL_EXPOSURE_USD", 0),
+			MaxAssetSharePercent:     getEnvFloat("RISK_MAX_ASSET_SHARE_PERCENT", 0),
+			MaxCorrelatedExposureUSD: getEnvFloat("RISK_MAX_CORRELATED_EXPOSURE_USD", 0),
+			CorrelationThreshold:     getEnvFloat("RISK_CORRELATION_THRESHOLD", 0.7),
+			CorrelationLookback:      getEnvInt("RISK_CORRELATION_LOOKBACK", 240),
+
+			MaxVaRUSD:         getEnvFloat("RISK_MAX_VAR_USD", 0),
+			VaRMethod:         getEnv("RISK_VAR_METHOD", "historical"),
+			VaRConfidence:     getEnvFloat("RISK_VAR_CONFIDENCE", 0.99),
+			VaRHorizonMinutes: getEnvInt("RISK_VAR_HORIZON_MINUTES", 60),
+			VaRLookback:       getEnvInt("RISK_VAR_LOOKBACK", 1440),
+
+			TrailingStopType:        getEnv("RISK_TRAILING_STOP_TYPE", "none"),
+			TrailingStopPercent:     getEnvFloat("RISK_TRAILING_STOP_PERCENT", 1.5),
+			TrailingStopATRMultiple: getEnvFloat("RISK_TRAILING_STOP_ATR_MULTIPLE", 3.0),
+			TrailingStopATRPeriod:   getEnvInt("RISK_TRAILING_STOP_ATR_PERIOD", 14),
+
+			BalanceDriftPercent: getEnvFloat("RISK_BALANCE_DRIFT_PERCENT", 0.1),
 		},
 		Strategy: StrategyConfig{
 			Enabled:   getEnvBool("STRATEGY_ENABLED", false),
-			Symbol:    getEnv("STRATEGY_SYMBOL", "BTC-USD"),
+			Symbols:   getEnvList("STRATEGY_SYMBOLS", []string{getEnv("STRATEGY_SYMBOL", "BTC-USD")}),
 			Timeframe: getEnv("STRATEGY_TIMEFRAME", "1m"),
 		},
+		Portfolio: PortfolioConfig{
+			ReportingCurrency: strings.ToUpper(getEnv("PORTFOLIO_REPORTING_CURRENCY", "USD")),
+			Stablecoins:       getEnvList("PORTFOLIO_STABLECOINS", []string{"USDC", "USDT", "DAI"}),
+		},
 		API: APIConfig{
 			Port:      getEnv("API_PORT", "8080"),
 			JWTSecret: getEnv("API_JWT_SECRET", "change_me_in_production"),
@@ -132,23 +249,134 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid trading mode: %s (must be 'paper' or 'live')", c.Trading.Mode)
 	}
 
+	switch c.Trading.LotMethod {
+	case "FIFO", "LIFO", "AVERAGE":
+	default:
+		return fmt.Errorf("invalid lot method: %s (must be 'FIFO', 'LIFO' or 'AVERAGE')", c.Trading.LotMethod)
+	}
+
+	if c.Trading.PaperInitialBalanceUSD <= 0 {
+		return fmt.Errorf("paper initial balance must be positive")
+	}
+	if c.Trading.PaperPriceSource != "simulated" && c.Trading.PaperPriceSource != "coinbase" {
+		return fmt.Errorf("invalid paper price source: %s (must be 'simulated' or 'coinbase')", c.Trading.PaperPriceSource)
+	}
+
 	// Validate risk parameters
-	if c.Risk.MaxPositionSizeUSD <= 0 {
+	if err := c.Risk.Validate(); err != nil {
+		return err
+	}
+
+	// Validate strategy symbols
+	if len(c.Strategy.Symbols) == 0 {
+		return fmt.Errorf("at least one strategy symbol is required")
+	}
+
+	if c.Portfolio.ReportingCurrency == "" {
+		return fmt.Errorf("portfolio reporting currency is required")
+	}
+
+	// Validate database URL
+	if c.Database.URL == "" {
+		return fmt.Errorf("database URL is required")
+	}
+
+	return nil
+}
+
+// Validate validates the risk parameters
+func (c *RiskConfig) Validate() error {
+	if c.MaxPositionSizeUSD <= 0 {
 		return fmt.Errorf("max position size must be positive")
 	}
-	if c.Risk.MaxOpenPositions <= 0 {
+	if c.MaxOpenPositions <= 0 {
 		return fmt.Errorf("max open positions must be positive")
 	}
-	if c.Risk.DailyLossLimitPercent <= 0 || c.Risk.DailyLossLimitPercent > 100 {
+	if c.MaxPositionNotionalUSD < 0 {
+		return fmt.Errorf("max position notional must not be negative")
+	}
+	if c.DailyLossLimitPercent <= 0 || c.DailyLossLimitPercent > 100 {
 		return fmt.Errorf("daily loss limit must be between 0 and 100")
 	}
-	if c.Risk.StopLossPercent <= 0 || c.Risk.StopLossPercent > 100 {
+	if c.StopLossPercent <= 0 || c.StopLossPercent > 100 {
 		return fmt.Errorf("stop loss percent must be between 0 and 100")
 	}
-
-	// Validate database URL
-	if c.Database.URL == "" {
-		return fmt.Errorf("database URL is required")
+	if c.MinBalanceUSD < 0 {
+		return fmt.Errorf("min balance must not be negative")
+	}
+	if c.MaxPriceDeviationPercent < 0 || c.MaxPriceDeviationPercent > 100 {
+		return fmt.Errorf("max price deviation percent must be between 0 and 100")
+	}
+	if c.MaxPriceAgeSeconds < 0 {
+		return fmt.Errorf("max price age must not be negative")
+	}
+	if c.MaxOrdersPerMinuteStrategy < 0 || c.MaxOrdersPerMinuteExchange < 0 {
+		return fmt.Errorf("order rate limits must not be negative")
+	}
+	if _, err := time.LoadLocation(c.TradingDayTimezone); err != nil {
+		return fmt.Errorf("invalid trading day timezone: %s", c.TradingDayTimezone)
+	}
+	if c.MaxDrawdownPercent < 0 || c.MaxDrawdownPercent > 100 {
+		return fmt.Errorf("max drawdown percent must be between 0 and 100")
+	}
+	if c.DrawdownCooldownHours < 0 || c.LossStreakLength < 0 || c.LossStreakCooldownMinutes < 0 {
+		return fmt.Errorf("circuit breaker settings must not be negative")
+	}
+	// A zero cooldown would trip and expire at once, never pausing the strategy
+	if c.MaxDrawdownPercent > 0 && c.DrawdownCooldownHours == 0 {
+		return fmt.Errorf("drawdown cooldown must be positive when the drawdown breaker is enabled")
+	}
+	if c.LossStreakLength > 0 && c.LossStreakCooldownMinutes == 0 {
+		return fmt.Errorf("loss streak cooldown must be positive when the loss streak breaker is enabled")
+	}
+	if c.MaxRiskPerTradePercent < 0 || c.MaxRiskPerTradePercent > 100 {
+		return fmt.Errorf("max risk per trade percent must be between 0 and 100")
+	}
+	if c.MaxGrossExposureUSD < 0 || c.MaxSymbolExposureUSD < 0 || c.MaxCorrelatedExposureUSD < 0 {
+		return fmt.Errorf("exposure limits must not be negative")
+	}
+	if c.MaxAssetSharePercent < 0 || c.MaxAssetSharePercent > 100 {
+		return fmt.Errorf("max asset share percent must be between 0 and 100")
+	}
+	if c.CorrelationThreshold <= 0 || c.CorrelationThreshold > 1 {
+		return fmt.Errorf("correlation threshold must be greater than 0 and at most 1")
+	}
+	if c.CorrelationLookback < 2 {
+		return fmt.Errorf("correlation lookback must be at least 2")
+	}
+	if c.MaxVaRUSD < 0 {
+		return fmt.Errorf("max VaR must not be negative")
+	}
+	if c.VaRMethod != "historical" && c.VaRMethod != "parametric" {
+		return fmt.Errorf("invalid VaR method: %s (must be 'historical' or 'parametric')", c.VaRMethod)
+	}
+	if c.VaRConfidence <= 0.5 || c.VaRConfidence >= 1 {
+		return fmt.Errorf("VaR confidence must be between 0.5 and 1")
+	}
+	if c.VaRHorizonMinutes < 1 {
+		return fmt.Errorf("VaR horizon must be at least 1 minute")
+	}
+	if c.VaRLookback < 2 {
+		return fmt.Errorf("VaR lookback must be at least 2")
+	}
+	if c.BalanceDriftPercent < 0 || c.BalanceDriftPercent > 100 {
+		return fmt.Errorf("balance drift percent must be between 0 and 100")
+	}
+	switch c.TrailingStopType {
+	case "none":
+	case "percent":
+		if c.TrailingStopPercent <= 0 || c.TrailingStopPercent > 100 {
+			return fmt.Errorf("trailing stop percent must be between 0 and 100")
+		}
+	case "atr":
+		if c.TrailingStopATRMultiple <= 0 {
+			return fmt.Errorf("trailing stop ATR multiple must be positive")
+		}
+		if c.TrailingStopATRPeriod <= 0 {
+			return fmt.Errorf("trailing stop ATR period must be positive")
+		}
+	default:
+		return fmt.Errorf("invalid trailing stop type: %s (must be 'none', 'percent' or 'atr')", c.TrailingStopType)
 	}
 
 	return nil
@@ -159,11 +387,35 @@ func (c *Config) IsPaperTrading() bool {
 	return c.Trading.Mode == "paper"
 }
 
+// UsesSimulatedPrices returns true if paper trading runs on simulated prices
+// rather than live market data
+func (c *Config) UsesSimulatedPrices() bool {
+	return c.IsPaperTrading() && c.Trading.PaperPriceSource == "simulated"
+}
+
+// PositionNotionalLimit returns the max notional of a position across its
+// tranches
+func (c *RiskConfig) PositionNotionalLimit() float64 {
+	if c.MaxPositionNotionalUSD > 0 {
+		return c.MaxPositionNotionalUSD
+	}
+	return c.MaxPositionSizeUSD
+}
+
 // GetMaxHoldDuration returns the maximum hold duration
 func (c *Config) GetMaxHoldDuration() time.Duration {
 	return time.Duration(c.Risk.MaxHoldTimeHours) * time.Hour
 }
 
+// TradingDayLocation returns the timezone of the trading day, UTC if invalid
+func (c *RiskConfig) TradingDayLocation() *time.Location {
+	location, err := time.LoadLocation(c.TradingDayTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
 // Helper functions to get environment variables with defaults
 
 func getEnv(key, defaultValue string) string {
@@ -198,6 +450,20 @@ func getEnvInt(key string, defaultValue int) int {
 	return intValue
 }
 
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	list := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func getEnvFloat(key string, defaultValue float64) float64 {
 	value := os.Getenv(key)
 	if value == "" {
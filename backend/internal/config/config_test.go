@@ -0,0 +1,73 @@
+package config
+
+import "testing"
+
+func TestRiskConfigValidateBreakers(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *RiskConfig)
+		wantErr bool
+	}{
+		{"defaults", func(c *RiskConfig) {}, false},
+		{
+			name: "drawdown breaker with cooldown",
+			modify: func(c *RiskConfig) {
+				c.MaxDrawdownPercent, c.DrawdownCooldownHours = 10, 24
+			},
+		},
+		{
+			name: "drawdown breaker without cooldown",
+			modify: func(c *RiskConfig) {
+				c.MaxDrawdownPercent, c.DrawdownCooldownHours = 10, 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "disabled drawdown breaker without cooldown",
+			modify: func(c *RiskConfig) {
+				c.MaxDrawdownPercent, c.DrawdownCooldownHours = 0, 0
+			},
+		},
+		{
+			name: "loss streak breaker with cooldown",
+			modify: func(c *RiskConfig) {
+				c.LossStreakLength, c.LossStreakCooldownMinutes = 3, 60
+			},
+		},
+		{
+			name: "loss streak breaker without cooldown",
+			modify: func(c *RiskConfig) {
+				c.LossStreakLength, c.LossStreakCooldownMinutes = 3, 0
+			},
+			wantErr: true,
+		},
+		{
+			name: "disabled loss streak breaker without cooldown",
+			modify: func(c *RiskConfig) {
+				c.LossStreakLength, c.LossStreakCooldownMinutes = 0, 0
+			},
+		},
+		{
+			name: "negative cooldown",
+			modify: func(c *RiskConfig) {
+				c.DrawdownCooldownHours = -1
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			risk := cfg.Risk
+			tt.modify(&risk)
+			if err := risk.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
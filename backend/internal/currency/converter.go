@@ -0,0 +1,237 @@
+package currency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// priceCacheTTL is how long a price loaded from price_data is reused
+const priceCacheTTL = 30 * time.Second
+
+// ErrNoRate is returned when no route between two currencies has a price
+var ErrNoRate = errors.New("no conversion rate")
+
+// hubs are the currencies tried as the middle leg of two-hop routes, before
+// the stablecoins
+var hubs = []string{"USD", "BTC", "ETH"}
+
+// Split returns the base and quote currency of a symbol, e.g. "BTC-USD" ->
+// "BTC", "USD". A symbol without a separator is quoted in USD.
+func Split(symbol string) (string, string) {
+	for i := 0; i < len(symbol); i++ {
+		if symbol[i] == '-' || symbol[i] == '/' {
+			return symbol[:i], symbol[i+1:]
+		}
+	}
+	return symbol, "USD"
+}
+
+// cachedPrice is a price loaded from price_data; nil if the symbol has none
+type cachedPrice struct {
+	price    *decimal.Decimal
+	loadedAt time.Time
+}
+
+// Converter converts amounts between currencies at the latest prices. A
+// rate comes from a symbol quoting the pair either way, or from a route
+// through one hub currency, e.g. SOL -> BTC -> USD. Stablecoins are valued
+// at par with USD.
+type Converter struct {
+	db          *sql.DB
+	stablecoins []string
+	stable      map[string]bool
+
+	mu     sync.Mutex
+	ticks  map[string]decimal.Decimal // Latest tick per symbol
+	closes map[string]cachedPrice     // Latest 1m close per symbol
+}
+
+// NewConverter creates a converter treating the given stablecoins as USD. A
+// converter without a database converts at the price updates it receives.
+func NewConverter(db *sql.DB, stablecoins []string) *Converter {
+	c := &Converter{
+		db:     db,
+		stable: make(map[string]bool, len(stablecoins)),
+		ticks:  make(map[string]decimal.Decimal),
+		closes: make(map[string]cachedPrice),
+	}
+	for _, coin := range stablecoins {
+		coin = strings.ToUpper(coin)
+		if coin != "" && coin != "USD" && !c.stable[coin] {
+			c.stable[coin] = true
+			c.stablecoins = append(c.stablecoins, coin)
+		}
+	}
+	return c
+}
+
+// OnPriceUpdate records the latest price of a symbol
+func (c *Converter) OnPriceUpdate(symbol string, price decimal.Decimal) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ticks[symbol] = price
+}
+
+// Convert converts an amount from one currency to another
+func (c *Converter) Convert(ctx context.Context, amount decimal.Decimal, from, to string) (decimal.Decimal, error) {
+	if amount.IsZero() {
+		return decimal.Zero, nil
+	}
+
+	rate, err := c.Rate(ctx, from, to)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return amount.Mul(rate), nil
+}
+
+// Rate returns the price of one unit of from in to
+func (c *Converter) Rate(ctx context.Context, from, to string) (decimal.Decimal, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if c.Par(from, to) {
+		return decimal.NewFromInt(1), nil
+	}
+
+	rate, err := c.directRate(ctx, from, to)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if rate != nil {
+		return *rate, nil
+	}
+
+	for _, hub := range c.hubs() {
+		if c.Par(hub, from) || c.Par(hub, to) {
+			continue
+		}
+
+		first, err := c.directRate(ctx, from, hub)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		if first == nil {
+			continue
+		}
+
+		second, err := c.directRate(ctx, hub, to)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		if second != nil {
+			return first.Mul(*second), nil
+		}
+	}
+
+	return decimal.Zero, fmt.Errorf("%w from %s to %s", ErrNoRate, from, to)
+}
+
+// Par reports whether two currencies are worth the same: the same currency,
+// or USD and stablecoins
+func (c *Converter) Par(a, b string) bool {
+	a, b = strings.ToUpper(a), strings.ToUpper(b)
+	return a == b || (c.isUSD(a) && c.isUSD(b))
+}
+
+// isUSD reports whether a currency is USD or a stablecoin
+func (c *Converter) isUSD(currency string) bool {
+	return currency == "USD" || c.stable[currency]
+}
+
+// hubs returns the middle legs of two-hop routes
+func (c *Converter) hubs() []string {
+	result := make([]string, 0, len(hubs)+len(c.stablecoins))
+	result = append(result, hubs...)
+	return append(result, c.stablecoins...)
+}
+
+// directRate returns the rate from a symbol quoting the pair either way,
+// with stablecoins standing in for USD, or nil if there is none
+func (c *Converter) directRate(ctx context.Context, from, to string) (*decimal.Decimal, error) {
+	for _, base := range c.equivalents(from) {
+		for _, quote := range c.equivalents(to) {
+			price, err := c.Price(ctx, base+"-"+quote)
+			if err != nil {
+				return nil, err
+			}
+			if price != nil {
+				return price, nil
+			}
+
+			inverse, err := c.Price(ctx, quote+"-"+base)
+			if err != nil {
+				return nil, err
+			}
+			if inverse != nil && inverse.IsPositive() {
+				rate := decimal.NewFromInt(1).Div(*inverse)
+				return &rate, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// equivalents returns the currencies worth the same as a currency, itself first
+func (c *Converter) equivalents(currency string) []string {
+	if !c.isUSD(currency) {
+		return []string{currency}
+	}
+
+	result := []string{currency}
+	if currency != "USD" {
+		result = append(result, "USD")
+	}
+	for _, coin := range c.stablecoins {
+		if coin != currency {
+			result = append(result, coin)
+		}
+	}
+	return result
+}
+
+// Price returns the latest tick of a symbol, falling back to its latest 1m
+// close, or nil if it has neither
+func (c *Converter) Price(ctx context.Context, symbol string) (*decimal.Decimal, error) {
+	c.mu.Lock()
+	if tick, exists := c.ticks[symbol]; exists {
+		c.mu.Unlock()
+		return &tick, nil
+	}
+	if cached, exists := c.closes[symbol]; exists && time.Since(cached.loadedAt) < priceCacheTTL {
+		c.mu.Unlock()
+		return cached.price, nil
+	}
+	c.mu.Unlock()
+
+	if c.db == nil {
+		return nil, nil
+	}
+
+	var price *decimal.Decimal
+	var closePrice decimal.Decimal
+	err := c.db.QueryRowContext(ctx, `
+		SELECT close FROM price_data
+		WHERE symbol = $1 AND interval = '1m'
+		ORDER BY time DESC
+		LIMIT 1
+	`, symbol).Scan(&closePrice)
+	switch {
+	case err == nil:
+		price = &closePrice
+	case err != sql.ErrNoRows:
+		return nil, fmt.Errorf("failed to get price of %s: %w", symbol, err)
+	}
+
+	c.mu.Lock()
+	c.closes[symbol] = cachedPrice{price: price, loadedAt: time.Now()}
+	c.mu.Unlock()
+
+	return price, nil
+}
@@ -0,0 +1,117 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func newTestConverter() *Converter {
+	c := NewConverter(nil, []string{"usdc", "USDT", "USD", ""})
+	for symbol, price := range map[string]string{
+		"BTC-USD":  "50000",
+		"ETH-BTC":  "0.05",
+		"SOL-BTC":  "0.002",
+		"ADA-USDT": "0.5",
+		"USD-EUR":  "0.8",
+	} {
+		c.OnPriceUpdate(symbol, dec(price))
+	}
+	return c
+}
+
+func TestConverterRate(t *testing.T) {
+	c := newTestConverter()
+
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{"same currency", "SOL", "SOL", "1"},
+		{"direct", "BTC", "USD", "50000"},
+		{"inverse", "USD", "BTC", "0.00002"},
+		{"inverse of a fiat pair", "EUR", "USD", "1.25"},
+		{"two hops", "SOL", "USD", "100"},
+		{"two hops inverse", "USD", "SOL", "0.01"},
+		{"two hops through BTC", "ETH", "USD", "2500"},
+		{"two hops between alts", "SOL", "ETH", "0.04"},
+		{"stablecoin at par", "USDC", "USD", "1"},
+		{"stablecoins at par", "usdc", "usdt", "1"},
+		{"quoted in a stablecoin", "ADA", "USD", "0.5"},
+		{"quoted in another stablecoin", "ADA", "USDC", "0.5"},
+		{"USD quote for a stablecoin", "BTC", "USDT", "50000"},
+		{"lower case", "btc", "usd", "50000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Rate(context.Background(), tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Rate(%s, %s): %v", tt.from, tt.to, err)
+			}
+			if !got.Equal(dec(tt.want)) {
+				t.Errorf("Rate(%s, %s) = %s, want %s", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverterNoRate(t *testing.T) {
+	c := newTestConverter()
+
+	for _, pair := range [][2]string{
+		{"DOGE", "USD"},
+		{"USD", "DOGE"},
+		{"EUR", "SOL"}, // Needs three hops
+	} {
+		if rate, err := c.Rate(context.Background(), pair[0], pair[1]); !errors.Is(err, ErrNoRate) {
+			t.Errorf("Rate(%s, %s) = %s, %v, want ErrNoRate", pair[0], pair[1], rate, err)
+		}
+	}
+}
+
+func TestConverterConvert(t *testing.T) {
+	c := newTestConverter()
+
+	got, err := c.Convert(context.Background(), dec("2.5"), "SOL", "USD")
+	if err != nil || !got.Equal(dec("250")) {
+		t.Errorf("Convert(2.5 SOL) = %s, %v, want 250", got, err)
+	}
+
+	// Nothing to convert needs no rate
+	got, err = c.Convert(context.Background(), decimal.Zero, "DOGE", "USD")
+	if err != nil || !got.IsZero() {
+		t.Errorf("Convert(0 DOGE) = %s, %v, want 0", got, err)
+	}
+
+	// The latest tick replaces the previous price
+	c.OnPriceUpdate("BTC-USD", dec("60000"))
+	got, err = c.Convert(context.Background(), dec("1"), "SOL", "USD")
+	if err != nil || !got.Equal(dec("120")) {
+		t.Errorf("Convert(1 SOL) after a tick = %s, %v, want 120", got, err)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		symbol, base, quote string
+	}{
+		{"BTC-USD", "BTC", "USD"},
+		{"ETH/BTC", "ETH", "BTC"},
+		{"SOL", "SOL", "USD"},
+	}
+
+	for _, tt := range tests {
+		if base, quote := Split(tt.symbol); base != tt.base || quote != tt.quote {
+			t.Errorf("Split(%s) = %s, %s, want %s, %s", tt.symbol, base, quote, tt.base, tt.quote)
+		}
+	}
+}
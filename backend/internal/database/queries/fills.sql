@@ -0,0 +1,32 @@
+-- name: CreateFill :exec
+INSERT INTO fills (
+    order_id,
+    exchange_id,
+    exchange_trade_id,
+    symbol,
+    side,
+    price,
+    quantity,
+    fee,
+    fee_currency,
+    liquidity,
+    executed_at
+) VALUES (
+    $1, (SELECT exchange_id FROM orders WHERE id = $1), $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+ON CONFLICT (exchange_id, symbol, exchange_trade_id) DO NOTHING;
+
+-- name: GetOrderFillTotals :one
+SELECT
+    COALESCE(SUM(quantity), 0) AS filled_quantity,
+    SUM(price * quantity) / NULLIF(SUM(quantity), 0) AS average_fill_price,
+    COALESCE(SUM(fee), 0) AS fees,
+    MIN(fee_currency) AS fee_currency,
+    COUNT(DISTINCT fee_currency) AS fee_currencies
+FROM fills
+WHERE order_id = $1;
+
+-- name: ListOrderFills :many
+SELECT * FROM fills
+WHERE order_id = $1
+ORDER BY executed_at, id;
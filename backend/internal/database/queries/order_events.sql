@@ -0,0 +1,22 @@
+-- name: CreateOrderEvent :one
+INSERT INTO order_events (
+    order_id,
+    from_status,
+    to_status,
+    source,
+    reason,
+    filled_quantity
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetOrderForTransition :one
+SELECT status FROM orders
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListOrderEvents :many
+SELECT * FROM order_events
+WHERE order_id = $1
+ORDER BY created_at, id;
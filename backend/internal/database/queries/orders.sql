@@ -5,13 +5,16 @@ INSERT INTO orders (
     strategy_id,
     symbol,
     side,
+    intent,
     type,
     quantity,
     price,
     stop_loss_price,
+    exit_reason,
+    close_trade_id,
     status
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
 ) RETURNING *;
 
 -- name: GetOrder :one
@@ -46,6 +49,11 @@ SELECT * FROM orders
 WHERE status IN ('PENDING', 'OPEN')
 ORDER BY created_at DESC;
 
+-- name: ListStalePendingOrders :many
+SELECT * FROM orders
+WHERE status = 'PENDING' AND created_at < $1
+ORDER BY created_at;
+
 -- name: UpdateOrderStatus :one
 UPDATE orders
 SET 
@@ -9,9 +9,10 @@ INSERT INTO performance_snapshots (
     total_trades,
     win_rate,
     sharpe_ratio,
-    max_drawdown
+    max_drawdown,
+    currency
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
 ) RETURNING *;
 
 -- name: GetLatestPerformanceSnapshot :one
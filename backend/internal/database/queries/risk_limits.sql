@@ -0,0 +1,29 @@
+-- name: GetRiskLimits :one
+SELECT value FROM system_config
+WHERE key = 'risk_limits';
+
+-- name: ListStrategyRiskLimits :many
+SELECT id, risk_limits FROM strategies
+WHERE risk_limits IS NOT NULL;
+
+-- name: SetStrategyRiskLimits :exec
+UPDATE strategies
+SET risk_limits = $2
+WHERE id = $1;
+
+-- name: CreateRiskLimitChange :exec
+INSERT INTO risk_limit_changes (
+    strategy_id,
+    field,
+    old_value,
+    new_value,
+    changed_by,
+    changed_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+);
+
+-- name: ListRiskLimitChanges :many
+SELECT * FROM risk_limit_changes
+ORDER BY changed_at DESC
+LIMIT $1;
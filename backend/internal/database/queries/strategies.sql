@@ -2,10 +2,11 @@
 INSERT INTO strategies (
     name,
     type,
+    symbol,
     config,
     is_active
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5
 ) RETURNING *;
 
 -- name: GetStrategy :one
@@ -16,6 +17,10 @@ WHERE id = $1;
 SELECT * FROM strategies
 WHERE name = $1;
 
+-- name: GetStrategyByNameAndSymbol :one
+SELECT * FROM strategies
+WHERE name = $1 AND symbol = $2;
+
 -- name: ListStrategies :many
 SELECT * FROM strategies
 ORDER BY created_at DESC;
@@ -48,3 +53,18 @@ WHERE id = $1;
 DELETE FROM strategies
 WHERE id = $1;
 
+
+-- name: PauseStrategy :exec
+UPDATE strategies
+SET paused_until = $2, pause_reason = $3
+WHERE id = $1;
+
+-- name: RearmStrategy :exec
+UPDATE strategies
+SET paused_until = NULL, pause_reason = NULL
+WHERE id = $1;
+
+-- name: ResetStrategyDrawdown :exec
+UPDATE strategies
+SET drawdown_reset_at = $2
+WHERE id = $1;
@@ -5,13 +5,25 @@ INSERT INTO trades (
     symbol,
     entry_price,
     quantity,
+    entry_fees,
     side,
     entry_time,
     metadata
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 ) RETURNING *;
 
+-- name: ListOpenLotsFIFO :many
+SELECT * FROM trades
+WHERE strategy_id = $1 AND symbol = $2 AND side = $3 AND exit_time IS NULL
+ORDER BY entry_time, id
+FOR UPDATE;
+
+-- name: ReduceLot :exec
+UPDATE trades
+SET quantity = $2, entry_fees = $3
+WHERE id = $1;
+
 -- name: GetTrade :one
 SELECT * FROM trades
 WHERE id = $1;
@@ -0,0 +1,23 @@
+package events
+
+// Bus is the message bus used by components to exchange events.
+// NATSClient is the production implementation; MemoryBus runs in-process.
+type Bus interface {
+	// Publish publishes an event on the subject named by its type
+	Publish(eventType EventType, data interface{}) error
+
+	// Subscribe subscribes to a subject with a handler
+	Subscribe(subject string, handler func(*Event) error) (Subscription, error)
+
+	// QueueSubscribe subscribes to a subject with a queue group, so that
+	// each event is delivered to only one member of the group
+	QueueSubscribe(subject, queue string, handler func(*Event) error) (Subscription, error)
+
+	// Close closes the bus and releases its subscriptions
+	Close()
+}
+
+// Subscription represents an active subscription on a Bus
+type Subscription interface {
+	Unsubscribe() error
+}
@@ -25,9 +25,17 @@ const (
 	EventTypeTradeOpened EventType = "trade.opened"
 	EventTypeTradeClosed EventType = "trade.closed"
 
+	// Strategy events
+	EventTypeStrategyConfigUpdated EventType = "strategy.config.updated"
+	EventTypeStrategyToggled       EventType = "strategy.toggled"
+
 	// Risk events
 	EventTypeRiskViolation EventType = "risk.violation"
 	EventTypeKillSwitch    EventType = "risk.kill_switch"
+	EventTypeRiskLimits    EventType = "risk.limits.updated"
+
+	// Portfolio events
+	EventTypePortfolioValuation EventType = "portfolio.valuation"
 
 	// System events
 	EventTypeSystemError  EventType = "system.error"
@@ -71,6 +79,8 @@ type PriceUpdateEvent struct {
 	Exchange string    `json:"exchange"`
 	Symbol   string    `json:"symbol"`
 	Price    float64   `json:"price"`
+	Bid      float64   `json:"bid,omitempty"`
+	Ask      float64   `json:"ask,omitempty"`
 	Volume   float64   `json:"volume"`
 	Time     time.Time `json:"time"`
 }
@@ -83,6 +93,7 @@ type OrderPlacedEvent struct {
 	StrategyID      string   `json:"strategy_id"`
 	Symbol          string   `json:"symbol"`
 	Side            string   `json:"side"`
+	Intent          string   `json:"intent,omitempty"`
 	Type            string   `json:"type"`
 	Quantity        float64  `json:"quantity"`
 	Price           *float64 `json:"price,omitempty"`
@@ -96,9 +107,11 @@ type OrderFilledEvent struct {
 	ExchangeOrderID  string    `json:"exchange_order_id"`
 	Symbol           string    `json:"symbol"`
 	Side             string    `json:"side"`
+	Intent           string    `json:"intent,omitempty"`
 	FilledQuantity   float64   `json:"filled_quantity"`
 	AverageFillPrice float64   `json:"average_fill_price"`
 	Fees             float64   `json:"fees"`
+	FeeCurrency      string    `json:"fee_currency,omitempty"`
 	FilledAt         time.Time `json:"filled_at"`
 }
 
@@ -108,12 +121,17 @@ type TradeSignalEvent struct {
 	StrategyID    string             `json:"strategy_id"`
 	Symbol        string             `json:"symbol"`
 	Side          string             `json:"side"`
+	Intent        string             `json:"intent,omitempty"` // OPEN_LONG, CLOSE_LONG, OPEN_SHORT or CLOSE_SHORT
 	Type          string             `json:"type"`
 	Quantity      float64            `json:"quantity"`
 	Price         *float64           `json:"price,omitempty"`
 	StopLossPrice float64            `json:"stop_loss_price"`
 	Reason        string             `json:"reason"`
 	Indicators    map[string]float64 `json:"indicators"`
+
+	// Closing signals only
+	ExitReason string `json:"exit_reason,omitempty"` // Defaults to SIGNAL
+	TradeID    string `json:"trade_id,omitempty"`    // Trade to close; empty matches the position's lots
 }
 
 // TradeOpenedEvent represents a trade opened event
@@ -142,6 +160,25 @@ type TradeClosedEvent struct {
 	HoldDuration string    `json:"hold_duration"`
 }
 
+// StrategyConfigUpdatedEvent represents a change to a strategy's parameters
+type StrategyConfigUpdatedEvent struct {
+	StrategyID string          `json:"strategy_id"`
+	Config     json.RawMessage `json:"config"`
+}
+
+// StrategyToggledEvent represents a strategy being enabled or paused
+type StrategyToggledEvent struct {
+	StrategyID     string `json:"strategy_id"`
+	Enabled        bool   `json:"enabled"`
+	ClosePositions bool   `json:"close_positions"` // Close open trades when pausing
+}
+
+// RiskLimitsUpdatedEvent represents a change of the runtime risk limits
+type RiskLimitsUpdatedEvent struct {
+	StrategyID string `json:"strategy_id,omitempty"` // Empty for global limits
+	ChangedBy  string `json:"changed_by"`
+}
+
 // RiskViolationEvent represents a risk violation event
 type RiskViolationEvent struct {
 	StrategyID  string                 `json:"strategy_id"`
@@ -157,6 +194,36 @@ type KillSwitchEvent struct {
 	Reason  string `json:"reason"`
 }
 
+// PortfolioValuationEvent is the portfolio marked to the latest prices, in
+// the reporting currency
+type PortfolioValuationEvent struct {
+	Currency       string              `json:"currency"`
+	PortfolioValue float64             `json:"portfolio_value"` // Balances less the market value of shorts
+	BalancesValue  float64             `json:"balances_value"`
+	CashValue      float64             `json:"cash_value"`     // Balances at par with the reporting currency
+	UnrealizedPnL  float64             `json:"unrealized_pnl"` // Net of entry fees
+	LongExposure   float64             `json:"long_exposure"`
+	ShortExposure  float64             `json:"short_exposure"`
+	GrossExposure  float64             `json:"gross_exposure"`
+	NetExposure    float64             `json:"net_exposure"`
+	Positions      []PositionValuation `json:"positions"`
+	Unpriced       []string            `json:"unpriced,omitempty"` // Currencies and symbols without a price, left out
+	ValuedAt       time.Time           `json:"valued_at"`
+}
+
+// PositionValuation is the open lots of a strategy, symbol and side marked
+// to the latest price
+type PositionValuation struct {
+	StrategyID    string  `json:"strategy_id"`
+	Symbol        string  `json:"symbol"`
+	Side          string  `json:"side"`
+	Quantity      float64 `json:"quantity"`
+	EntryPrice    float64 `json:"entry_price"` // Average over the lots, in the quote currency
+	MarkPrice     float64 `json:"mark_price"`  // In the quote currency
+	MarketValue   float64 `json:"market_value"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+}
+
 // SystemErrorEvent represents a system error event
 type SystemErrorEvent struct {
 	Component string `json:"component"`
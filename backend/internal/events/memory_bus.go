@@ -0,0 +1,273 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DeliveryMode controls how MemoryBus hands events to subscribers
+type DeliveryMode int
+
+const (
+	// DeliverySync runs handlers inline before Publish returns
+	DeliverySync DeliveryMode = iota
+
+	// DeliveryAsync gives every subscription its own goroutine, delivering
+	// events in publish order like a NATS subscription
+	DeliveryAsync
+)
+
+// memorySubscriptionBuffer is the number of events queued per async subscription
+const memorySubscriptionBuffer = 1024
+
+// MemoryBus is an in-process Bus used for tests and single-binary mode.
+// Subjects follow NATS semantics: "*" matches one token and ">" matches
+// one or more trailing tokens.
+type MemoryBus struct {
+	mode        DeliveryMode
+	logger      *logrus.Logger
+	subs        map[uint64]*memorySubscription
+	nextID      uint64
+	queueCursor map[string]int
+	closed      bool
+	mu          sync.Mutex
+}
+
+// memorySubscription is a single subscription on a MemoryBus
+type memorySubscription struct {
+	id      uint64
+	bus     *MemoryBus
+	subject string
+	queue   string
+	handler func(*Event) error
+	events  chan *Event
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewMemoryBus creates a new in-process bus
+func NewMemoryBus(mode DeliveryMode, logger *logrus.Logger) *MemoryBus {
+	return &MemoryBus{
+		mode:        mode,
+		logger:      logger,
+		subs:        make(map[uint64]*memorySubscription),
+		queueCursor: make(map[string]int),
+	}
+}
+
+// Publish publishes an event to all matching subscriptions
+func (mb *MemoryBus) Publish(eventType EventType, data interface{}) error {
+	event, err := NewEvent(eventType, data)
+	if err != nil {
+		return fmt.Errorf("failed to create event: %w", err)
+	}
+
+	subject := string(eventType)
+	targets, err := mb.route(subject)
+	if err != nil {
+		return err
+	}
+
+	mb.logger.WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": eventType,
+		"subject":    subject,
+	}).Debug("Published event")
+
+	for _, sub := range targets {
+		// Each subscriber gets its own copy, as it would from the wire
+		eventCopy := *event
+		sub.deliver(&eventCopy)
+	}
+
+	return nil
+}
+
+// Subscribe subscribes to a subject with a handler
+func (mb *MemoryBus) Subscribe(subject string, handler func(*Event) error) (Subscription, error) {
+	return mb.subscribe(subject, "", handler)
+}
+
+// QueueSubscribe subscribes to a subject with a queue group
+func (mb *MemoryBus) QueueSubscribe(subject, queue string, handler func(*Event) error) (Subscription, error) {
+	return mb.subscribe(subject, queue, handler)
+}
+
+// Close unsubscribes all subscriptions and rejects further publishes
+func (mb *MemoryBus) Close() {
+	mb.mu.Lock()
+	subs := make([]*memorySubscription, 0, len(mb.subs))
+	for _, sub := range mb.subs {
+		subs = append(subs, sub)
+	}
+	mb.subs = make(map[uint64]*memorySubscription)
+	mb.closed = true
+	mb.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.stop()
+	}
+
+	mb.logger.Info("Memory bus closed")
+}
+
+// Helper methods
+
+func (mb *MemoryBus) subscribe(subject, queue string, handler func(*Event) error) (Subscription, error) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	if mb.closed {
+		return nil, fmt.Errorf("failed to subscribe: bus is closed")
+	}
+
+	mb.nextID++
+	sub := &memorySubscription{
+		id:      mb.nextID,
+		bus:     mb,
+		subject: subject,
+		queue:   queue,
+		handler: handler,
+		done:    make(chan struct{}),
+	}
+
+	if mb.mode == DeliveryAsync {
+		sub.events = make(chan *Event, memorySubscriptionBuffer)
+		go sub.run()
+	}
+
+	mb.subs[sub.id] = sub
+
+	mb.logger.WithFields(logrus.Fields{
+		"subject": subject,
+		"queue":   queue,
+	}).Info("Subscribed to subject")
+
+	return sub, nil
+}
+
+// route returns the subscriptions that should receive an event on subject.
+// Plain subscriptions all receive it; each queue group receives it once,
+// rotating between members.
+func (mb *MemoryBus) route(subject string) ([]*memorySubscription, error) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	if mb.closed {
+		return nil, fmt.Errorf("failed to publish event: bus is closed")
+	}
+
+	targets := make([]*memorySubscription, 0, len(mb.subs))
+	groups := make(map[string][]*memorySubscription)
+	groupOrder := make([]string, 0)
+
+	for _, sub := range mb.subs {
+		if !subjectMatches(sub.subject, subject) {
+			continue
+		}
+		if sub.queue == "" {
+			targets = append(targets, sub)
+			continue
+		}
+		key := sub.queue + "|" + sub.subject
+		if _, exists := groups[key]; !exists {
+			groupOrder = append(groupOrder, key)
+		}
+		groups[key] = append(groups[key], sub)
+	}
+
+	for _, key := range groupOrder {
+		members := groups[key]
+		// Map iteration order is random; pick by subscription ID so the
+		// rotation is stable
+		sort.Slice(members, func(i, j int) bool { return members[i].id < members[j].id })
+		cursor := mb.queueCursor[key] % len(members)
+		mb.queueCursor[key] = cursor + 1
+		targets = append(targets, members[cursor])
+	}
+
+	return targets, nil
+}
+
+func (mb *MemoryBus) remove(id uint64) {
+	mb.mu.Lock()
+	delete(mb.subs, id)
+	mb.mu.Unlock()
+}
+
+// Unsubscribe removes the subscription from the bus
+func (ms *memorySubscription) Unsubscribe() error {
+	ms.bus.remove(ms.id)
+	ms.stop()
+	return nil
+}
+
+func (ms *memorySubscription) stop() {
+	ms.once.Do(func() {
+		close(ms.done)
+	})
+}
+
+func (ms *memorySubscription) deliver(event *Event) {
+	if ms.events == nil {
+		ms.handle(event)
+		return
+	}
+
+	select {
+	case ms.events <- event:
+	case <-ms.done:
+	}
+}
+
+func (ms *memorySubscription) run() {
+	for {
+		select {
+		case <-ms.done:
+			return
+		case event := <-ms.events:
+			ms.handle(event)
+		}
+	}
+}
+
+func (ms *memorySubscription) handle(event *Event) {
+	logger := ms.bus.logger
+
+	logger.WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+		"subject":    ms.subject,
+	}).Debug("Received event")
+
+	if err := ms.handler(event); err != nil {
+		logger.WithError(err).WithFields(logrus.Fields{
+			"event_id":   event.ID,
+			"event_type": event.Type,
+		}).Error("Failed to handle event")
+	}
+}
+
+// subjectMatches reports whether subject matches a NATS-style pattern
+func subjectMatches(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+
+	for i, token := range patternTokens {
+		if token == ">" {
+			return len(subjectTokens) > i
+		}
+		if i >= len(subjectTokens) {
+			return false
+		}
+		if token != "*" && token != subjectTokens[i] {
+			return false
+		}
+	}
+
+	return len(patternTokens) == len(subjectTokens)
+}
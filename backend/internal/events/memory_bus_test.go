@@ -0,0 +1,177 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestBus(mode DeliveryMode) *MemoryBus {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewMemoryBus(mode, logger)
+}
+
+func TestSubjectMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		subject string
+		want    bool
+	}{
+		{"market.data.BTC-USD", "market.data.BTC-USD", true},
+		{"market.data.BTC-USD", "market.data.ETH-USD", false},
+		{"market.data.*", "market.data.BTC-USD", true},
+		{"market.data.*", "market.data", false},
+		{"market.data.*", "market.data.BTC-USD.trades", false},
+		{"market.*.BTC-USD", "market.data.BTC-USD", true},
+		{"market.>", "market.data", true},
+		{"market.>", "market.data.BTC-USD", true},
+		{"market.>", "market", false},
+		{">", "orders", true},
+		{"*", "orders.filled", false},
+		{"orders.filled", "orders", false},
+		{"orders", "orders.filled", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"~"+tt.subject, func(t *testing.T) {
+			if got := subjectMatches(tt.pattern, tt.subject); got != tt.want {
+				t.Errorf("subjectMatches(%q, %q) = %v, want %v", tt.pattern, tt.subject, got, tt.want)
+			}
+		})
+	}
+}
+
+// recorder counts the events received by named subscriptions
+type recorder struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (r *recorder) handler(name string) func(*Event) error {
+	return func(*Event) error {
+		r.mu.Lock()
+		r.counts[name]++
+		r.mu.Unlock()
+		return nil
+	}
+}
+
+func (r *recorder) count(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counts[name]
+}
+
+func TestMemoryBusRouting(t *testing.T) {
+	bus := newTestBus(DeliverySync)
+	defer bus.Close()
+
+	rec := &recorder{counts: make(map[string]int)}
+	subscribe := func(name, subject, queue string) Subscription {
+		var sub Subscription
+		var err error
+		if queue == "" {
+			sub, err = bus.Subscribe(subject, rec.handler(name))
+		} else {
+			sub, err = bus.QueueSubscribe(subject, queue, rec.handler(name))
+		}
+		if err != nil {
+			t.Fatalf("subscribe %s: %v", name, err)
+		}
+		return sub
+	}
+
+	subscribe("exact", "orders.filled", "")
+	subscribe("wildcard", "orders.*", "")
+	subscribe("tail", "orders.>", "")
+	subscribe("other", "trades.*", "")
+	subscribe("workers-1", "orders.filled", "workers")
+	subscribe("workers-2", "orders.filled", "workers")
+	subscribe("workers-3", "orders.filled", "workers")
+	subscribe("audit-1", "orders.*", "audit")
+	unsubscribed := subscribe("gone", "orders.filled", "")
+
+	if err := unsubscribed.Unsubscribe(); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+
+	for i := 0; i < 6; i++ {
+		if err := bus.Publish("orders.filled", map[string]int{"n": i}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	if err := bus.Publish("orders.cancelled", nil); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	want := map[string]int{
+		"exact":    6,
+		"wildcard": 7,
+		"tail":     7,
+		"other":    0,
+		// Each queue group gets every event once, rotating between members
+		"workers-1": 2,
+		"workers-2": 2,
+		"workers-3": 2,
+		"audit-1":   7,
+		"gone":      0,
+	}
+	for name, count := range want {
+		if got := rec.count(name); got != count {
+			t.Errorf("%s received %d events, want %d", name, got, count)
+		}
+	}
+}
+
+func TestMemoryBusAsyncOrder(t *testing.T) {
+	bus := newTestBus(DeliveryAsync)
+	defer bus.Close()
+
+	const events = 100
+	received := make(chan int, events)
+	_, err := bus.Subscribe("ticks.*", func(event *Event) error {
+		var data map[string]int
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			return err
+		}
+		received <- data["n"]
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	for i := 0; i < events; i++ {
+		if err := bus.Publish("ticks.BTC-USD", map[string]int{"n": i}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	for i := 0; i < events; i++ {
+		select {
+		case n := <-received:
+			if n != i {
+				t.Fatalf("received event %d, want %d in publish order", n, i)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+}
+
+func TestMemoryBusClosed(t *testing.T) {
+	bus := newTestBus(DeliverySync)
+	bus.Close()
+
+	if err := bus.Publish("orders.filled", nil); err == nil {
+		t.Error("Publish on a closed bus succeeded")
+	}
+	if _, err := bus.Subscribe("orders.filled", func(*Event) error { return nil }); err == nil {
+		t.Error("Subscribe on a closed bus succeeded")
+	}
+}
@@ -75,7 +75,7 @@ func (nc *NATSClient) Publish(eventType EventType, data interface{}) error {
 }
 
 // Subscribe subscribes to a subject with a handler
-func (nc *NATSClient) Subscribe(subject string, handler func(*Event) error) (*nats.Subscription, error) {
+func (nc *NATSClient) Subscribe(subject string, handler func(*Event) error) (Subscription, error) {
 	sub, err := nc.conn.Subscribe(subject, func(msg *nats.Msg) {
 		var event Event
 		if err := json.Unmarshal(msg.Data, &event); err != nil {
@@ -107,7 +107,7 @@ func (nc *NATSClient) Subscribe(subject string, handler func(*Event) error) (*na
 }
 
 // QueueSubscribe subscribes to a subject with a queue group
-func (nc *NATSClient) QueueSubscribe(subject, queue string, handler func(*Event) error) (*nats.Subscription, error) {
+func (nc *NATSClient) QueueSubscribe(subject, queue string, handler func(*Event) error) (Subscription, error) {
 	sub, err := nc.conn.QueueSubscribe(subject, queue, func(msg *nats.Msg) {
 		var event Event
 		if err := json.Unmarshal(msg.Data, &event); err != nil {
@@ -162,6 +162,49 @@ func (nc *NATSClient) Request(subject string, data interface{}, timeout time.Dur
 	return &event, nil
 }
 
+// Respond subscribes to requests on a subject and replies to each with an
+// event carrying the handler's result. The handler gets the subject the
+// request was sent to and its raw payload. Each request is handled in its own
+// goroutine, so a slow request doesn't hold up the others; the handler must
+// be safe for concurrent use.
+func (nc *NATSClient) Respond(subject string, handler func(subject string, data []byte) interface{}) (Subscription, error) {
+	sub, err := nc.conn.Subscribe(subject, func(msg *nats.Msg) {
+		if msg.Reply == "" {
+			nc.logger.WithField("subject", msg.Subject).Warn("Dropping request without a reply subject")
+			return
+		}
+
+		go nc.reply(msg, handler)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to requests: %w", err)
+	}
+
+	nc.logger.WithField("subject", subject).Info("Responding to requests")
+
+	return sub, nil
+}
+
+// reply handles a request and sends the handler's result back
+func (nc *NATSClient) reply(msg *nats.Msg, handler func(subject string, data []byte) interface{}) {
+	event, err := NewEvent(EventType(msg.Subject), handler(msg.Subject, msg.Data))
+	if err != nil {
+		nc.logger.WithError(err).WithField("subject", msg.Subject).Error("Failed to create reply")
+		return
+	}
+
+	eventBytes, err := json.Marshal(event)
+	if err != nil {
+		nc.logger.WithError(err).WithField("subject", msg.Subject).Error("Failed to marshal reply")
+		return
+	}
+
+	if err := msg.Respond(eventBytes); err != nil {
+		nc.logger.WithError(err).WithField("subject", msg.Subject).Error("Failed to send reply")
+	}
+}
+
 // Close closes the NATS connection
 func (nc *NATSClient) Close() {
 	if nc.conn != nil {
@@ -7,14 +7,17 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/crypto-trading-bot/internal/models"
+	"github.com/crypto-trading-bot/internal/ratelimit"
 	"github.com/gorilla/websocket"
 	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
@@ -25,6 +28,10 @@ const (
 	coinbaseSandboxAPIURL = "https://api-public.sandbox.exchange.coinbase.com"
 	coinbaseWSURL         = "wss://ws-feed.exchange.coinbase.com"
 	coinbaseSandboxWSURL  = "wss://ws-feed-public.sandbox.exchange.coinbase.com"
+
+	// Private REST endpoints allow 15 requests per second
+	coinbaseRequestsPerSecond = 15
+	coinbaseMaxRetries        = 3
 )
 
 // CoinbaseExchange implements the Exchange interface for Coinbase Advanced Trade
@@ -39,6 +46,10 @@ type CoinbaseExchange struct {
 	logger      *logrus.Logger
 	callbacks   []func(*PriceUpdate)
 	callbacksMu sync.RWMutex
+	limiter     *ratelimit.TokenBucket
+
+	symbolInfo   map[string]*SymbolInfo // Product rules, cached as they rarely change
+	symbolInfoMu sync.Mutex
 }
 
 // NewCoinbaseExchange creates a new Coinbase exchange connector
@@ -60,8 +71,10 @@ func NewCoinbaseExchange(apiKey, apiSecret, passphrase string, sandbox bool, log
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		logger:    logger,
-		callbacks: make([]func(*PriceUpdate), 0),
+		logger:     logger,
+		callbacks:  make([]func(*PriceUpdate), 0),
+		symbolInfo: make(map[string]*SymbolInfo),
+		limiter:    ratelimit.NewTokenBucket(coinbaseRequestsPerSecond, coinbaseRequestsPerSecond),
 	}
 }
 
@@ -72,6 +85,16 @@ func (ce *CoinbaseExchange) Name() string {
 
 // PlaceOrder places an order on Coinbase
 func (ce *CoinbaseExchange) PlaceOrder(ctx context.Context, req *OrderRequest) (*OrderResponse, error) {
+	// Trailing stops are enforced by the risk manager for live trading
+	if req.Type == models.OrderTypeTrailingStop {
+		return nil, fmt.Errorf("trailing-stop orders are not supported by Coinbase")
+	}
+
+	// Spot account: shorts can't be opened
+	if req.Intent == models.PositionIntentOpenShort {
+		return nil, fmt.Errorf("short selling is not supported by Coinbase spot trading")
+	}
+
 	// Build order request
 	orderReq := map[string]interface{}{
 		"product_id": req.Symbol,
@@ -79,6 +102,10 @@ func (ce *CoinbaseExchange) PlaceOrder(ctx context.Context, req *OrderRequest) (
 		"type":       string(req.Type),
 	}
 
+	if req.ClientOrderID != "" {
+		orderReq["client_oid"] = req.ClientOrderID
+	}
+
 	if req.Type == models.OrderTypeMarket {
 		// Market order - specify size
 		orderReq["size"] = req.Quantity.String()
@@ -97,39 +124,10 @@ func (ce *CoinbaseExchange) PlaceOrder(ctx context.Context, req *OrderRequest) (
 		return nil, fmt.Errorf("failed to place order: %w", err)
 	}
 
-	// Parse response
-	orderResp := &OrderResponse{
-		ID:              response["id"].(string),
-		ExchangeOrderID: response["id"].(string),
-		ClientOrderID:   response["id"].(string),
-		Symbol:          req.Symbol,
-		Side:            req.Side,
-		Type:            req.Type,
-		Status:          ce.parseOrderStatus(response["status"].(string)),
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
-	}
-
-	// Parse quantity
-	if size, ok := response["size"].(string); ok {
-		if qty, err := decimal.NewFromString(size); err == nil {
-			orderResp.Quantity = qty
-		}
-	}
-
-	// Parse filled quantity
-	if filled, ok := response["filled_size"].(string); ok {
-		if qty, err := decimal.NewFromString(filled); err == nil {
-			orderResp.FilledQuantity = qty
-		}
-	}
-
-	// Parse fees
-	if fillFees, ok := response["fill_fees"].(string); ok {
-		if fees, err := decimal.NewFromString(fillFees); err == nil {
-			orderResp.Fees = fees
-		}
-	}
+	orderResp := ce.parseOrder(response)
+	orderResp.Symbol = req.Symbol
+	orderResp.Side = req.Side
+	orderResp.Type = req.Type
 
 	ce.logger.WithFields(logrus.Fields{
 		"order_id": orderResp.ID,
@@ -162,15 +160,77 @@ func (ce *CoinbaseExchange) GetOrder(ctx context.Context, orderID string) (*Orde
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
 
-	// Parse response (similar to PlaceOrder)
-	orderResp := &OrderResponse{
-		ID:              response["id"].(string),
-		ExchangeOrderID: response["id"].(string),
-		Symbol:          response["product_id"].(string),
-		Status:          ce.parseOrderStatus(response["status"].(string)),
+	return ce.parseOrder(response), nil
+}
+
+// GetOrderByClientID gets an order by the client_oid it was placed with
+func (ce *CoinbaseExchange) GetOrderByClientID(ctx context.Context, clientOrderID string) (*OrderResponse, error) {
+	endpoint := fmt.Sprintf("/orders/client:%s", clientOrderID)
+
+	var response map[string]interface{}
+	if err := ce.makeRequest(ctx, "GET", endpoint, nil, &response); err != nil {
+		var apiErr *coinbaseAPIError
+		if errors.As(err, &apiErr) && apiErr.statusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: client order ID %s", ErrOrderNotFound, clientOrderID)
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
 
-	return orderResp, nil
+	return ce.parseOrder(response), nil
+}
+
+// GetFills gets the executions of an order
+func (ce *CoinbaseExchange) GetFills(ctx context.Context, orderID string) ([]*Fill, error) {
+	endpoint := fmt.Sprintf("/fills?order_id=%s", orderID)
+
+	var response []map[string]interface{}
+	if err := ce.makeRequest(ctx, "GET", endpoint, nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to get fills: %w", err)
+	}
+
+	fills := make([]*Fill, 0, len(response))
+	for _, raw := range response {
+		fill := &Fill{OrderID: orderID, Liquidity: LiquidityTaker}
+
+		// Trade IDs are numeric
+		switch tradeID := raw["trade_id"].(type) {
+		case float64:
+			fill.TradeID = strconv.FormatInt(int64(tradeID), 10)
+		case string:
+			fill.TradeID = tradeID
+		default:
+			return nil, fmt.Errorf("fill of order %s has no trade ID", orderID)
+		}
+
+		fill.Symbol, _ = raw["product_id"].(string)
+		fill.FeeCurrency = QuoteCurrency(fill.Symbol) // Fees are charged in the quote currency
+		if side, ok := raw["side"].(string); ok {
+			fill.Side = models.OrderSide(strings.ToUpper(side))
+		}
+		if liquidity, _ := raw["liquidity"].(string); liquidity == "M" {
+			fill.Liquidity = LiquidityMaker
+		}
+		if createdAt, ok := raw["created_at"].(string); ok {
+			fill.Time, _ = time.Parse(time.RFC3339Nano, createdAt)
+		}
+
+		for field, value := range map[string]*decimal.Decimal{
+			"price": &fill.Price,
+			"size":  &fill.Quantity,
+			"fee":   &fill.Fee,
+		} {
+			rawValue, _ := raw[field].(string)
+			parsed, err := decimal.NewFromString(rawValue)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse fill %s: %w", field, err)
+			}
+			*value = parsed
+		}
+
+		fills = append(fills, fill)
+	}
+
+	return fills, nil
 }
 
 // GetBalance gets account balances
@@ -216,6 +276,46 @@ func (ce *CoinbaseExchange) GetPrice(ctx context.Context, symbol string) (decima
 	return price, nil
 }
 
+// GetSymbolInfo gets the order size rules of a product
+func (ce *CoinbaseExchange) GetSymbolInfo(ctx context.Context, symbol string) (*SymbolInfo, error) {
+	ce.symbolInfoMu.Lock()
+	info, exists := ce.symbolInfo[symbol]
+	ce.symbolInfoMu.Unlock()
+	if exists {
+		return info, nil
+	}
+
+	endpoint := fmt.Sprintf("/products/%s", symbol)
+
+	var response map[string]interface{}
+	if err := ce.makeRequest(ctx, "GET", endpoint, nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	info = &SymbolInfo{Symbol: symbol}
+	for field, value := range map[string]*decimal.Decimal{
+		"base_min_size":    &info.MinOrderSize,
+		"base_increment":   &info.StepSize,
+		"min_market_funds": &info.MinNotional,
+	} {
+		raw, ok := response[field].(string)
+		if !ok {
+			continue
+		}
+		parsed, err := decimal.NewFromString(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", field, err)
+		}
+		*value = parsed
+	}
+
+	ce.symbolInfoMu.Lock()
+	ce.symbolInfo[symbol] = info
+	ce.symbolInfoMu.Unlock()
+
+	return info, nil
+}
+
 // SubscribePriceUpdates subscribes to price updates via WebSocket
 func (ce *CoinbaseExchange) SubscribePriceUpdates(ctx context.Context, symbols []string, callback func(*PriceUpdate)) error {
 	ce.callbacksMu.Lock()
@@ -270,10 +370,55 @@ func (ce *CoinbaseExchange) makeRequest(ctx context.Context, method, endpoint st
 		}
 	}
 
+	for attempt := 0; ; attempt++ {
+		// Stay under the API rate limit
+		if err := ce.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		respBody, retryAfter, err := ce.doRequest(ctx, method, endpoint, bodyBytes)
+		if err != nil {
+			return err
+		}
+
+		if retryAfter > 0 {
+			if attempt >= coinbaseMaxRetries {
+				return fmt.Errorf("API rate limit exceeded after %d retries", attempt)
+			}
+
+			ce.logger.WithFields(logrus.Fields{
+				"endpoint":    endpoint,
+				"retry_after": retryAfter,
+				"attempt":     attempt + 1,
+			}).Warn("Coinbase rate limit hit, backing off")
+
+			timer := time.NewTimer(retryAfter)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+			continue
+		}
+
+		if result != nil {
+			if err := json.Unmarshal(respBody, result); err != nil {
+				return fmt.Errorf("failed to unmarshal response: %w", err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// doRequest sends a signed request once. If it was rate limited it returns
+// how long to wait before retrying.
+func (ce *CoinbaseExchange) doRequest(ctx context.Context, method, endpoint string, bodyBytes []byte) ([]byte, time.Duration, error) {
 	url := ce.baseURL + endpoint
 	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(bodyBytes))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Add authentication headers
@@ -290,27 +435,49 @@ func (ce *CoinbaseExchange) makeRequest(ctx context.Context, method, endpoint st
 	// Make request
 	resp, err := ce.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, 0, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read response
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return nil, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), nil
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+		return nil, 0, &coinbaseAPIError{statusCode: resp.StatusCode, body: string(respBody)}
 	}
 
-	if result != nil {
-		if err := json.Unmarshal(respBody, result); err != nil {
-			return fmt.Errorf("failed to unmarshal response: %w", err)
+	return respBody, 0, nil
+}
+
+// coinbaseAPIError is a non-2xx response from the REST API
+type coinbaseAPIError struct {
+	statusCode int
+	body       string
+}
+
+func (e *coinbaseAPIError) Error() string {
+	return fmt.Sprintf("API error (status %d): %s", e.statusCode, e.body)
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an
+// HTTP date, defaulting to one second
+func parseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
 		}
 	}
-
-	return nil
+	return time.Second
 }
 
 func (ce *CoinbaseExchange) generateSignature(message string) string {
@@ -320,6 +487,59 @@ func (ce *CoinbaseExchange) generateSignature(message string) string {
 	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
 }
 
+// parseOrder parses an order from the REST API
+func (ce *CoinbaseExchange) parseOrder(response map[string]interface{}) *OrderResponse {
+	orderResp := &OrderResponse{
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	orderResp.ID, _ = response["id"].(string)
+	orderResp.ExchangeOrderID = orderResp.ID
+	orderResp.ClientOrderID, _ = response["client_oid"].(string)
+	orderResp.Symbol, _ = response["product_id"].(string)
+
+	if side, ok := response["side"].(string); ok {
+		orderResp.Side = models.OrderSide(strings.ToUpper(side))
+	}
+	if orderType, ok := response["type"].(string); ok {
+		orderResp.Type = models.OrderType(strings.ToUpper(orderType))
+	}
+	if status, ok := response["status"].(string); ok {
+		orderResp.Status = ce.parseOrderStatus(status)
+	}
+
+	parse := func(field string) (decimal.Decimal, bool) {
+		raw, ok := response[field].(string)
+		if !ok {
+			return decimal.Zero, false
+		}
+		value, err := decimal.NewFromString(raw)
+		return value, err == nil
+	}
+
+	if qty, ok := parse("size"); ok {
+		orderResp.Quantity = qty
+	}
+	if price, ok := parse("price"); ok {
+		orderResp.Price = &price
+	}
+	if filled, ok := parse("filled_size"); ok {
+		orderResp.FilledQuantity = filled
+	}
+	if fees, ok := parse("fill_fees"); ok {
+		orderResp.Fees = fees
+	}
+
+	// Average fill price from the quote amount filled
+	if executed, ok := parse("executed_value"); ok && orderResp.FilledQuantity.IsPositive() {
+		avgPrice := executed.Div(orderResp.FilledQuantity)
+		orderResp.AverageFillPrice = &avgPrice
+	}
+
+	return orderResp
+}
+
 func (ce *CoinbaseExchange) parseOrderStatus(status string) models.OrderStatus {
 	switch status {
 	case "pending":
@@ -371,6 +591,9 @@ func (ce *CoinbaseExchange) processTicker(msg map[string]interface{}) {
 		Exchange:  "coinbase",
 		Symbol:    symbol,
 		Price:     price,
+		Bid:       tickerDecimal(msg, "best_bid"),
+		Ask:       tickerDecimal(msg, "best_ask"),
+		Volume:    tickerDecimal(msg, "last_size"),
 		Timestamp: time.Now(),
 	}
 
@@ -384,3 +607,17 @@ func (ce *CoinbaseExchange) processTicker(msg map[string]interface{}) {
 		go callback(update)
 	}
 }
+
+// tickerDecimal returns a decimal field of a ticker message, zero if it is
+// missing or malformed
+func tickerDecimal(msg map[string]interface{}, field string) decimal.Decimal {
+	raw, ok := msg[field].(string)
+	if !ok {
+		return decimal.Zero
+	}
+	value, err := decimal.NewFromString(raw)
+	if err != nil {
+		return decimal.Zero
+	}
+	return value
+}
@@ -2,6 +2,8 @@ package exchange
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/crypto-trading-bot/internal/models"
@@ -22,12 +24,22 @@ type Exchange interface {
 	// GetOrder gets an order by ID
 	GetOrder(ctx context.Context, orderID string) (*OrderResponse, error)
 
+	// GetOrderByClientID gets an order by the client order ID it was placed
+	// with, returning ErrOrderNotFound if the exchange never received it
+	GetOrderByClientID(ctx context.Context, clientOrderID string) (*OrderResponse, error)
+
+	// GetFills gets the executions of an order by exchange order ID
+	GetFills(ctx context.Context, orderID string) ([]*Fill, error)
+
 	// GetBalance gets account balances
 	GetBalance(ctx context.Context) (map[string]*Balance, error)
 
 	// GetPrice gets the current price for a symbol
 	GetPrice(ctx context.Context, symbol string) (decimal.Decimal, error)
 
+	// GetSymbolInfo gets the trading rules of a symbol
+	GetSymbolInfo(ctx context.Context, symbol string) (*SymbolInfo, error)
+
 	// SubscribePriceUpdates subscribes to price updates
 	SubscribePriceUpdates(ctx context.Context, symbols []string, callback func(*PriceUpdate)) error
 
@@ -35,14 +47,24 @@ type Exchange interface {
 	Close() error
 }
 
+// ErrOrderNotFound is returned when the exchange has no such order
+var ErrOrderNotFound = errors.New("order not found")
+
 // OrderRequest represents a request to place an order
 type OrderRequest struct {
+	ClientOrderID string // UUID for finding the order again if the response is lost
 	Symbol        string
 	Side          models.OrderSide
+	Intent        models.PositionIntent // Empty means a spot order
 	Type          models.OrderType
 	Quantity      decimal.Decimal
 	Price         *decimal.Decimal // For limit orders
 	StopLossPrice *decimal.Decimal
+
+	// For trailing-stop orders, exactly one of these sets how far the stop
+	// trails the best price: as a percentage or as a fixed price amount
+	TrailingPercent *decimal.Decimal
+	TrailingAmount  *decimal.Decimal
 }
 
 // OrderResponse represents the response from placing an order
@@ -63,6 +85,59 @@ type OrderResponse struct {
 	UpdatedAt        time.Time
 }
 
+// Liquidity flags of a fill
+const (
+	LiquidityMaker = "MAKER"
+	LiquidityTaker = "TAKER"
+)
+
+// Fill is a single execution of an order
+type Fill struct {
+	TradeID     string // Exchange trade ID, unique per symbol
+	OrderID     string // Exchange order ID
+	Symbol      string
+	Side        models.OrderSide
+	Price       decimal.Decimal
+	Quantity    decimal.Decimal
+	Fee         decimal.Decimal
+	FeeCurrency string
+	Liquidity   string
+	Time        time.Time
+}
+
+// SymbolInfo holds the order size rules of a symbol
+type SymbolInfo struct {
+	Symbol       string
+	MinOrderSize decimal.Decimal // Minimum base quantity
+	StepSize     decimal.Decimal // Base quantity increment
+	MinNotional  decimal.Decimal // Minimum quote value, zero if none
+}
+
+// RoundQuantity rounds a quantity down to the step size
+func (si *SymbolInfo) RoundQuantity(quantity decimal.Decimal) decimal.Decimal {
+	if !si.StepSize.IsPositive() {
+		return quantity
+	}
+	return quantity.Div(si.StepSize).Floor().Mul(si.StepSize)
+}
+
+// ValidateQuantity returns an error if the exchange would reject the quantity
+func (si *SymbolInfo) ValidateQuantity(quantity, price decimal.Decimal) error {
+	if quantity.LessThan(si.MinOrderSize) {
+		return fmt.Errorf("quantity %s is below the minimum order size %s for %s",
+			quantity.String(), si.MinOrderSize.String(), si.Symbol)
+	}
+	if si.StepSize.IsPositive() && !quantity.Mod(si.StepSize).IsZero() {
+		return fmt.Errorf("quantity %s is not a multiple of the step size %s for %s",
+			quantity.String(), si.StepSize.String(), si.Symbol)
+	}
+	if si.MinNotional.IsPositive() && quantity.Mul(price).LessThan(si.MinNotional) {
+		return fmt.Errorf("order value %s is below the minimum %s for %s",
+			quantity.Mul(price).String(), si.MinNotional.String(), si.Symbol)
+	}
+	return nil
+}
+
 // Balance represents account balance
 type Balance struct {
 	Currency  string
@@ -76,10 +151,23 @@ type PriceUpdate struct {
 	Exchange  string
 	Symbol    string
 	Price     decimal.Decimal
+	Bid       decimal.Decimal // Best bid, zero if unknown
+	Ask       decimal.Decimal // Best ask, zero if unknown
 	Volume    decimal.Decimal
 	Timestamp time.Time
 }
 
+// QuoteCurrency returns the quote currency of a symbol, e.g. "USD" for
+// "BTC-USD"
+func QuoteCurrency(symbol string) string {
+	for i := 0; i < len(symbol); i++ {
+		if symbol[i] == '-' || symbol[i] == '/' {
+			return symbol[i+1:]
+		}
+	}
+	return "USD"
+}
+
 // Trade represents a trade execution
 type Trade struct {
 	ID        string
@@ -0,0 +1,238 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// MarketSnapshot is the state of a symbol's market an order is filled against
+type MarketSnapshot struct {
+	Price        decimal.Decimal // Last trade price
+	Bid          decimal.Decimal // Best bid, zero if unknown
+	Ask          decimal.Decimal // Best ask, zero if unknown
+	CandleVolume decimal.Decimal // Base volume of the last complete 1m candle, zero if unknown
+}
+
+// FillModel simulates how orders execute against the market. It is
+// independent of the paper exchange so backtests can fill orders the same way.
+type FillModel interface {
+	// Latency returns the delay between placing an order and the market it
+	// is filled against
+	Latency(symbol string) time.Duration
+
+	// Price returns the average price a marketable order of the given
+	// quantity executes at
+	Price(symbol string, side models.OrderSide, quantity decimal.Decimal, market MarketSnapshot) decimal.Decimal
+
+	// FillableQuantity returns how much of a marketable order can execute
+	// against the market at once
+	FillableQuantity(symbol string, quantity decimal.Decimal, market MarketSnapshot) decimal.Decimal
+
+	// QueueAhead returns the volume queued ahead of a new resting limit order
+	// at its price, which has to trade before the order fills
+	QueueAhead(symbol string, quantity decimal.Decimal, market MarketSnapshot) decimal.Decimal
+}
+
+// FillParams configures the simulated fills of a symbol; zero disables a
+// component
+type FillParams struct {
+	// Bid/ask spread assumed when the market has no quote, as a percentage
+	// of the price. Marketable orders cross half of it.
+	SpreadPercent float64 `json:"spread_percent"`
+
+	// Market impact of an order of ImpactNotional quote value, as a
+	// percentage of the price; impact grows with the square root of size
+	ImpactPercent  float64 `json:"impact_percent"`
+	ImpactNotional float64 `json:"impact_notional"`
+
+	// Delay between placing an order and the price it fills at
+	LatencyMs int `json:"latency_ms"`
+
+	// Largest share of the last candle's volume a marketable order fills;
+	// the paper venue rejects larger orders
+	MaxParticipation float64 `json:"max_participation"`
+
+	// Resting limit orders join the queue at their price at a random
+	// position within this multiple of the last candle's volume
+	QueueVolumeMultiple float64 `json:"queue_volume_multiple"`
+}
+
+// DefaultFillParams returns the fill parameters of symbols without their own:
+// a 0.1% spread and no impact, latency, volume cap or queue
+func DefaultFillParams() FillParams {
+	return FillParams{
+		SpreadPercent: 0.1,
+	}
+}
+
+// Validate returns an error if the parameters are out of range
+func (p FillParams) Validate() error {
+	if p.SpreadPercent < 0 || p.SpreadPercent >= 100 {
+		return fmt.Errorf("spread percent must be between 0 and 100")
+	}
+	if p.ImpactPercent < 0 || p.ImpactPercent >= 100 {
+		return fmt.Errorf("impact percent must be between 0 and 100")
+	}
+	if p.ImpactPercent > 0 && p.ImpactNotional <= 0 {
+		return fmt.Errorf("impact notional must be positive when impact is set")
+	}
+	if p.LatencyMs < 0 || p.LatencyMs > 2000 {
+		return fmt.Errorf("latency must be between 0 and 2000 ms")
+	}
+	if p.MaxParticipation < 0 || p.MaxParticipation > 1 {
+		return fmt.Errorf("max participation must be between 0 and 1")
+	}
+	if p.QueueVolumeMultiple < 0 {
+		return fmt.Errorf("queue volume multiple must not be negative")
+	}
+	return nil
+}
+
+// FillModelConfig holds the default fill parameters and per-symbol overrides
+type FillModelConfig struct {
+	Default FillParams
+	Symbols map[string]FillParams
+}
+
+// Params returns the fill parameters of a symbol
+func (c *FillModelConfig) Params(symbol string) FillParams {
+	if params, exists := c.Symbols[symbol]; exists {
+		return params
+	}
+	return c.Default
+}
+
+// ParseFillModelConfig parses and validates a fill model configuration such
+// as {"default": {"spread_percent": 0.1}, "symbols": {"SOL-USD":
+// {"max_participation": 0.05}}}. Symbol entries override single fields of the
+// default. An empty string gives the default parameters for every symbol.
+func ParseFillModelConfig(raw string) (*FillModelConfig, error) {
+	config := &FillModelConfig{
+		Default: DefaultFillParams(),
+		Symbols: make(map[string]FillParams),
+	}
+	if raw == "" {
+		return config, nil
+	}
+
+	var parsed struct {
+		Default json.RawMessage            `json:"default"`
+		Symbols map[string]json.RawMessage `json:"symbols"`
+	}
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return nil, fmt.Errorf("invalid fill model config: %w", err)
+	}
+
+	if len(parsed.Default) > 0 {
+		if err := json.Unmarshal(parsed.Default, &config.Default); err != nil {
+			return nil, fmt.Errorf("invalid default fill params: %w", err)
+		}
+	}
+	if err := config.Default.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid default fill params: %w", err)
+	}
+
+	for symbol, rawParams := range parsed.Symbols {
+		params := config.Default
+		if err := json.Unmarshal(rawParams, &params); err != nil {
+			return nil, fmt.Errorf("invalid fill params for %s: %w", symbol, err)
+		}
+		if err := params.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid fill params for %s: %w", symbol, err)
+		}
+		config.Symbols[symbol] = params
+	}
+
+	return config, nil
+}
+
+// SimulatedFills is the fill model configured by FillParams: orders cross the
+// spread, pay square-root market impact, are capped by candle volume and rest
+// at a random queue position
+type SimulatedFills struct {
+	config *FillModelConfig
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewSimulatedFills creates a fill model. A nil rng draws queue positions
+// from a time-seeded source; backtests pass a seeded one to be repeatable.
+func NewSimulatedFills(config *FillModelConfig, rng *rand.Rand) *SimulatedFills {
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return &SimulatedFills{
+		config: config,
+		rng:    rng,
+	}
+}
+
+// Latency returns the symbol's configured latency
+func (sf *SimulatedFills) Latency(symbol string) time.Duration {
+	return time.Duration(sf.config.Params(symbol).LatencyMs) * time.Millisecond
+}
+
+// Price returns the far side of the spread moved by the order's market impact
+func (sf *SimulatedFills) Price(symbol string, side models.OrderSide, quantity decimal.Decimal, market MarketSnapshot) decimal.Decimal {
+	params := sf.config.Params(symbol)
+	hundred := decimal.NewFromInt(100)
+
+	price := market.Ask
+	if side == models.OrderSideSell {
+		price = market.Bid
+	}
+	if !price.IsPositive() {
+		halfSpread := market.Price.Mul(decimal.NewFromFloat(params.SpreadPercent)).Div(decimal.NewFromInt(200))
+		price = market.Price.Add(halfSpread)
+		if side == models.OrderSideSell {
+			price = market.Price.Sub(halfSpread)
+		}
+	}
+
+	if params.ImpactPercent > 0 {
+		notional := price.Mul(quantity).InexactFloat64()
+		impactPercent := params.ImpactPercent * math.Sqrt(notional/params.ImpactNotional)
+		impact := price.Mul(decimal.NewFromFloat(impactPercent)).Div(hundred)
+		if side == models.OrderSideBuy {
+			price = price.Add(impact)
+		} else {
+			price = price.Sub(impact)
+		}
+	}
+
+	return price
+}
+
+// FillableQuantity caps the quantity at the symbol's share of candle volume,
+// if the volume is known
+func (sf *SimulatedFills) FillableQuantity(symbol string, quantity decimal.Decimal, market MarketSnapshot) decimal.Decimal {
+	params := sf.config.Params(symbol)
+	if params.MaxParticipation <= 0 || !market.CandleVolume.IsPositive() {
+		return quantity
+	}
+
+	return decimal.Min(quantity, market.CandleVolume.Mul(decimal.NewFromFloat(params.MaxParticipation)))
+}
+
+// QueueAhead draws a uniform queue position within the symbol's multiple of
+// candle volume, zero if the volume is unknown
+func (sf *SimulatedFills) QueueAhead(symbol string, quantity decimal.Decimal, market MarketSnapshot) decimal.Decimal {
+	params := sf.config.Params(symbol)
+	if params.QueueVolumeMultiple <= 0 || !market.CandleVolume.IsPositive() {
+		return decimal.Zero
+	}
+
+	sf.mu.Lock()
+	position := sf.rng.Float64()
+	sf.mu.Unlock()
+
+	return market.CandleVolume.Mul(decimal.NewFromFloat(params.QueueVolumeMultiple * position))
+}
@@ -0,0 +1,152 @@
+package exchange
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestSimulatedFillsPrice(t *testing.T) {
+	quoted := MarketSnapshot{Price: dec("100"), Bid: dec("99.5"), Ask: dec("100.5")}
+	unquoted := MarketSnapshot{Price: dec("100")}
+	flat := MarketSnapshot{Price: dec("100"), Bid: dec("100"), Ask: dec("100")}
+	impact := FillParams{ImpactPercent: 0.1, ImpactNotional: 10000}
+
+	tests := []struct {
+		name     string
+		params   FillParams
+		side     models.OrderSide
+		quantity string
+		market   MarketSnapshot
+		want     string
+	}{
+		{"buy at the ask", FillParams{SpreadPercent: 0.1}, models.OrderSideBuy, "1", quoted, "100.5"},
+		{"sell at the bid", FillParams{SpreadPercent: 0.1}, models.OrderSideSell, "1", quoted, "99.5"},
+		{"buy crosses half the assumed spread", FillParams{SpreadPercent: 0.1}, models.OrderSideBuy, "1", unquoted, "100.05"},
+		{"sell crosses half the assumed spread", FillParams{SpreadPercent: 0.1}, models.OrderSideSell, "1", unquoted, "99.95"},
+		{"no spread", FillParams{}, models.OrderSideBuy, "1", unquoted, "100"},
+		// 40000 notional is 4x the impact notional, so twice the impact
+		{"buy impact grows with the square root of size", impact, models.OrderSideBuy, "400", flat, "100.2"},
+		{"sell impact", impact, models.OrderSideSell, "400", flat, "99.8"},
+		{"impact at the impact notional", impact, models.OrderSideBuy, "100", flat, "100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fills := NewSimulatedFills(&FillModelConfig{Default: tt.params}, nil)
+			got := fills.Price("BTC-USD", tt.side, dec(tt.quantity), tt.market)
+			if !got.Equal(dec(tt.want)) {
+				t.Errorf("Price = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimulatedFillsFillableQuantity(t *testing.T) {
+	capped := FillParams{MaxParticipation: 0.05}
+
+	tests := []struct {
+		name     string
+		params   FillParams
+		quantity string
+		volume   string
+		want     string
+	}{
+		{"capped at the participation", capped, "10", "100", "5"},
+		{"below the cap", capped, "3", "100", "3"},
+		{"unknown volume", capped, "10", "0", "10"},
+		{"no cap", FillParams{}, "10", "100", "10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fills := NewSimulatedFills(&FillModelConfig{Default: tt.params}, nil)
+			got := fills.FillableQuantity("BTC-USD", dec(tt.quantity), MarketSnapshot{CandleVolume: dec(tt.volume)})
+			if !got.Equal(dec(tt.want)) {
+				t.Errorf("FillableQuantity = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimulatedFillsQueueAhead(t *testing.T) {
+	config := &FillModelConfig{Default: FillParams{QueueVolumeMultiple: 2}}
+	market := MarketSnapshot{CandleVolume: dec("100")}
+
+	fills := NewSimulatedFills(config, rand.New(rand.NewSource(1)))
+	expected := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 3; i++ {
+		want := market.CandleVolume.Mul(decimal.NewFromFloat(2 * expected.Float64()))
+		got := fills.QueueAhead("BTC-USD", dec("1"), market)
+		if !got.Equal(want) {
+			t.Errorf("QueueAhead #%d = %s, want %s", i+1, got, want)
+		}
+		if got.IsNegative() || got.GreaterThan(dec("200")) {
+			t.Errorf("QueueAhead #%d = %s, want within 2x the candle volume", i+1, got)
+		}
+	}
+
+	if got := fills.QueueAhead("BTC-USD", dec("1"), MarketSnapshot{}); !got.IsZero() {
+		t.Errorf("QueueAhead with unknown volume = %s, want 0", got)
+	}
+
+	noQueue := NewSimulatedFills(&FillModelConfig{Default: FillParams{}}, nil)
+	if got := noQueue.QueueAhead("BTC-USD", dec("1"), market); !got.IsZero() {
+		t.Errorf("QueueAhead without a queue = %s, want 0", got)
+	}
+}
+
+func TestParseFillModelConfig(t *testing.T) {
+	config, err := ParseFillModelConfig(`{
+		"default": {"spread_percent": 0.2, "latency_ms": 50},
+		"symbols": {"SOL-USD": {"max_participation": 0.05}}
+	}`)
+	if err != nil {
+		t.Fatalf("ParseFillModelConfig: %v", err)
+	}
+
+	sol := config.Params("SOL-USD")
+	if sol.SpreadPercent != 0.2 || sol.LatencyMs != 50 || sol.MaxParticipation != 0.05 {
+		t.Errorf("SOL-USD params = %+v, want the default overridden by max_participation", sol)
+	}
+	if btc := config.Params("BTC-USD"); btc != config.Default {
+		t.Errorf("BTC-USD params = %+v, want the default %+v", btc, config.Default)
+	}
+
+	fills := NewSimulatedFills(config, nil)
+	if got := fills.Latency("BTC-USD"); got != 50*time.Millisecond {
+		t.Errorf("Latency = %s, want 50ms", got)
+	}
+
+	empty, err := ParseFillModelConfig("")
+	if err != nil || empty.Default != DefaultFillParams() {
+		t.Errorf("ParseFillModelConfig(\"\") = %+v, %v, want the default params", empty, err)
+	}
+
+	invalid := []struct {
+		name string
+		raw  string
+	}{
+		{"malformed", `{"default":`},
+		{"negative spread", `{"default": {"spread_percent": -1}}`},
+		{"impact without notional", `{"default": {"impact_percent": 0.1}}`},
+		{"latency too high", `{"default": {"latency_ms": 5000}}`},
+		{"participation over 1", `{"symbols": {"SOL-USD": {"max_participation": 1.5}}}`},
+		{"negative queue", `{"symbols": {"SOL-USD": {"queue_volume_multiple": -1}}}`},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseFillModelConfig(tt.raw); err == nil {
+				t.Errorf("ParseFillModelConfig(%s) succeeded, want an error", tt.raw)
+			}
+		})
+	}
+}
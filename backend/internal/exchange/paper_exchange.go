@@ -2,6 +2,7 @@ package exchange
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"sync"
 	"time"
@@ -12,15 +13,35 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// defaultPaperStepSize is the quantity increment of symbols without rules
+var defaultPaperStepSize = decimal.New(1, -8)
+
 // PaperExchange simulates an exchange for paper trading
 type PaperExchange struct {
 	name             string
 	balances         map[string]*Balance
 	orders           map[string]*OrderResponse
+	clientOrders     map[string]string  // Order IDs by client order ID
+	fills            map[string][]*Fill // Executions by order ID
 	currentPrices    map[string]decimal.Decimal
-	slippagePercent  decimal.Decimal
+	trailingStops    map[string]*paperTrailingStop // Resting trailing-stop orders by order ID
+	loans            map[string]*MarginLoan        // Borrowed balances of short positions by symbol
+	symbolInfo       map[string]*SymbolInfo        // Order size rules, defaultSymbolInfo if unset
+	limitOrders      map[string]*paperLimitOrder   // Resting limit orders by order ID
+	markets          map[string]*paperMarket       // Quotes and candle volume by symbol
+	fillModel        FillModel
 	takerFeePercent  decimal.Decimal
 	makerFeePercent  decimal.Decimal
+	marginRate       decimal.Decimal // Annual borrow interest, percent
+	initialMargin    decimal.Decimal // USD collateral locked per short, percent of notional
+	store            *paperStore     // nil keeps the state in memory only
+	unsaved          map[string]bool // IDs of orders changed since the last save
+	saveErr          error           // Error of the last save, nil once a save succeeds
+	saveSignal       chan struct{}
+	saveMu           sync.Mutex // Serializes saves so they are written in order
+	stopSaver        chan struct{}
+	saverDone        chan struct{}
+	closeOnce        sync.Once
 	mu               sync.RWMutex
 	logger           *logrus.Logger
 	priceCallbacks   []func(*PriceUpdate)
@@ -40,99 +61,156 @@ func NewPaperExchange(name string, initialBalance decimal.Decimal, logger *logru
 			},
 		},
 		orders:          make(map[string]*OrderResponse),
+		clientOrders:    make(map[string]string),
+		fills:           make(map[string][]*Fill),
 		currentPrices:   make(map[string]decimal.Decimal),
-		slippagePercent: decimal.NewFromFloat(0.05), // 0.05% slippage
+		trailingStops:   make(map[string]*paperTrailingStop),
+		loans:           make(map[string]*MarginLoan),
+		symbolInfo:      make(map[string]*SymbolInfo),
+		limitOrders:     make(map[string]*paperLimitOrder),
+		markets:         make(map[string]*paperMarket),
+		fillModel:       NewSimulatedFills(&FillModelConfig{Default: DefaultFillParams()}, nil),
 		takerFeePercent: decimal.NewFromFloat(0.4),  // 0.4% taker fee
 		makerFeePercent: decimal.NewFromFloat(0.25), // 0.25% maker fee
+		marginRate:      decimal.NewFromFloat(10),   // 10% APR borrow interest
+		initialMargin:   decimal.NewFromFloat(50),   // 50% initial margin
 		logger:          logger,
 		priceCallbacks:  make([]func(*PriceUpdate), 0),
 	}
 }
 
+// NewPersistentPaperExchange creates a paper exchange whose balances, orders,
+// fills and margin loans are kept in the database under its name, so it
+// resumes where it left off after a restart. A venue without stored state
+// starts with the initial USD balance.
+func NewPersistentPaperExchange(
+	ctx context.Context,
+	name string,
+	initialBalance decimal.Decimal,
+	db *sql.DB,
+	logger *logrus.Logger,
+) (*PaperExchange, error) {
+	pe := NewPaperExchange(name, initialBalance, logger)
+	pe.store = &paperStore{db: db}
+	pe.unsaved = make(map[string]bool)
+	pe.saveSignal = make(chan struct{}, 1)
+	pe.stopSaver = make(chan struct{})
+	pe.saverDone = make(chan struct{})
+
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	restored, err := pe.store.load(ctx, pe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load paper exchange state: %w", err)
+	}
+
+	if !restored {
+		if err := pe.store.save(ctx, pe.name, pe.snapshot(nil)); err != nil {
+			return nil, fmt.Errorf("failed to save paper exchange state: %w", err)
+		}
+	}
+
+	go pe.runSaver()
+
+	logger.WithFields(logrus.Fields{
+		"venue":          name,
+		"restored":       restored,
+		"orders":         len(pe.orders),
+		"trailing_stops": len(pe.trailingStops),
+		"margin_loans":   len(pe.loans),
+	}).Info("Paper exchange state loaded")
+
+	return pe, nil
+}
+
 // Name returns the exchange name
 func (pe *PaperExchange) Name() string {
 	return pe.name
 }
 
-// PlaceOrder places a simulated order
+// PlaceOrder places a simulated order. Market orders and marketable limit
+// orders fill in full against the market the fill model sees after its
+// latency, and are rejected if they exceed the model's volume cap. Other
+// limit orders rest until the price reaches them.
 func (pe *PaperExchange) PlaceOrder(ctx context.Context, req *OrderRequest) (*OrderResponse, error) {
+	pe.mu.RLock()
+	latency := pe.fillModel.Latency(req.Symbol)
+	pe.mu.RUnlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
 	pe.mu.Lock()
 	defer pe.mu.Unlock()
 
+	// A resent client order ID returns the order it already placed
+	if orderID, exists := pe.clientOrders[req.ClientOrderID]; exists {
+		return copyOrder(pe.orders[orderID]), nil
+	}
+
+	// Orders placed while the state can't be saved would be lost on restart
+	if pe.saveErr != nil {
+		return nil, fmt.Errorf("paper exchange state can't be saved, not accepting orders: %w", pe.saveErr)
+	}
+
 	// Get current price
 	currentPrice, exists := pe.currentPrices[req.Symbol]
 	if !exists {
 		return nil, fmt.Errorf("no price available for symbol %s", req.Symbol)
 	}
 
-	// Calculate execution price with slippage
-	executionPrice := pe.calculateExecutionPrice(currentPrice, req.Side, req.Type)
-
-	// Calculate required funds
-	totalCost := executionPrice.Mul(req.Quantity)
-
-	// Calculate fees (taker fee for market orders, maker fee for limit orders)
-	feePercent := pe.takerFeePercent
-	if req.Type == models.OrderTypeLimit {
-		feePercent = pe.makerFeePercent
+	symbolInfo := pe.symbolInfoLocked(req.Symbol)
+	if err := symbolInfo.ValidateQuantity(req.Quantity, currentPrice); err != nil {
+		return nil, err
 	}
-	fees := totalCost.Mul(feePercent).Div(decimal.NewFromInt(100))
 
-	// Check if we have sufficient balance
-	if req.Side == models.OrderSideBuy {
-		totalRequired := totalCost.Add(fees)
-		if pe.balances["USD"].Available.LessThan(totalRequired) {
-			return nil, fmt.Errorf("insufficient balance: need %s, have %s",
-				totalRequired.String(), pe.balances["USD"].Available.String())
-		}
+	if req.Type == models.OrderTypeTrailingStop {
+		return pe.placeTrailingStop(req, currentPrice)
+	}
 
-		// Deduct from USD balance
-		pe.balances["USD"].Available = pe.balances["USD"].Available.Sub(totalRequired)
-		pe.balances["USD"].Total = pe.balances["USD"].Available.Add(pe.balances["USD"].Locked)
+	if req.Type == models.OrderTypeLimit && req.Price == nil {
+		return nil, fmt.Errorf("limit order requires a price")
+	}
 
-		// Add to asset balance
-		baseCurrency := pe.getBaseCurrency(req.Symbol)
-		if _, exists := pe.balances[baseCurrency]; !exists {
-			pe.balances[baseCurrency] = &Balance{
-				Currency:  baseCurrency,
-				Available: decimal.Zero,
-				Locked:    decimal.Zero,
-				Total:     decimal.Zero,
-			}
-		}
-		pe.balances[baseCurrency].Available = pe.balances[baseCurrency].Available.Add(req.Quantity)
-		pe.balances[baseCurrency].Total = pe.balances[baseCurrency].Available.Add(pe.balances[baseCurrency].Locked)
+	market := pe.snapshotLocked(req.Symbol)
+	executionPrice := pe.fillModel.Price(req.Symbol, req.Side, req.Quantity, market)
 
-	} else { // SELL
-		baseCurrency := pe.getBaseCurrency(req.Symbol)
-		if pe.balances[baseCurrency].Available.LessThan(req.Quantity) {
-			return nil, fmt.Errorf("insufficient %s balance: need %s, have %s",
-				baseCurrency, req.Quantity.String(), pe.balances[baseCurrency].Available.String())
-		}
+	if req.Type == models.OrderTypeLimit && !marketable(req.Side, *req.Price, executionPrice) {
+		return pe.placeLimitOrder(req, market)
+	}
 
-		// Deduct from asset balance
-		pe.balances[baseCurrency].Available = pe.balances[baseCurrency].Available.Sub(req.Quantity)
-		pe.balances[baseCurrency].Total = pe.balances[baseCurrency].Available.Add(pe.balances[baseCurrency].Locked)
+	// Reject rather than partially fill, which would leave the caller with a
+	// FILLED order short of its quantity
+	fillable := symbolInfo.RoundQuantity(pe.fillModel.FillableQuantity(req.Symbol, req.Quantity, market))
+	if fillable.LessThan(req.Quantity) {
+		return nil, fmt.Errorf("order of %s %s exceeds the %s the market can fill: last candle traded %s",
+			req.Quantity.String(), req.Symbol, fillable.String(), market.CandleVolume.String())
+	}
 
-		// Add to USD balance (minus fees)
-		usdReceived := totalCost.Sub(fees)
-		pe.balances["USD"].Available = pe.balances["USD"].Available.Add(usdReceived)
-		pe.balances["USD"].Total = pe.balances["USD"].Available.Add(pe.balances["USD"].Locked)
+	// Marketable orders take liquidity
+	fees, err := pe.execute(req.Symbol, req.Intent, req.Side, models.OrderTypeMarket, req.Quantity, executionPrice)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create order response
 	orderID := uuid.New().String()
-	clientOrderID := uuid.New().String()
 	now := time.Now()
 
 	order := &OrderResponse{
 		ID:               orderID,
-		ClientOrderID:    clientOrderID,
+		ClientOrderID:    clientOrderIDOf(req),
 		ExchangeOrderID:  orderID,
 		Symbol:           req.Symbol,
 		Side:             req.Side,
 		Type:             req.Type,
-		Status:           models.OrderStatusFilled, // Paper orders fill immediately
+		Status:           models.OrderStatusFilled,
 		Quantity:         req.Quantity,
 		Price:            &executionPrice,
 		FilledQuantity:   req.Quantity,
@@ -143,6 +221,9 @@ func (pe *PaperExchange) PlaceOrder(ctx context.Context, req *OrderRequest) (*Or
 	}
 
 	pe.orders[orderID] = order
+	pe.clientOrders[order.ClientOrderID] = orderID
+	pe.recordFill(order, executionPrice, req.Quantity, fees, LiquidityTaker)
+	pe.persist(order)
 
 	pe.logger.WithFields(logrus.Fields{
 		"order_id":        orderID,
@@ -153,7 +234,89 @@ func (pe *PaperExchange) PlaceOrder(ctx context.Context, req *OrderRequest) (*Or
 		"fees":            fees.String(),
 	}).Info("Paper order executed")
 
-	return order, nil
+	return copyOrder(order), nil
+}
+
+// marketable returns true if a limit order would execute at the price
+func marketable(side models.OrderSide, limit, price decimal.Decimal) bool {
+	if side == models.OrderSideBuy {
+		return price.LessThanOrEqual(limit)
+	}
+	return price.GreaterThanOrEqual(limit)
+}
+
+// execute settles a fill of an order with the given intent and returns the
+// fees charged, taker fees for market order types and maker fees for limit
+// ones. Must be called with pe.mu held.
+func (pe *PaperExchange) execute(
+	symbol string,
+	intent models.PositionIntent,
+	side models.OrderSide,
+	feeType models.OrderType,
+	quantity decimal.Decimal,
+	executionPrice decimal.Decimal,
+) (decimal.Decimal, error) {
+	switch intent {
+	case models.PositionIntentOpenShort:
+		return pe.openShort(symbol, feeType, quantity, executionPrice)
+	case models.PositionIntentCloseShort:
+		return pe.closeShort(symbol, feeType, quantity, executionPrice)
+	default:
+		return pe.settle(symbol, side, feeType, quantity, executionPrice)
+	}
+}
+
+// settle moves balances for a fill and returns the fees charged.
+// Must be called with pe.mu held.
+func (pe *PaperExchange) settle(
+	symbol string,
+	side models.OrderSide,
+	orderType models.OrderType,
+	quantity decimal.Decimal,
+	executionPrice decimal.Decimal,
+) (decimal.Decimal, error) {
+	// Calculate required funds
+	totalCost := executionPrice.Mul(quantity)
+
+	fees := totalCost.Mul(pe.feePercent(orderType)).Div(decimal.NewFromInt(100))
+
+	baseCurrency := pe.getBaseCurrency(symbol)
+
+	// Check if we have sufficient balance
+	if side == models.OrderSideBuy {
+		totalRequired := totalCost.Add(fees)
+		if pe.balances["USD"].Available.LessThan(totalRequired) {
+			return decimal.Zero, fmt.Errorf("insufficient balance: need %s, have %s",
+				totalRequired.String(), pe.balances["USD"].Available.String())
+		}
+
+		// Deduct from USD balance
+		pe.balances["USD"].Available = pe.balances["USD"].Available.Sub(totalRequired)
+		pe.balances["USD"].Total = pe.balances["USD"].Available.Add(pe.balances["USD"].Locked)
+
+		// Add to asset balance
+		balance := pe.getOrCreateBalance(baseCurrency)
+		balance.Available = balance.Available.Add(quantity)
+		balance.Total = balance.Available.Add(balance.Locked)
+
+	} else { // SELL
+		balance := pe.getOrCreateBalance(baseCurrency)
+		if balance.Available.LessThan(quantity) {
+			return decimal.Zero, fmt.Errorf("insufficient %s balance: need %s, have %s",
+				baseCurrency, quantity.String(), balance.Available.String())
+		}
+
+		// Deduct from asset balance
+		balance.Available = balance.Available.Sub(quantity)
+		balance.Total = balance.Available.Add(balance.Locked)
+
+		// Add to USD balance (minus fees)
+		usdReceived := totalCost.Sub(fees)
+		pe.balances["USD"].Available = pe.balances["USD"].Available.Add(usdReceived)
+		pe.balances["USD"].Total = pe.balances["USD"].Available.Add(pe.balances["USD"].Locked)
+	}
+
+	return fees, nil
 }
 
 // CancelOrder cancels an order (no-op for paper trading)
@@ -170,8 +333,16 @@ func (pe *PaperExchange) CancelOrder(ctx context.Context, orderID string) error
 		return fmt.Errorf("cannot cancel filled order")
 	}
 
+	if ts, exists := pe.trailingStops[orderID]; exists {
+		pe.releaseTrailingStop(ts)
+	}
+	if lo, exists := pe.limitOrders[orderID]; exists {
+		pe.releaseLimitOrder(lo)
+	}
+
 	order.Status = models.OrderStatusCancelled
 	order.UpdatedAt = time.Now()
+	pe.persist(order)
 
 	return nil
 }
@@ -186,7 +357,161 @@ func (pe *PaperExchange) GetOrder(ctx context.Context, orderID string) (*OrderRe
 		return nil, fmt.Errorf("order not found: %s", orderID)
 	}
 
-	return order, nil
+	return copyOrder(order), nil
+}
+
+// GetOrderByClientID gets an order by its client order ID
+func (pe *PaperExchange) GetOrderByClientID(ctx context.Context, clientOrderID string) (*OrderResponse, error) {
+	pe.mu.RLock()
+	defer pe.mu.RUnlock()
+
+	orderID, exists := pe.clientOrders[clientOrderID]
+	if !exists {
+		return nil, fmt.Errorf("%w: client order ID %s", ErrOrderNotFound, clientOrderID)
+	}
+
+	return copyOrder(pe.orders[orderID]), nil
+}
+
+// copyOrder returns a copy of an order that stays consistent after pe.mu is
+// released, as resting orders are updated in place when they fill. Must be
+// called with pe.mu held.
+func copyOrder(order *OrderResponse) *OrderResponse {
+	copied := *order
+	if order.Price != nil {
+		price := *order.Price
+		copied.Price = &price
+	}
+	if order.AverageFillPrice != nil {
+		averageFillPrice := *order.AverageFillPrice
+		copied.AverageFillPrice = &averageFillPrice
+	}
+	return &copied
+}
+
+// clientOrderIDOf returns the request's client order ID, generating one if
+// the caller didn't set it
+func clientOrderIDOf(req *OrderRequest) string {
+	if req.ClientOrderID != "" {
+		return req.ClientOrderID
+	}
+	return uuid.New().String()
+}
+
+// GetFills gets the executions of an order
+func (pe *PaperExchange) GetFills(ctx context.Context, orderID string) ([]*Fill, error) {
+	pe.mu.RLock()
+	defer pe.mu.RUnlock()
+
+	if _, exists := pe.orders[orderID]; !exists {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, orderID)
+	}
+
+	return append([]*Fill(nil), pe.fills[orderID]...), nil
+}
+
+// recordFill records an execution of an order. Must be called with pe.mu held.
+func (pe *PaperExchange) recordFill(order *OrderResponse, executionPrice, quantity, fees decimal.Decimal, liquidity string) {
+	pe.fills[order.ID] = append(pe.fills[order.ID], &Fill{
+		TradeID:     uuid.New().String(),
+		OrderID:     order.ID,
+		Symbol:      order.Symbol,
+		Side:        order.Side,
+		Price:       executionPrice,
+		Quantity:    quantity,
+		Fee:         fees,
+		FeeCurrency: "USD", // Paper fees are always charged in USD
+		Liquidity:   liquidity,
+		Time:        order.UpdatedAt,
+	})
+}
+
+// persist marks orders as changed and wakes the saver, which stores them
+// along with the balances and margin loans, if the exchange has a store.
+// Must be called with pe.mu held.
+func (pe *PaperExchange) persist(orders ...*OrderResponse) {
+	if pe.store == nil {
+		return
+	}
+
+	for _, order := range orders {
+		pe.unsaved[order.ID] = true
+	}
+
+	select {
+	case pe.saveSignal <- struct{}{}:
+	default: // A save is already pending
+	}
+}
+
+// runSaver saves changed orders as they change, and margin interest and
+// resting orders every paperSaveInterval, until Close
+func (pe *PaperExchange) runSaver() {
+	defer close(pe.saverDone)
+
+	ticker := time.NewTicker(paperSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pe.stopSaver:
+			return
+		case <-pe.saveSignal:
+			pe.save(false)
+		case <-ticker.C:
+			pe.save(true)
+		}
+	}
+}
+
+// save writes the changed orders, balances and margin loans to the store
+// without holding pe.mu during the write. With resting set, resting orders
+// are saved too for their high-water marks and queue positions. Orders that
+// fail to save stay marked for the next save, and new orders are refused
+// until one succeeds.
+func (pe *PaperExchange) save(resting bool) error {
+	pe.saveMu.Lock()
+	defer pe.saveMu.Unlock()
+
+	pe.mu.Lock()
+	orderIDs := make([]string, 0, len(pe.unsaved))
+	for orderID := range pe.unsaved {
+		orderIDs = append(orderIDs, orderID)
+	}
+	if resting {
+		for orderID := range pe.trailingStops {
+			orderIDs = append(orderIDs, orderID)
+		}
+		for orderID := range pe.limitOrders {
+			orderIDs = append(orderIDs, orderID)
+		}
+	}
+	if len(orderIDs) == 0 && len(pe.loans) == 0 && pe.saveErr == nil {
+		pe.mu.Unlock()
+		return nil
+	}
+	snap := pe.snapshot(orderIDs)
+	pe.unsaved = make(map[string]bool)
+	pe.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), paperSaveTimeout)
+	defer cancel()
+	err := pe.store.save(ctx, pe.name, snap)
+
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	if err != nil {
+		for i := range snap.orders {
+			pe.unsaved[snap.orders[i].order.ID] = true
+		}
+		pe.logger.WithError(err).WithField("venue", pe.name).Error("Failed to save paper exchange state")
+	} else if pe.saveErr != nil {
+		pe.logger.WithField("venue", pe.name).Info("Paper exchange state saved again")
+	}
+	pe.saveErr = err
+
+	return err
 }
 
 // GetBalance gets account balances
@@ -221,10 +546,59 @@ func (pe *PaperExchange) GetPrice(ctx context.Context, symbol string) (decimal.D
 	return price, nil
 }
 
+// GetSymbolInfo gets the order size rules of a symbol
+func (pe *PaperExchange) GetSymbolInfo(ctx context.Context, symbol string) (*SymbolInfo, error) {
+	pe.mu.RLock()
+	defer pe.mu.RUnlock()
+
+	info := *pe.symbolInfoLocked(symbol)
+	return &info, nil
+}
+
+// SetSymbolInfo sets the order size rules of a symbol
+func (pe *PaperExchange) SetSymbolInfo(info *SymbolInfo) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	pe.symbolInfo[info.Symbol] = info
+}
+
+// symbolInfoLocked returns the rules of a symbol. Must be called with pe.mu held.
+func (pe *PaperExchange) symbolInfoLocked(symbol string) *SymbolInfo {
+	if info, exists := pe.symbolInfo[symbol]; exists {
+		return info
+	}
+	return &SymbolInfo{
+		Symbol:       symbol,
+		MinOrderSize: defaultPaperStepSize,
+		StepSize:     defaultPaperStepSize,
+	}
+}
+
 // UpdatePrice updates the current price for a symbol (used by market data service)
 func (pe *PaperExchange) UpdatePrice(symbol string, price decimal.Decimal) {
+	pe.UpdateQuote(&PriceUpdate{
+		Symbol:    symbol,
+		Price:     price,
+		Timestamp: time.Now(),
+	})
+}
+
+// UpdateQuote updates the price, best bid and ask and traded volume of a
+// symbol, then executes the resting orders the update reaches
+func (pe *PaperExchange) UpdateQuote(update *PriceUpdate) {
+	tick := *update
+	tick.Exchange = pe.name
+	if tick.Timestamp.IsZero() {
+		tick.Timestamp = time.Now()
+	}
+
 	pe.mu.Lock()
-	pe.currentPrices[symbol] = price
+	pe.currentPrices[tick.Symbol] = tick.Price
+	pe.updateMarket(&tick)
+	pe.accrueInterest(tick.Symbol, tick.Price, time.Now())
+	pe.checkTrailingStops(tick.Symbol, tick.Price)
+	pe.checkLimitOrders(tick.Symbol, tick.Price, tick.Volume)
 	pe.mu.Unlock()
 
 	// Notify callbacks
@@ -233,18 +607,19 @@ func (pe *PaperExchange) UpdatePrice(symbol string, price decimal.Decimal) {
 	copy(callbacks, pe.priceCallbacks)
 	pe.priceCallbacksMu.RUnlock()
 
-	update := &PriceUpdate{
-		Exchange:  pe.name,
-		Symbol:    symbol,
-		Price:     price,
-		Timestamp: time.Now(),
-	}
-
 	for _, callback := range callbacks {
-		go callback(update)
+		go callback(&tick)
 	}
 }
 
+// SetFillModel sets how orders are filled
+func (pe *PaperExchange) SetFillModel(model FillModel) {
+	pe.mu.Lock()
+	defer pe.mu.Unlock()
+
+	pe.fillModel = model
+}
+
 // SubscribePriceUpdates subscribes to price updates
 func (pe *PaperExchange) SubscribePriceUpdates(ctx context.Context, symbols []string, callback func(*PriceUpdate)) error {
 	pe.priceCallbacksMu.Lock()
@@ -257,28 +632,43 @@ func (pe *PaperExchange) SubscribePriceUpdates(ctx context.Context, symbols []st
 	return nil
 }
 
-// Close closes the exchange connection (no-op for paper trading)
+// Close stops the saver after a final save, returning its error
 func (pe *PaperExchange) Close() error {
+	var err error
+	if pe.store != nil {
+		pe.closeOnce.Do(func() {
+			close(pe.stopSaver)
+			<-pe.saverDone
+			err = pe.save(true)
+		})
+	}
+
 	pe.logger.Info("Paper exchange closed")
-	return nil
+	return err
 }
 
 // Helper methods
 
-func (pe *PaperExchange) calculateExecutionPrice(price decimal.Decimal, side models.OrderSide, orderType models.OrderType) decimal.Decimal {
+// feePercent returns the taker fee for market orders, maker fee for limit orders
+func (pe *PaperExchange) feePercent(orderType models.OrderType) decimal.Decimal {
 	if orderType == models.OrderTypeLimit {
-		// Limit orders execute at the limit price (no slippage)
-		return price
+		return pe.makerFeePercent
 	}
+	return pe.takerFeePercent
+}
 
-	// Market orders have slippage
-	slippage := price.Mul(pe.slippagePercent).Div(decimal.NewFromInt(100))
-	if side == models.OrderSideBuy {
-		// Buying costs more (slippage against us)
-		return price.Add(slippage)
+func (pe *PaperExchange) getOrCreateBalance(currency string) *Balance {
+	balance, exists := pe.balances[currency]
+	if !exists {
+		balance = &Balance{
+			Currency:  currency,
+			Available: decimal.Zero,
+			Locked:    decimal.Zero,
+			Total:     decimal.Zero,
+		}
+		pe.balances[currency] = balance
 	}
-	// Selling gets less (slippage against us)
-	return price.Sub(slippage)
+	return balance
 }
 
 func (pe *PaperExchange) getBaseCurrency(symbol string) string {
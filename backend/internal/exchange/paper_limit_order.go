@@ -0,0 +1,182 @@
+package exchange
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// paperLimitOrder is a resting limit order. It fills at its limit once the
+// price trades through it, or once the volume queued ahead of it has traded
+// at the limit.
+type paperLimitOrder struct {
+	order      *OrderResponse
+	intent     models.PositionIntent
+	queueAhead decimal.Decimal
+}
+
+// locksBase returns true if the order holds the base currency it will sell
+func (lo *paperLimitOrder) locksBase() bool {
+	return lo.order.Side == models.OrderSideSell &&
+		lo.intent != models.PositionIntentOpenShort && lo.intent != models.PositionIntentCloseShort
+}
+
+// quoteLock returns the USD a resting order reserves for its fill: the cost
+// and maker fee of a buy, or the collateral and maker fee of a short sale.
+// Spot sells lock base currency instead.
+func (pe *PaperExchange) quoteLock(lo *paperLimitOrder) decimal.Decimal {
+	if lo.locksBase() {
+		return decimal.Zero
+	}
+
+	percent := decimal.NewFromInt(100).Add(pe.makerFeePercent)
+	if lo.intent == models.PositionIntentOpenShort {
+		percent = pe.initialMargin.Add(pe.makerFeePercent)
+	}
+	return lo.order.Price.Mul(lo.order.Quantity).Mul(percent).Div(decimal.NewFromInt(100))
+}
+
+// placeLimitOrder rests a limit order at a random queue position drawn by
+// the fill model. Spot sells lock the base currency they will sell, other
+// orders the USD they will pay. Must be called with pe.mu held.
+func (pe *PaperExchange) placeLimitOrder(req *OrderRequest, market MarketSnapshot) (*OrderResponse, error) {
+	orderID := uuid.New().String()
+	now := time.Now()
+	limit := *req.Price
+
+	lo := &paperLimitOrder{
+		order: &OrderResponse{
+			ID:              orderID,
+			ClientOrderID:   clientOrderIDOf(req),
+			ExchangeOrderID: orderID,
+			Symbol:          req.Symbol,
+			Side:            req.Side,
+			Type:            req.Type,
+			Status:          models.OrderStatusOpen,
+			Quantity:        req.Quantity,
+			Price:           &limit,
+			FilledQuantity:  decimal.Zero,
+			Fees:            decimal.Zero,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		},
+		intent:     req.Intent,
+		queueAhead: pe.fillModel.QueueAhead(req.Symbol, req.Quantity, market),
+	}
+
+	if lo.locksBase() {
+		baseCurrency := pe.getBaseCurrency(req.Symbol)
+		balance := pe.getOrCreateBalance(baseCurrency)
+		if balance.Available.LessThan(req.Quantity) {
+			return nil, fmt.Errorf("insufficient %s balance: need %s, have %s",
+				baseCurrency, req.Quantity.String(), balance.Available.String())
+		}
+
+		// Lock the quantity so it can't be sold twice
+		balance.Available = balance.Available.Sub(req.Quantity)
+		balance.Locked = balance.Locked.Add(req.Quantity)
+	} else {
+		required := pe.quoteLock(lo)
+		usd := pe.getOrCreateBalance("USD")
+		if usd.Available.LessThan(required) {
+			return nil, fmt.Errorf("insufficient balance: need %s, have %s",
+				required.String(), usd.Available.String())
+		}
+
+		// Lock the USD so it can't be spent twice
+		usd.Available = usd.Available.Sub(required)
+		usd.Locked = usd.Locked.Add(required)
+	}
+
+	pe.orders[orderID] = lo.order
+	pe.clientOrders[lo.order.ClientOrderID] = orderID
+	pe.limitOrders[orderID] = lo
+	pe.persist(lo.order)
+
+	pe.logger.WithFields(logrus.Fields{
+		"order_id":    orderID,
+		"symbol":      req.Symbol,
+		"side":        req.Side,
+		"quantity":    req.Quantity.String(),
+		"limit_price": limit.String(),
+		"queue_ahead": lo.queueAhead.String(),
+	}).Info("Paper limit order resting")
+
+	return copyOrder(lo.order), nil
+}
+
+// checkLimitOrders fills the resting limit orders of a symbol the price has
+// reached, as makers at their limit. Volume traded at the limit works through
+// the queue ahead of an order. Must be called with pe.mu held.
+func (pe *PaperExchange) checkLimitOrders(symbol string, price, volume decimal.Decimal) {
+	var executed []*OrderResponse
+	defer func() {
+		if len(executed) > 0 {
+			pe.persist(executed...)
+		}
+	}()
+
+	for _, lo := range pe.limitOrders {
+		order := lo.order
+		if order.Symbol != symbol {
+			continue
+		}
+
+		limit := *order.Price
+		if price.Equal(limit) {
+			lo.queueAhead = lo.queueAhead.Sub(volume)
+			if lo.queueAhead.IsPositive() {
+				continue
+			}
+		} else if !marketable(order.Side, limit, price) {
+			continue
+		}
+
+		pe.releaseLimitOrder(lo)
+		executed = append(executed, order)
+
+		fees, err := pe.execute(symbol, lo.intent, order.Side, models.OrderTypeLimit, order.Quantity, limit)
+		order.UpdatedAt = time.Now()
+		if err != nil {
+			order.Status = models.OrderStatusFailed
+			pe.logger.WithError(err).WithField("order_id", order.ID).Error("Paper limit order failed to execute")
+			continue
+		}
+
+		order.Status = models.OrderStatusFilled
+		order.FilledQuantity = order.Quantity
+		order.AverageFillPrice = &limit
+		order.Fees = fees
+		pe.recordFill(order, limit, order.Quantity, fees, LiquidityMaker)
+
+		pe.logger.WithFields(logrus.Fields{
+			"order_id":    order.ID,
+			"symbol":      symbol,
+			"side":        order.Side,
+			"quantity":    order.Quantity.String(),
+			"limit_price": limit.String(),
+			"fees":        fees.String(),
+		}).Info("Paper limit order executed")
+	}
+}
+
+// releaseLimitOrder removes a resting limit order and unlocks its funds.
+// Must be called with pe.mu held.
+func (pe *PaperExchange) releaseLimitOrder(lo *paperLimitOrder) {
+	delete(pe.limitOrders, lo.order.ID)
+
+	if lo.locksBase() {
+		balance := pe.getOrCreateBalance(pe.getBaseCurrency(lo.order.Symbol))
+		balance.Locked = balance.Locked.Sub(lo.order.Quantity)
+		balance.Available = balance.Available.Add(lo.order.Quantity)
+	} else {
+		locked := pe.quoteLock(lo)
+		usd := pe.getOrCreateBalance("USD")
+		usd.Locked = usd.Locked.Sub(locked)
+		usd.Available = usd.Available.Add(locked)
+	}
+}
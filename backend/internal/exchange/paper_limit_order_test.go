@@ -0,0 +1,130 @@
+package exchange
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestPaperExchange(usd string) *PaperExchange {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewPaperExchange("paper", dec(usd), logger)
+}
+
+func limitRequest(side models.OrderSide, intent models.PositionIntent, quantity, limit string) *OrderRequest {
+	price := dec(limit)
+	return &OrderRequest{
+		Symbol:   "BTC-USD",
+		Side:     side,
+		Intent:   intent,
+		Type:     models.OrderTypeLimit,
+		Quantity: dec(quantity),
+		Price:    &price,
+	}
+}
+
+func usdBalance(t *testing.T, pe *PaperExchange) (decimal.Decimal, decimal.Decimal) {
+	t.Helper()
+	balances, err := pe.GetBalance(context.Background())
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	return balances["USD"].Available, balances["USD"].Locked
+}
+
+func TestPaperLimitOrderLocksQuote(t *testing.T) {
+	tests := []struct {
+		name   string
+		side   models.OrderSide
+		intent models.PositionIntent
+		locked string // Cost plus 0.25% maker fee, or 50% margin plus maker fee
+	}{
+		{"spot buy", models.OrderSideBuy, "", "4511.25"},
+		{"open short", models.OrderSideSell, models.PositionIntentOpenShort, "2763.75"},
+		{"close short", models.OrderSideBuy, models.PositionIntentCloseShort, "4511.25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pe := newTestPaperExchange("10000")
+			pe.UpdatePrice("BTC-USD", dec("100"))
+
+			limit := "90"
+			if tt.side == models.OrderSideSell {
+				limit = "110"
+			}
+
+			order, err := pe.PlaceOrder(context.Background(), limitRequest(tt.side, tt.intent, "50", limit))
+			if err != nil {
+				t.Fatalf("PlaceOrder: %v", err)
+			}
+			if order.Status != models.OrderStatusOpen {
+				t.Fatalf("status = %s, want OPEN", order.Status)
+			}
+
+			available, locked := usdBalance(t, pe)
+			if !locked.Equal(dec(tt.locked)) || !available.Equal(dec("10000").Sub(dec(tt.locked))) {
+				t.Errorf("USD available %s, locked %s, want %s locked", available, locked, tt.locked)
+			}
+
+			if err := pe.CancelOrder(context.Background(), order.ID); err != nil {
+				t.Fatalf("CancelOrder: %v", err)
+			}
+			available, locked = usdBalance(t, pe)
+			if !locked.IsZero() || !available.Equal(dec("10000")) {
+				t.Errorf("after cancel USD available %s, locked %s, want all available", available, locked)
+			}
+		})
+	}
+}
+
+func TestPaperLimitOrderRejectsUnfundedBuys(t *testing.T) {
+	pe := newTestPaperExchange("10000")
+	pe.UpdatePrice("BTC-USD", dec("100"))
+
+	if _, err := pe.PlaceOrder(context.Background(), limitRequest(models.OrderSideBuy, "", "60", "90")); err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+
+	// The first order holds 5413.50, leaving too little for a second
+	if _, err := pe.PlaceOrder(context.Background(), limitRequest(models.OrderSideBuy, "", "60", "90")); err == nil {
+		t.Fatal("PlaceOrder without funds succeeded")
+	}
+}
+
+func TestPaperLimitOrderFill(t *testing.T) {
+	pe := newTestPaperExchange("10000")
+	pe.UpdatePrice("BTC-USD", dec("100"))
+
+	placed, err := pe.PlaceOrder(context.Background(), limitRequest(models.OrderSideBuy, "", "10", "90"))
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+
+	pe.UpdatePrice("BTC-USD", dec("89"))
+
+	// The order returned at placement is a copy and isn't changed by the fill
+	if placed.Status != models.OrderStatusOpen || !placed.FilledQuantity.IsZero() {
+		t.Errorf("placed order changed to %s with %s filled", placed.Status, placed.FilledQuantity)
+	}
+
+	order, err := pe.GetOrder(context.Background(), placed.ID)
+	if err != nil {
+		t.Fatalf("GetOrder: %v", err)
+	}
+	if order.Status != models.OrderStatusFilled || !order.FilledQuantity.Equal(dec("10")) ||
+		!order.AverageFillPrice.Equal(dec("90")) || !order.Fees.Equal(dec("2.25")) {
+		t.Errorf("order = %s, %s filled at %s, fees %s, want FILLED, 10 at 90, fees 2.25",
+			order.Status, order.FilledQuantity, order.AverageFillPrice, order.Fees)
+	}
+
+	available, locked := usdBalance(t, pe)
+	if !available.Equal(dec("9097.75")) || !locked.IsZero() {
+		t.Errorf("USD available %s, locked %s, want 9097.75 and 0", available, locked)
+	}
+}
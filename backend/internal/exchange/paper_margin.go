@@ -0,0 +1,161 @@
+package exchange
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// MarginLoan is a balance borrowed by the paper margin account to sell short
+type MarginLoan struct {
+	Symbol          string
+	Currency        string
+	Borrowed        decimal.Decimal // Quantity of the currency owed
+	Collateral      decimal.Decimal // USD locked while the loan is open
+	AccruedInterest decimal.Decimal // Interest owed in USD
+	LastAccrual     time.Time
+}
+
+// openShort borrows the base currency and sells it, locking USD collateral.
+// Must be called with pe.mu held.
+func (pe *PaperExchange) openShort(
+	symbol string,
+	orderType models.OrderType,
+	quantity decimal.Decimal,
+	executionPrice decimal.Decimal,
+) (decimal.Decimal, error) {
+	notional := executionPrice.Mul(quantity)
+	fees := notional.Mul(pe.feePercent(orderType)).Div(decimal.NewFromInt(100))
+	collateral := notional.Mul(pe.initialMargin).Div(decimal.NewFromInt(100))
+
+	usd := pe.balances["USD"]
+	if usd.Available.LessThan(collateral.Add(fees)) {
+		return decimal.Zero, fmt.Errorf("insufficient margin: need %s, have %s",
+			collateral.Add(fees).String(), usd.Available.String())
+	}
+
+	now := time.Now()
+	pe.accrueInterest(symbol, executionPrice, now)
+
+	loan, exists := pe.loans[symbol]
+	if !exists {
+		loan = &MarginLoan{
+			Symbol:          symbol,
+			Currency:        pe.getBaseCurrency(symbol),
+			Borrowed:        decimal.Zero,
+			Collateral:      decimal.Zero,
+			AccruedInterest: decimal.Zero,
+			LastAccrual:     now,
+		}
+		pe.loans[symbol] = loan
+	}
+	loan.Borrowed = loan.Borrowed.Add(quantity)
+	loan.Collateral = loan.Collateral.Add(collateral)
+
+	// Sale proceeds are credited, collateral is locked
+	usd.Available = usd.Available.Add(notional).Sub(fees).Sub(collateral)
+	usd.Locked = usd.Locked.Add(collateral)
+	usd.Total = usd.Available.Add(usd.Locked)
+
+	pe.logger.WithFields(logrus.Fields{
+		"symbol":     symbol,
+		"borrowed":   loan.Borrowed.String(),
+		"collateral": loan.Collateral.String(),
+	}).Info("Paper margin loan opened")
+
+	return fees, nil
+}
+
+// closeShort buys back the base currency and repays its loan with a
+// proportional share of accrued interest, which is charged as a fee.
+// Must be called with pe.mu held.
+func (pe *PaperExchange) closeShort(
+	symbol string,
+	orderType models.OrderType,
+	quantity decimal.Decimal,
+	executionPrice decimal.Decimal,
+) (decimal.Decimal, error) {
+	loan, exists := pe.loans[symbol]
+	if !exists || loan.Borrowed.LessThan(quantity) {
+		borrowed := decimal.Zero
+		if exists {
+			borrowed = loan.Borrowed
+		}
+		return decimal.Zero, fmt.Errorf("cannot repay %s %s: only %s borrowed",
+			quantity.String(), pe.getBaseCurrency(symbol), borrowed.String())
+	}
+
+	pe.accrueInterest(symbol, executionPrice, time.Now())
+
+	share := quantity.Div(loan.Borrowed)
+	interest := loan.AccruedInterest.Mul(share)
+	collateral := loan.Collateral.Mul(share)
+
+	cost := executionPrice.Mul(quantity)
+	fees := cost.Mul(pe.feePercent(orderType)).Div(decimal.NewFromInt(100)).Add(interest)
+
+	usd := pe.balances["USD"]
+	available := usd.Available.Add(collateral)
+	if available.LessThan(cost.Add(fees)) {
+		return decimal.Zero, fmt.Errorf("insufficient balance to cover short: need %s, have %s",
+			cost.Add(fees).String(), available.String())
+	}
+
+	usd.Locked = usd.Locked.Sub(collateral)
+	usd.Available = available.Sub(cost).Sub(fees)
+	usd.Total = usd.Available.Add(usd.Locked)
+
+	loan.Borrowed = loan.Borrowed.Sub(quantity)
+	loan.Collateral = loan.Collateral.Sub(collateral)
+	loan.AccruedInterest = loan.AccruedInterest.Sub(interest)
+	if loan.Borrowed.IsZero() {
+		delete(pe.loans, symbol)
+	}
+
+	pe.logger.WithFields(logrus.Fields{
+		"symbol":   symbol,
+		"repaid":   quantity.String(),
+		"interest": interest.String(),
+	}).Info("Paper margin loan repaid")
+
+	return fees, nil
+}
+
+// accrueInterest accrues borrow interest on a symbol's loan up to now,
+// valued at the given price. Must be called with pe.mu held.
+func (pe *PaperExchange) accrueInterest(symbol string, price decimal.Decimal, now time.Time) {
+	loan, exists := pe.loans[symbol]
+	if !exists {
+		return
+	}
+
+	elapsed := now.Sub(loan.LastAccrual)
+	if elapsed <= 0 {
+		return
+	}
+
+	yearFraction := decimal.NewFromFloat(elapsed.Hours() / (365 * 24))
+	interest := loan.Borrowed.Mul(price).
+		Mul(pe.marginRate).Div(decimal.NewFromInt(100)).
+		Mul(yearFraction)
+
+	loan.AccruedInterest = loan.AccruedInterest.Add(interest)
+	loan.LastAccrual = now
+}
+
+// GetMarginLoans returns the open loans of the paper margin account
+func (pe *PaperExchange) GetMarginLoans() map[string]*MarginLoan {
+	pe.mu.RLock()
+	defer pe.mu.RUnlock()
+
+	loans := make(map[string]*MarginLoan, len(pe.loans))
+	for symbol, loan := range pe.loans {
+		loanCopy := *loan
+		loans[symbol] = &loanCopy
+	}
+
+	return loans
+}
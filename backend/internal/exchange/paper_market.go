@@ -0,0 +1,53 @@
+package exchange
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// paperMarket is what the paper exchange knows of a symbol's market beyond
+// its last price
+type paperMarket struct {
+	bid              decimal.Decimal
+	ask              decimal.Decimal
+	candleStart      time.Time       // Start of the 1m candle being built
+	candleVolume     decimal.Decimal // Volume traded in it so far
+	lastCandleVolume decimal.Decimal // Volume of the previous 1m candle
+}
+
+// updateMarket records the quote and traded volume of a tick. Must be called
+// with pe.mu held.
+func (pe *PaperExchange) updateMarket(update *PriceUpdate) {
+	market, exists := pe.markets[update.Symbol]
+	if !exists {
+		market = &paperMarket{}
+		pe.markets[update.Symbol] = market
+	}
+
+	// A tick without a quote clears the previous one rather than leave it stale
+	market.bid = update.Bid
+	market.ask = update.Ask
+
+	candleStart := update.Timestamp.Truncate(time.Minute)
+	if candleStart.After(market.candleStart) {
+		if !market.candleStart.IsZero() {
+			market.lastCandleVolume = market.candleVolume
+		}
+		market.candleStart = candleStart
+		market.candleVolume = decimal.Zero
+	}
+	market.candleVolume = market.candleVolume.Add(update.Volume)
+}
+
+// snapshotLocked returns the market of a symbol as the fill model sees it.
+// Must be called with pe.mu held.
+func (pe *PaperExchange) snapshotLocked(symbol string) MarketSnapshot {
+	snapshot := MarketSnapshot{Price: pe.currentPrices[symbol]}
+	if market, exists := pe.markets[symbol]; exists {
+		snapshot.Bid = market.bid
+		snapshot.Ask = market.ask
+		snapshot.CandleVolume = market.lastCandleVolume
+	}
+	return snapshot
+}
@@ -0,0 +1,43 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/crypto-trading-bot/internal/events"
+	"github.com/shopspring/decimal"
+)
+
+// FollowPriceFeed drives the paper exchange's prices from the price updates
+// published on the bus, so paper orders execute at the prices of whatever
+// source the market data service reads, e.g. live Coinbase tickers. Updates
+// the exchange published itself are skipped.
+func (pe *PaperExchange) FollowPriceFeed(bus events.Bus) (events.Subscription, error) {
+	sub, err := bus.Subscribe(string(events.EventTypePriceUpdate), func(event *events.Event) error {
+		var update events.PriceUpdateEvent
+		if err := json.Unmarshal(event.Data, &update); err != nil {
+			return fmt.Errorf("failed to unmarshal price update: %w", err)
+		}
+
+		if update.Exchange == pe.name || update.Price <= 0 {
+			return nil
+		}
+
+		pe.UpdateQuote(&PriceUpdate{
+			Symbol:    update.Symbol,
+			Price:     decimal.NewFromFloat(update.Price),
+			Bid:       decimal.NewFromFloat(update.Bid),
+			Ask:       decimal.NewFromFloat(update.Ask),
+			Volume:    decimal.NewFromFloat(update.Volume),
+			Timestamp: update.Time,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to follow price feed: %w", err)
+	}
+
+	pe.logger.WithField("venue", pe.name).Info("Paper exchange following the price feed")
+
+	return sub, nil
+}
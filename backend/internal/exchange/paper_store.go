@@ -0,0 +1,365 @@
+package exchange
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// paperSaveTimeout bounds how long saving the paper exchange's state may take
+	paperSaveTimeout = 5 * time.Second
+
+	// paperSaveInterval is how often margin interest and the state of resting
+	// orders, which change with every price, are saved
+	paperSaveInterval = 5 * time.Second
+)
+
+// paperStore keeps the state of a paper exchange in Postgres, keyed by the
+// exchange name as its venue
+type paperStore struct {
+	db *sql.DB
+}
+
+// load restores the venue's balances, orders, fills, resting trailing stops
+// and limit orders and margin loans into the exchange. It returns false if the venue has no
+// stored state yet. Must be called with pe.mu held.
+func (ps *paperStore) load(ctx context.Context, pe *PaperExchange) (bool, error) {
+	balances, err := ps.loadBalances(ctx, pe.name)
+	if err != nil {
+		return false, err
+	}
+	if len(balances) == 0 {
+		return false, nil
+	}
+	pe.balances = balances
+
+	if err := ps.loadOrders(ctx, pe); err != nil {
+		return false, err
+	}
+	if err := ps.loadFills(ctx, pe); err != nil {
+		return false, err
+	}
+	if err := ps.loadLoans(ctx, pe); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// loadBalances returns the stored balances of a venue
+func (ps *paperStore) loadBalances(ctx context.Context, venue string) (map[string]*Balance, error) {
+	rows, err := ps.db.QueryContext(ctx, `
+		SELECT currency, available, locked FROM paper_balances WHERE venue = $1
+	`, venue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paper balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make(map[string]*Balance)
+	for rows.Next() {
+		balance := &Balance{}
+		if err := rows.Scan(&balance.Currency, &balance.Available, &balance.Locked); err != nil {
+			return nil, fmt.Errorf("failed to scan paper balance: %w", err)
+		}
+		balance.Total = balance.Available.Add(balance.Locked)
+		balances[balance.Currency] = balance
+	}
+
+	return balances, rows.Err()
+}
+
+// loadOrders restores the venue's orders and rests its open trailing stops
+// and limit orders again. Must be called with pe.mu held.
+func (ps *paperStore) loadOrders(ctx context.Context, pe *PaperExchange) error {
+	rows, err := ps.db.QueryContext(ctx, `
+		SELECT id, client_order_id, symbol, side, type, status, quantity, price,
+		       filled_quantity, average_fill_price, fees,
+		       trailing_percent, trailing_amount, high_water_mark, intent, queue_ahead,
+		       created_at, updated_at
+		FROM paper_orders WHERE venue = $1
+	`, pe.name)
+	if err != nil {
+		return fmt.Errorf("failed to get paper orders: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		order := &OrderResponse{}
+		var price, averageFillPrice, trailingPercent, trailingAmount, highWaterMark, queueAhead decimal.NullDecimal
+		var intent models.PositionIntent
+		if err := rows.Scan(
+			&order.ID,
+			&order.ClientOrderID,
+			&order.Symbol,
+			&order.Side,
+			&order.Type,
+			&order.Status,
+			&order.Quantity,
+			&price,
+			&order.FilledQuantity,
+			&averageFillPrice,
+			&order.Fees,
+			&trailingPercent,
+			&trailingAmount,
+			&highWaterMark,
+			&intent,
+			&queueAhead,
+			&order.CreatedAt,
+			&order.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to scan paper order: %w", err)
+		}
+		order.ExchangeOrderID = order.ID
+		order.Price = nullDecimalPtr(price)
+		order.AverageFillPrice = nullDecimalPtr(averageFillPrice)
+
+		pe.orders[order.ID] = order
+		pe.clientOrders[order.ClientOrderID] = order.ID
+
+		if order.Type == models.OrderTypeTrailingStop && order.Status == models.OrderStatusOpen && highWaterMark.Valid {
+			ts := &paperTrailingStop{
+				order:         order,
+				percent:       nullDecimalPtr(trailingPercent),
+				amount:        nullDecimalPtr(trailingAmount),
+				highWaterMark: highWaterMark.Decimal,
+			}
+			ts.update(highWaterMark.Decimal)
+			pe.trailingStops[order.ID] = ts
+		}
+
+		if order.Type == models.OrderTypeLimit && order.Status == models.OrderStatusOpen && order.Price != nil {
+			pe.limitOrders[order.ID] = &paperLimitOrder{
+				order:      order,
+				intent:     intent,
+				queueAhead: queueAhead.Decimal,
+			}
+		}
+	}
+
+	return rows.Err()
+}
+
+// loadFills restores the executions of the venue's orders. Must be called
+// with pe.mu held.
+func (ps *paperStore) loadFills(ctx context.Context, pe *PaperExchange) error {
+	rows, err := ps.db.QueryContext(ctx, `
+		SELECT f.trade_id, f.order_id, f.symbol, f.side, f.price, f.quantity,
+		       f.fee, f.fee_currency, f.liquidity, f.executed_at
+		FROM paper_fills f
+		JOIN paper_orders o ON o.id = f.order_id
+		WHERE o.venue = $1
+		ORDER BY f.executed_at
+	`, pe.name)
+	if err != nil {
+		return fmt.Errorf("failed to get paper fills: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		fill := &Fill{}
+		if err := rows.Scan(
+			&fill.TradeID,
+			&fill.OrderID,
+			&fill.Symbol,
+			&fill.Side,
+			&fill.Price,
+			&fill.Quantity,
+			&fill.Fee,
+			&fill.FeeCurrency,
+			&fill.Liquidity,
+			&fill.Time,
+		); err != nil {
+			return fmt.Errorf("failed to scan paper fill: %w", err)
+		}
+		pe.fills[fill.OrderID] = append(pe.fills[fill.OrderID], fill)
+	}
+
+	return rows.Err()
+}
+
+// loadLoans restores the venue's margin loans. Must be called with pe.mu held.
+func (ps *paperStore) loadLoans(ctx context.Context, pe *PaperExchange) error {
+	rows, err := ps.db.QueryContext(ctx, `
+		SELECT symbol, currency, borrowed, collateral, accrued_interest, last_accrual
+		FROM paper_margin_loans WHERE venue = $1
+	`, pe.name)
+	if err != nil {
+		return fmt.Errorf("failed to get paper margin loans: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		loan := &MarginLoan{}
+		if err := rows.Scan(
+			&loan.Symbol,
+			&loan.Currency,
+			&loan.Borrowed,
+			&loan.Collateral,
+			&loan.AccruedInterest,
+			&loan.LastAccrual,
+		); err != nil {
+			return fmt.Errorf("failed to scan paper margin loan: %w", err)
+		}
+		pe.loans[loan.Symbol] = loan
+	}
+
+	return rows.Err()
+}
+
+// paperSnapshot is a copy of the state of a paper exchange to save, taken
+// with pe.mu held so it can be written without holding the lock
+type paperSnapshot struct {
+	balances []Balance
+	loans    []MarginLoan
+	orders   []paperOrderSnapshot
+}
+
+// paperOrderSnapshot is a copy of an order with its fills and resting state
+type paperOrderSnapshot struct {
+	order           OrderResponse
+	fills           []Fill
+	trailingPercent *decimal.Decimal
+	trailingAmount  *decimal.Decimal
+	highWaterMark   *decimal.Decimal
+	intent          models.PositionIntent
+	queueAhead      *decimal.Decimal
+}
+
+// snapshot copies the balances, margin loans and the given orders of the
+// exchange. Must be called with pe.mu held.
+func (pe *PaperExchange) snapshot(orderIDs []string) *paperSnapshot {
+	snap := &paperSnapshot{}
+	for _, balance := range pe.balances {
+		snap.balances = append(snap.balances, *balance)
+	}
+	for _, loan := range pe.loans {
+		snap.loans = append(snap.loans, *loan)
+	}
+
+	for _, orderID := range orderIDs {
+		order, exists := pe.orders[orderID]
+		if !exists {
+			continue
+		}
+
+		orderSnap := paperOrderSnapshot{order: *order}
+		for _, fill := range pe.fills[orderID] {
+			orderSnap.fills = append(orderSnap.fills, *fill)
+		}
+		if ts, exists := pe.trailingStops[orderID]; exists {
+			highWaterMark := ts.highWaterMark
+			orderSnap.trailingPercent, orderSnap.trailingAmount = ts.percent, ts.amount
+			orderSnap.highWaterMark = &highWaterMark
+		}
+		if lo, exists := pe.limitOrders[orderID]; exists {
+			queueAhead := lo.queueAhead
+			orderSnap.intent = lo.intent
+			orderSnap.queueAhead = &queueAhead
+		}
+		snap.orders = append(snap.orders, orderSnap)
+	}
+
+	return snap
+}
+
+// save stores a snapshot of the venue's state
+func (ps *paperStore) save(ctx context.Context, venue string, snap *paperSnapshot) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, balance := range snap.balances {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO paper_balances (venue, currency, available, locked, updated_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			ON CONFLICT (venue, currency) DO UPDATE
+			SET available = EXCLUDED.available, locked = EXCLUDED.locked, updated_at = NOW()
+		`, venue, balance.Currency, balance.Available, balance.Locked)
+		if err != nil {
+			return fmt.Errorf("failed to save paper balance: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM paper_margin_loans WHERE venue = $1`, venue); err != nil {
+		return fmt.Errorf("failed to clear paper margin loans: %w", err)
+	}
+	for _, loan := range snap.loans {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO paper_margin_loans (venue, symbol, currency, borrowed, collateral, accrued_interest, last_accrual)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, venue, loan.Symbol, loan.Currency, loan.Borrowed, loan.Collateral, loan.AccruedInterest, loan.LastAccrual)
+		if err != nil {
+			return fmt.Errorf("failed to save paper margin loan: %w", err)
+		}
+	}
+
+	for i := range snap.orders {
+		if err := ps.saveOrder(ctx, tx, venue, &snap.orders[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// saveOrder upserts an order, the trailing state of a resting trailing stop,
+// the queue position of a resting limit order and the order's fills
+func (ps *paperStore) saveOrder(ctx context.Context, tx *sql.Tx, venue string, snap *paperOrderSnapshot) error {
+	order := &snap.order
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO paper_orders (
+			id, venue, client_order_id, symbol, side, type, status, quantity, price,
+			filled_quantity, average_fill_price, fees,
+			trailing_percent, trailing_amount, high_water_mark, intent, queue_ahead, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		ON CONFLICT (id) DO UPDATE
+		SET status = EXCLUDED.status,
+		    price = EXCLUDED.price,
+		    filled_quantity = EXCLUDED.filled_quantity,
+		    average_fill_price = EXCLUDED.average_fill_price,
+		    fees = EXCLUDED.fees,
+		    high_water_mark = EXCLUDED.high_water_mark,
+		    queue_ahead = EXCLUDED.queue_ahead,
+		    updated_at = EXCLUDED.updated_at
+	`, order.ID, venue, order.ClientOrderID, order.Symbol, order.Side, order.Type, order.Status,
+		order.Quantity, order.Price, order.FilledQuantity, order.AverageFillPrice, order.Fees,
+		snap.trailingPercent, snap.trailingAmount, snap.highWaterMark, snap.intent, snap.queueAhead,
+		order.CreatedAt, order.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save paper order: %w", err)
+	}
+
+	for _, fill := range snap.fills {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO paper_fills (
+				trade_id, order_id, symbol, side, price, quantity, fee, fee_currency, liquidity, executed_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (trade_id) DO NOTHING
+		`, fill.TradeID, fill.OrderID, fill.Symbol, fill.Side, fill.Price, fill.Quantity,
+			fill.Fee, fill.FeeCurrency, fill.Liquidity, fill.Time)
+		if err != nil {
+			return fmt.Errorf("failed to save paper fill: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// nullDecimalPtr returns the value of a nullable decimal, or nil if it is NULL
+func nullDecimalPtr(value decimal.NullDecimal) *decimal.Decimal {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Decimal
+}
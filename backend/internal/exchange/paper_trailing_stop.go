@@ -0,0 +1,189 @@
+package exchange
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// paperTrailingStop is a resting trailing-stop order. The high-water mark is
+// the best price seen since placement: the highest for sell stops protecting
+// a long, the lowest for buy stops protecting a short.
+type paperTrailingStop struct {
+	order         *OrderResponse
+	percent       *decimal.Decimal
+	amount        *decimal.Decimal
+	highWaterMark decimal.Decimal
+	stopPrice     decimal.Decimal
+}
+
+// distance returns how far the stop trails the high-water mark
+func (ts *paperTrailingStop) distance() decimal.Decimal {
+	if ts.percent != nil {
+		return ts.highWaterMark.Mul(*ts.percent).Div(decimal.NewFromInt(100))
+	}
+	return *ts.amount
+}
+
+// update moves the high-water mark and stop price with a new price
+func (ts *paperTrailingStop) update(price decimal.Decimal) {
+	if ts.order.Side == models.OrderSideSell {
+		if price.GreaterThan(ts.highWaterMark) {
+			ts.highWaterMark = price
+		}
+		ts.stopPrice = ts.highWaterMark.Sub(ts.distance())
+		return
+	}
+
+	if price.LessThan(ts.highWaterMark) {
+		ts.highWaterMark = price
+	}
+	ts.stopPrice = ts.highWaterMark.Add(ts.distance())
+}
+
+// triggered returns true if the price crossed the stop
+func (ts *paperTrailingStop) triggered(price decimal.Decimal) bool {
+	if ts.order.Side == models.OrderSideSell {
+		return price.LessThanOrEqual(ts.stopPrice)
+	}
+	return price.GreaterThanOrEqual(ts.stopPrice)
+}
+
+// placeTrailingStop rests a trailing-stop order until the price crosses it.
+// Sell stops lock the base currency they will sell. Must be called with pe.mu held.
+func (pe *PaperExchange) placeTrailingStop(req *OrderRequest, currentPrice decimal.Decimal) (*OrderResponse, error) {
+	if (req.TrailingPercent == nil) == (req.TrailingAmount == nil) {
+		return nil, fmt.Errorf("trailing-stop order requires exactly one of trailing percent or trailing amount")
+	}
+	if req.TrailingPercent != nil && (!req.TrailingPercent.IsPositive() || req.TrailingPercent.GreaterThanOrEqual(decimal.NewFromInt(100))) {
+		return nil, fmt.Errorf("trailing percent must be between 0 and 100")
+	}
+	if req.TrailingAmount != nil && !req.TrailingAmount.IsPositive() {
+		return nil, fmt.Errorf("trailing amount must be positive")
+	}
+
+	if req.Side == models.OrderSideSell {
+		baseCurrency := pe.getBaseCurrency(req.Symbol)
+		balance := pe.getOrCreateBalance(baseCurrency)
+		if balance.Available.LessThan(req.Quantity) {
+			return nil, fmt.Errorf("insufficient %s balance: need %s, have %s",
+				baseCurrency, req.Quantity.String(), balance.Available.String())
+		}
+
+		// Lock the quantity so it can't be sold twice
+		balance.Available = balance.Available.Sub(req.Quantity)
+		balance.Locked = balance.Locked.Add(req.Quantity)
+	}
+
+	orderID := uuid.New().String()
+	now := time.Now()
+
+	ts := &paperTrailingStop{
+		order: &OrderResponse{
+			ID:              orderID,
+			ClientOrderID:   clientOrderIDOf(req),
+			ExchangeOrderID: orderID,
+			Symbol:          req.Symbol,
+			Side:            req.Side,
+			Type:            req.Type,
+			Status:          models.OrderStatusOpen,
+			Quantity:        req.Quantity,
+			FilledQuantity:  decimal.Zero,
+			Fees:            decimal.Zero,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		},
+		percent:       req.TrailingPercent,
+		amount:        req.TrailingAmount,
+		highWaterMark: currentPrice,
+	}
+	ts.update(currentPrice)
+
+	stopPrice := ts.stopPrice
+	ts.order.Price = &stopPrice
+
+	pe.orders[orderID] = ts.order
+	pe.clientOrders[ts.order.ClientOrderID] = orderID
+	pe.trailingStops[orderID] = ts
+	pe.persist(ts.order)
+
+	pe.logger.WithFields(logrus.Fields{
+		"order_id":   orderID,
+		"symbol":     req.Symbol,
+		"side":       req.Side,
+		"quantity":   req.Quantity.String(),
+		"stop_price": stopPrice.String(),
+	}).Info("Paper trailing stop placed")
+
+	return copyOrder(ts.order), nil
+}
+
+// checkTrailingStops advances the trailing stops of a symbol and executes
+// those crossed by the price as market orders. Must be called with pe.mu held.
+func (pe *PaperExchange) checkTrailingStops(symbol string, price decimal.Decimal) {
+	var executed []*OrderResponse
+	defer func() {
+		if len(executed) > 0 {
+			pe.persist(executed...)
+		}
+	}()
+
+	for _, ts := range pe.trailingStops {
+		if ts.order.Symbol != symbol {
+			continue
+		}
+
+		ts.update(price)
+		stopPrice := ts.stopPrice
+		ts.order.Price = &stopPrice
+
+		if !ts.triggered(price) {
+			continue
+		}
+
+		pe.releaseTrailingStop(ts)
+
+		order := ts.order
+		executed = append(executed, order)
+		executionPrice := pe.fillModel.Price(symbol, order.Side, order.Quantity, pe.snapshotLocked(symbol))
+		fees, err := pe.settle(symbol, order.Side, order.Type, order.Quantity, executionPrice)
+		order.UpdatedAt = time.Now()
+		if err != nil {
+			order.Status = models.OrderStatusFailed
+			pe.logger.WithError(err).WithField("order_id", order.ID).Error("Paper trailing stop failed to execute")
+			continue
+		}
+
+		order.Status = models.OrderStatusFilled
+		order.FilledQuantity = order.Quantity
+		order.AverageFillPrice = &executionPrice
+		order.Fees = fees
+		pe.recordFill(order, executionPrice, order.Quantity, fees, LiquidityTaker)
+
+		pe.logger.WithFields(logrus.Fields{
+			"order_id":        order.ID,
+			"symbol":          symbol,
+			"side":            order.Side,
+			"quantity":        order.Quantity.String(),
+			"stop_price":      stopPrice.String(),
+			"execution_price": executionPrice.String(),
+			"fees":            fees.String(),
+		}).Info("Paper trailing stop executed")
+	}
+}
+
+// releaseTrailingStop removes a resting trailing stop and unlocks its funds.
+// Must be called with pe.mu held.
+func (pe *PaperExchange) releaseTrailingStop(ts *paperTrailingStop) {
+	delete(pe.trailingStops, ts.order.ID)
+
+	if ts.order.Side == models.OrderSideSell {
+		balance := pe.getOrCreateBalance(pe.getBaseCurrency(ts.order.Symbol))
+		balance.Locked = balance.Locked.Sub(ts.order.Quantity)
+		balance.Available = balance.Available.Add(ts.order.Quantity)
+	}
+}
@@ -0,0 +1,322 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/events"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// paperRequestTimeout bounds a request to a paper venue
+const paperRequestTimeout = 5 * time.Second
+
+// Paper venue operations, each served on its own subject
+const (
+	paperOpPlaceOrder         = "place_order"
+	paperOpCancelOrder        = "cancel_order"
+	paperOpGetOrder           = "get_order"
+	paperOpGetOrderByClientID = "get_order_by_client_id"
+	paperOpGetFills           = "get_fills"
+	paperOpGetBalance         = "get_balance"
+	paperOpGetPrice           = "get_price"
+	paperOpGetSymbolInfo      = "get_symbol_info"
+)
+
+// paperSubject returns the subject of an operation of a paper venue, e.g.
+// "paper.exchange.paper.place_order"
+func paperSubject(venue, op string) string {
+	return "paper.exchange." + venue + "." + op
+}
+
+// paperRequest is a request to a paper venue; only the fields of its
+// operation are set
+type paperRequest struct {
+	Order         *OrderRequest `json:"order,omitempty"`
+	OrderID       string        `json:"order_id,omitempty"`
+	ClientOrderID string        `json:"client_order_id,omitempty"`
+	Symbol        string        `json:"symbol,omitempty"`
+}
+
+// paperReply is a paper venue's response; Error is set if the operation failed
+type paperReply struct {
+	Error      string              `json:"error,omitempty"`
+	NotFound   bool                `json:"not_found,omitempty"` // Error is ErrOrderNotFound
+	Order      *OrderResponse      `json:"order,omitempty"`
+	Fills      []*Fill             `json:"fills,omitempty"`
+	Balances   map[string]*Balance `json:"balances,omitempty"`
+	Price      *decimal.Decimal    `json:"price,omitempty"`
+	SymbolInfo *SymbolInfo         `json:"symbol_info,omitempty"`
+}
+
+// paperVenueError is an operation error returned by a paper venue
+type paperVenueError struct {
+	message  string
+	notFound bool
+}
+
+func (e *paperVenueError) Error() string {
+	return e.message
+}
+
+// Is matches ErrOrderNotFound for orders the venue doesn't have
+func (e *paperVenueError) Is(target error) bool {
+	return e.notFound && target == ErrOrderNotFound
+}
+
+// PaperVenue serves a paper exchange to other services over NATS
+// request-reply, making it the single venue their paper orders execute on
+type PaperVenue struct {
+	exchange *PaperExchange
+	nats     *events.NATSClient
+	logger   *logrus.Entry
+}
+
+// NewPaperVenue creates a venue serving the given paper exchange
+func NewPaperVenue(pe *PaperExchange, nc *events.NATSClient, logger *logrus.Logger) *PaperVenue {
+	return &PaperVenue{
+		exchange: pe,
+		nats:     nc,
+		logger:   logger.WithField("component", "paper-venue"),
+	}
+}
+
+// Start starts answering requests for the venue's operations. Requests are
+// served concurrently, so an order waiting out its fill latency doesn't hold
+// up the venue's other calls.
+func (pv *PaperVenue) Start() error {
+	if _, err := pv.nats.Respond(paperSubject(pv.exchange.Name(), "*"), pv.handle); err != nil {
+		return fmt.Errorf("failed to serve paper venue %s: %w", pv.exchange.Name(), err)
+	}
+
+	pv.logger.WithField("venue", pv.exchange.Name()).Info("Paper venue started")
+	return nil
+}
+
+// handle executes a request on the paper exchange
+func (pv *PaperVenue) handle(subject string, data []byte) interface{} {
+	var req paperRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return &paperReply{Error: fmt.Sprintf("invalid request: %v", err)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), paperRequestTimeout)
+	defer cancel()
+
+	reply := &paperReply{}
+	var err error
+
+	switch subject {
+	case paperSubject(pv.exchange.Name(), paperOpPlaceOrder):
+		if req.Order == nil {
+			return &paperReply{Error: "missing order"}
+		}
+		reply.Order, err = pv.exchange.PlaceOrder(ctx, req.Order)
+	case paperSubject(pv.exchange.Name(), paperOpCancelOrder):
+		err = pv.exchange.CancelOrder(ctx, req.OrderID)
+	case paperSubject(pv.exchange.Name(), paperOpGetOrder):
+		reply.Order, err = pv.exchange.GetOrder(ctx, req.OrderID)
+	case paperSubject(pv.exchange.Name(), paperOpGetOrderByClientID):
+		reply.Order, err = pv.exchange.GetOrderByClientID(ctx, req.ClientOrderID)
+	case paperSubject(pv.exchange.Name(), paperOpGetFills):
+		reply.Fills, err = pv.exchange.GetFills(ctx, req.OrderID)
+	case paperSubject(pv.exchange.Name(), paperOpGetBalance):
+		reply.Balances, err = pv.exchange.GetBalance(ctx)
+	case paperSubject(pv.exchange.Name(), paperOpGetPrice):
+		var price decimal.Decimal
+		price, err = pv.exchange.GetPrice(ctx, req.Symbol)
+		reply.Price = &price
+	case paperSubject(pv.exchange.Name(), paperOpGetSymbolInfo):
+		reply.SymbolInfo, err = pv.exchange.GetSymbolInfo(ctx, req.Symbol)
+	default:
+		err = fmt.Errorf("unknown paper venue operation: %s", subject)
+	}
+
+	if err != nil {
+		pv.logger.WithError(err).WithField("subject", subject).Debug("Paper venue request failed")
+		return &paperReply{Error: err.Error(), NotFound: errors.Is(err, ErrOrderNotFound)}
+	}
+	return reply
+}
+
+// PaperClient is the Exchange of a paper venue served by another process.
+// Orders, balances and prices all come from the venue.
+type PaperClient struct {
+	venue  string
+	nats   *events.NATSClient
+	logger *logrus.Entry
+
+	mu   sync.Mutex
+	subs []events.Subscription
+}
+
+// NewPaperClient creates a client of the named paper venue
+func NewPaperClient(venue string, nc *events.NATSClient, logger *logrus.Logger) *PaperClient {
+	return &PaperClient{
+		venue:  venue,
+		nats:   nc,
+		logger: logger.WithField("component", "paper-client"),
+	}
+}
+
+// Name returns the venue name
+func (pc *PaperClient) Name() string {
+	return pc.venue
+}
+
+// PlaceOrder places an order on the venue
+func (pc *PaperClient) PlaceOrder(ctx context.Context, order *OrderRequest) (*OrderResponse, error) {
+	reply, err := pc.request(ctx, paperOpPlaceOrder, &paperRequest{Order: order})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Order, nil
+}
+
+// CancelOrder cancels an order on the venue
+func (pc *PaperClient) CancelOrder(ctx context.Context, orderID string) error {
+	_, err := pc.request(ctx, paperOpCancelOrder, &paperRequest{OrderID: orderID})
+	return err
+}
+
+// GetOrder gets an order by ID
+func (pc *PaperClient) GetOrder(ctx context.Context, orderID string) (*OrderResponse, error) {
+	reply, err := pc.request(ctx, paperOpGetOrder, &paperRequest{OrderID: orderID})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Order, nil
+}
+
+// GetOrderByClientID gets an order by its client order ID
+func (pc *PaperClient) GetOrderByClientID(ctx context.Context, clientOrderID string) (*OrderResponse, error) {
+	reply, err := pc.request(ctx, paperOpGetOrderByClientID, &paperRequest{ClientOrderID: clientOrderID})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Order, nil
+}
+
+// GetFills gets the executions of an order
+func (pc *PaperClient) GetFills(ctx context.Context, orderID string) ([]*Fill, error) {
+	reply, err := pc.request(ctx, paperOpGetFills, &paperRequest{OrderID: orderID})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Fills, nil
+}
+
+// GetBalance gets the venue's balances
+func (pc *PaperClient) GetBalance(ctx context.Context) (map[string]*Balance, error) {
+	reply, err := pc.request(ctx, paperOpGetBalance, &paperRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if reply.Balances == nil {
+		return map[string]*Balance{}, nil
+	}
+	return reply.Balances, nil
+}
+
+// GetPrice gets the venue's current price for a symbol
+func (pc *PaperClient) GetPrice(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	reply, err := pc.request(ctx, paperOpGetPrice, &paperRequest{Symbol: symbol})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if reply.Price == nil {
+		return decimal.Zero, fmt.Errorf("no price available for symbol %s", symbol)
+	}
+	return *reply.Price, nil
+}
+
+// GetSymbolInfo gets the order size rules of a symbol
+func (pc *PaperClient) GetSymbolInfo(ctx context.Context, symbol string) (*SymbolInfo, error) {
+	reply, err := pc.request(ctx, paperOpGetSymbolInfo, &paperRequest{Symbol: symbol})
+	if err != nil {
+		return nil, err
+	}
+	return reply.SymbolInfo, nil
+}
+
+// SubscribePriceUpdates subscribes to the price updates published on the bus
+func (pc *PaperClient) SubscribePriceUpdates(ctx context.Context, symbols []string, callback func(*PriceUpdate)) error {
+	wanted := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		wanted[symbol] = true
+	}
+
+	sub, err := pc.nats.Subscribe(string(events.EventTypePriceUpdate), func(event *events.Event) error {
+		var update events.PriceUpdateEvent
+		if err := json.Unmarshal(event.Data, &update); err != nil {
+			return fmt.Errorf("failed to unmarshal price update: %w", err)
+		}
+		if !wanted[update.Symbol] {
+			return nil
+		}
+
+		callback(&PriceUpdate{
+			Exchange:  pc.venue,
+			Symbol:    update.Symbol,
+			Price:     decimal.NewFromFloat(update.Price),
+			Bid:       decimal.NewFromFloat(update.Bid),
+			Ask:       decimal.NewFromFloat(update.Ask),
+			Volume:    decimal.NewFromFloat(update.Volume),
+			Timestamp: update.Time,
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	pc.mu.Lock()
+	pc.subs = append(pc.subs, sub)
+	pc.mu.Unlock()
+
+	return nil
+}
+
+// Close unsubscribes from price updates
+func (pc *PaperClient) Close() error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	for _, sub := range pc.subs {
+		if err := sub.Unsubscribe(); err != nil {
+			pc.logger.WithError(err).Warn("Failed to unsubscribe from price updates")
+		}
+	}
+	pc.subs = nil
+
+	return nil
+}
+
+// request sends an operation to the venue and returns its reply, or the
+// error the operation failed with
+func (pc *PaperClient) request(ctx context.Context, op string, req *paperRequest) (*paperReply, error) {
+	timeout := paperRequestTimeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+
+	event, err := pc.nats.Request(paperSubject(pc.venue, op), req, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("paper venue %s unavailable: %w", pc.venue, err)
+	}
+
+	var reply paperReply
+	if err := json.Unmarshal(event.Data, &reply); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal paper venue reply: %w", err)
+	}
+
+	if reply.Error != "" {
+		return nil, &paperVenueError{message: reply.Error, notFound: reply.NotFound}
+	}
+	return &reply, nil
+}
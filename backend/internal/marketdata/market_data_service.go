@@ -17,7 +17,7 @@ import (
 type MarketDataService struct {
 	db             *sql.DB
 	exchange       exchange.Exchange
-	nats           *events.NATSClient
+	bus            events.Bus
 	logger         *logrus.Entry
 	symbols        []string
 	priceCache     map[string]*PriceCacheEntry
@@ -47,14 +47,14 @@ type CandleBuffer struct {
 func NewMarketDataService(
 	db *sql.DB,
 	exch exchange.Exchange,
-	natsClient *events.NATSClient,
+	bus events.Bus,
 	symbols []string,
 	logger *logrus.Logger,
 ) *MarketDataService {
 	return &MarketDataService{
 		db:           db,
 		exchange:     exch,
-		nats:         natsClient,
+		bus:          bus,
 		logger:       logger.WithField("component", "market-data"),
 		symbols:      symbols,
 		priceCache:   make(map[string]*PriceCacheEntry),
@@ -102,11 +102,13 @@ func (mds *MarketDataService) handlePriceUpdate(ctx context.Context, update *exc
 		Exchange: update.Exchange,
 		Symbol:   update.Symbol,
 		Price:    update.Price.InexactFloat64(),
+		Bid:      update.Bid.InexactFloat64(),
+		Ask:      update.Ask.InexactFloat64(),
 		Volume:   update.Volume.InexactFloat64(),
 		Time:     update.Timestamp,
 	}
 
-	if err := mds.nats.Publish(events.EventTypePriceUpdate, priceEvent); err != nil {
+	if err := mds.bus.Publish(events.EventTypePriceUpdate, priceEvent); err != nil {
 		mds.logger.WithError(err).Error("Failed to publish price update event")
 	}
 
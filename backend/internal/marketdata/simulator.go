@@ -0,0 +1,57 @@
+package marketdata
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/exchange"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// simulatedBasePrices are the starting prices for simulated symbols
+var simulatedBasePrices = map[string]float64{
+	"BTC-USD": 45000.0,
+	"ETH-USD": 2500.0,
+	"SOL-USD": 100.0,
+}
+
+// defaultSimulatedBasePrice is used for symbols without a known base price
+const defaultSimulatedBasePrice = 100.0
+
+// SimulatePriceUpdates simulates price updates for paper trading
+func SimulatePriceUpdates(ctx context.Context, paperExch *exchange.PaperExchange, symbols []string, lgr *logrus.Logger) {
+	currentPrices := make(map[string]decimal.Decimal, len(symbols))
+	for _, symbol := range symbols {
+		basePrice, exists := simulatedBasePrices[symbol]
+		if !exists {
+			basePrice = defaultSimulatedBasePrice
+		}
+		currentPrices[symbol] = decimal.NewFromFloat(basePrice)
+	}
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	lgr.WithField("symbols", symbols).Info("Simulating price updates")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, symbol := range symbols {
+				// Simulate price movement (random walk of +/- 0.055%,
+				// i.e. +/- $25 for BTC at $45,000)
+				price := currentPrices[symbol]
+				change := (rand.Float64() - 0.5) * 0.0011 * price.InexactFloat64()
+				price = price.Add(decimal.NewFromFloat(change))
+				currentPrices[symbol] = price
+
+				// Update paper exchange
+				paperExch.UpdatePrice(symbol, price)
+			}
+		}
+	}
+}
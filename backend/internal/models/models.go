@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,8 +20,9 @@ const (
 type OrderType string
 
 const (
-	OrderTypeMarket OrderType = "MARKET"
-	OrderTypeLimit  OrderType = "LIMIT"
+	OrderTypeMarket       OrderType = "MARKET"
+	OrderTypeLimit        OrderType = "LIMIT"
+	OrderTypeTrailingStop OrderType = "TRAILING_STOP"
 )
 
 // OrderStatus represents the status of an order
@@ -42,6 +44,89 @@ const (
 	TradeSideShort TradeSide = "SHORT"
 )
 
+// PositionIntent represents whether an order opens or closes a long or short position
+type PositionIntent string
+
+const (
+	PositionIntentOpenLong   PositionIntent = "OPEN_LONG"
+	PositionIntentCloseLong  PositionIntent = "CLOSE_LONG"
+	PositionIntentOpenShort  PositionIntent = "OPEN_SHORT"
+	PositionIntentCloseShort PositionIntent = "CLOSE_SHORT"
+)
+
+// IntentForSide returns the intent of an order that doesn't state one.
+// Before short selling, every BUY opened a long and every SELL closed it.
+func IntentForSide(side OrderSide) PositionIntent {
+	if side == OrderSideSell {
+		return PositionIntentCloseLong
+	}
+	return PositionIntentOpenLong
+}
+
+// ParsePositionIntent parses the intent of an order on the given side,
+// defaulting to IntentForSide when it is empty
+func ParsePositionIntent(intent string, side OrderSide) (PositionIntent, error) {
+	if intent == "" {
+		return IntentForSide(side), nil
+	}
+
+	parsed := PositionIntent(intent)
+	if !parsed.IsValid() {
+		return "", fmt.Errorf("invalid position intent: %s", intent)
+	}
+	if parsed.OrderSide() != side {
+		return "", fmt.Errorf("position intent %s does not match order side %s", intent, side)
+	}
+
+	return parsed, nil
+}
+
+// IsValid returns true if the intent is one of the known intents
+func (i PositionIntent) IsValid() bool {
+	switch i {
+	case PositionIntentOpenLong, PositionIntentCloseLong, PositionIntentOpenShort, PositionIntentCloseShort:
+		return true
+	}
+	return false
+}
+
+// IsOpening returns true if the intent opens a position
+func (i PositionIntent) IsOpening() bool {
+	return i == PositionIntentOpenLong || i == PositionIntentOpenShort
+}
+
+// TradeSide returns the side of the position the intent opens or closes
+func (i PositionIntent) TradeSide() TradeSide {
+	if i == PositionIntentOpenShort || i == PositionIntentCloseShort {
+		return TradeSideShort
+	}
+	return TradeSideLong
+}
+
+// OrderSide returns the order side that carries out the intent
+func (i PositionIntent) OrderSide() OrderSide {
+	if i == PositionIntentOpenLong || i == PositionIntentCloseShort {
+		return OrderSideBuy
+	}
+	return OrderSideSell
+}
+
+// OpenIntent returns the intent that opens a position of the given side
+func OpenIntent(side TradeSide) PositionIntent {
+	if side == TradeSideShort {
+		return PositionIntentOpenShort
+	}
+	return PositionIntentOpenLong
+}
+
+// CloseIntent returns the intent that closes a position of the given side
+func CloseIntent(side TradeSide) PositionIntent {
+	if side == TradeSideShort {
+		return PositionIntentCloseShort
+	}
+	return PositionIntentCloseLong
+}
+
 // ExitReason represents why a trade was exited
 type ExitReason string
 
@@ -54,6 +139,19 @@ const (
 	ExitReasonKillSwitch ExitReason = "KILL_SWITCH"
 )
 
+// ParseExitReason parses the exit reason of a closing order, defaulting to
+// ExitReasonSignal when it is empty
+func ParseExitReason(reason string) (ExitReason, error) {
+	switch parsed := ExitReason(reason); parsed {
+	case "":
+		return ExitReasonSignal, nil
+	case ExitReasonStopLoss, ExitReasonTakeProfit, ExitReasonTimeout,
+		ExitReasonManual, ExitReasonSignal, ExitReasonKillSwitch:
+		return parsed, nil
+	}
+	return "", fmt.Errorf("invalid exit reason: %s", reason)
+}
+
 // Order represents a trading order
 type Order struct {
 	ID               uuid.UUID
@@ -63,6 +161,7 @@ type Order struct {
 	StrategyID       uuid.UUID
 	Symbol           string
 	Side             OrderSide
+	Intent           PositionIntent
 	Type             OrderType
 	Quantity         decimal.Decimal
 	Price            decimal.NullDecimal
@@ -86,6 +185,7 @@ type Trade struct {
 	EntryPrice   decimal.Decimal
 	ExitPrice    decimal.NullDecimal
 	Quantity     decimal.Decimal
+	EntryFees    decimal.Decimal // Share of the entry order's fees, reduced by partial closes
 	Side         TradeSide
 	EntryTime    time.Time
 	ExitTime     *time.Time
@@ -125,6 +225,7 @@ type TradeSignal struct {
 	StrategyID    uuid.UUID
 	Symbol        string
 	Side          OrderSide
+	Intent        PositionIntent
 	Type          OrderType
 	Quantity      decimal.Decimal
 	Price         decimal.NullDecimal
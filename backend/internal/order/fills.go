@@ -0,0 +1,79 @@
+package order
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// fillTotals are the order aggregates derived from its recorded fills
+type fillTotals struct {
+	quantity     decimal.Decimal
+	averagePrice *decimal.Decimal
+	fees         decimal.Decimal
+	feeCurrency  string
+}
+
+// recordFills stores the order's executions reported by the exchange, once
+// per exchange trade ID, and returns the totals of all its recorded fills.
+// It returns nil if the order has none.
+func (om *OrderManager) recordFills(ctx context.Context, orderID uuid.UUID, exchangeOrderID string) (*fillTotals, error) {
+	fills, err := om.exchange.GetFills(ctx, exchangeOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fill := range fills {
+		_, err := om.db.ExecContext(ctx, `
+			INSERT INTO fills (
+				order_id, exchange_id, exchange_trade_id, symbol, side, price, quantity,
+				fee, fee_currency, liquidity, executed_at
+			) VALUES ($1, (SELECT exchange_id FROM orders WHERE id = $1), $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (exchange_id, symbol, exchange_trade_id) DO NOTHING
+		`, orderID, fill.TradeID, fill.Symbol, fill.Side, fill.Price, fill.Quantity,
+			fill.Fee, fill.FeeCurrency, fill.Liquidity, fill.Time)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert fill: %w", err)
+		}
+	}
+
+	var totals fillTotals
+	var averagePrice decimal.NullDecimal
+	var feeCurrency *string
+	var feeCurrencies int
+	err = om.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(quantity), 0),
+		       SUM(price * quantity) / NULLIF(SUM(quantity), 0),
+		       COALESCE(SUM(fee), 0),
+		       MIN(fee_currency),
+		       COUNT(DISTINCT fee_currency)
+		FROM fills
+		WHERE order_id = $1
+	`, orderID).Scan(&totals.quantity, &averagePrice, &totals.fees, &feeCurrency, &feeCurrencies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fill totals: %w", err)
+	}
+
+	if !totals.quantity.IsPositive() {
+		return nil, nil
+	}
+
+	if feeCurrencies > 1 {
+		om.logger.WithFields(logrus.Fields{
+			"order_id":       orderID,
+			"fee_currencies": feeCurrencies,
+		}).Warn("Order fees were charged in several currencies, total mixes them")
+	}
+
+	if averagePrice.Valid {
+		totals.averagePrice = &averagePrice.Decimal
+	}
+	if feeCurrency != nil {
+		totals.feeCurrency = *feeCurrency
+	}
+
+	return &totals, nil
+}
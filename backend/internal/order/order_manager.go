@@ -2,40 +2,57 @@ package order
 
 import (
 	"context"
-	"crypto/sha256"
 	"database/sql"
-	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/crypto-trading-bot/internal/events"
 	"github.com/crypto-trading-bot/internal/exchange"
 	"github.com/crypto-trading-bot/internal/models"
+	"github.com/crypto-trading-bot/internal/position"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// pendingOrderRecoveryAge is how long an order may stay PENDING before
+	// recovery looks it up on the exchange
+	pendingOrderRecoveryAge = 30 * time.Second
+
+	// pendingOrderMaxRetryAge is the age after which a PENDING order the
+	// exchange never received is failed rather than resent
+	pendingOrderMaxRetryAge = 5 * time.Minute
+)
+
+// clientOrderIDNamespace namespaces the UUIDs derived from signals
+var clientOrderIDNamespace = uuid.MustParse("6f1c2a52-3f5e-4d4b-9a0e-8c7d2b1e4f60")
+
 // OrderManager handles order placement and tracking
 type OrderManager struct {
-	db       *sql.DB
-	exchange exchange.Exchange
-	nats     *events.NATSClient
-	logger   *logrus.Entry
+	db        *sql.DB
+	exchange  exchange.Exchange
+	bus       events.Bus
+	ledger    *position.Ledger
+	logger    *logrus.Entry
+	executing sync.Map // Order IDs being sent to the exchange by this process
 }
 
 // NewOrderManager creates a new order manager
 func NewOrderManager(
 	db *sql.DB,
 	exch exchange.Exchange,
-	natsClient *events.NATSClient,
+	bus events.Bus,
+	ledger *position.Ledger,
 	logger *logrus.Logger,
 ) *OrderManager {
 	return &OrderManager{
 		db:       db,
 		exchange: exch,
-		nats:     natsClient,
+		bus:      bus,
+		ledger:   ledger,
 		logger:   logger.WithField("component", "order-manager"),
 	}
 }
@@ -70,6 +87,30 @@ func (om *OrderManager) PlaceOrder(ctx context.Context, signal *events.TradeSign
 		return fmt.Errorf("invalid strategy ID: %w", err)
 	}
 
+	intent, err := models.ParsePositionIntent(signal.Intent, models.OrderSide(signal.Side))
+	if err != nil {
+		return err
+	}
+
+	// Closing orders record why they were placed and the trade they target
+	var exitReason *models.ExitReason
+	var closeTradeID *uuid.UUID
+	if !intent.IsOpening() {
+		reason, err := models.ParseExitReason(signal.ExitReason)
+		if err != nil {
+			return err
+		}
+		exitReason = &reason
+
+		if signal.TradeID != "" {
+			tradeID, err := uuid.Parse(signal.TradeID)
+			if err != nil {
+				return fmt.Errorf("invalid trade ID: %w", err)
+			}
+			closeTradeID = &tradeID
+		}
+	}
+
 	// Start transaction
 	tx, err := om.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -103,16 +144,24 @@ func (om *OrderManager) PlaceOrder(ctx context.Context, signal *events.TradeSign
 
 	_, err = tx.ExecContext(ctx, `
 		INSERT INTO orders (
-			id, client_order_id, exchange_id, strategy_id, symbol, side, type,
-			quantity, price, stop_loss_price, status
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, 'PENDING')
+			id, client_order_id, exchange_id, strategy_id, symbol, side, intent, type,
+			quantity, price, stop_loss_price, exit_reason, close_trade_id, status
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, 'PENDING')
 	`, orderID, clientOrderID, exchangeID, strategyID, signal.Symbol,
-		signal.Side, signal.Type, quantity, price, stopLossPrice)
+		signal.Side, intent, signal.Type, quantity, price, stopLossPrice, exitReason, closeTradeID)
 
 	if err != nil {
 		return fmt.Errorf("failed to insert order: %w", err)
 	}
 
+	if err := insertOrderEvent(ctx, tx, orderID, nil, Transition{
+		To:     models.OrderStatusPending,
+		Source: SourceOrderManager,
+		Reason: signal.Reason,
+	}); err != nil {
+		return err
+	}
+
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
@@ -123,6 +172,7 @@ func (om *OrderManager) PlaceOrder(ctx context.Context, signal *events.TradeSign
 		"client_order_id": clientOrderID,
 		"symbol":          signal.Symbol,
 		"side":            signal.Side,
+		"intent":          intent,
 		"quantity":        quantity.String(),
 	}).Info("Order created with PENDING status")
 
@@ -134,11 +184,18 @@ func (om *OrderManager) PlaceOrder(ctx context.Context, signal *events.TradeSign
 
 // executeOrder executes the order on the exchange (async)
 func (om *OrderManager) executeOrder(ctx context.Context, orderID uuid.UUID, signal *events.TradeSignalEvent) {
+	if _, running := om.executing.LoadOrStore(orderID, struct{}{}); running {
+		return
+	}
+	defer om.executing.Delete(orderID)
+
 	// Get order details from database
 	var order struct {
 		ClientOrderID string
+		Status        models.OrderStatus
 		Symbol        string
 		Side          string
+		Intent        string
 		Type          string
 		Quantity      decimal.Decimal
 		Price         sql.NullString
@@ -146,12 +203,14 @@ func (om *OrderManager) executeOrder(ctx context.Context, orderID uuid.UUID, sig
 	}
 
 	err := om.db.QueryRowContext(ctx, `
-		SELECT client_order_id, symbol, side, type, quantity, price, stop_loss_price
+		SELECT client_order_id, status, symbol, side, intent, type, quantity, price, stop_loss_price
 		FROM orders WHERE id = $1
 	`, orderID).Scan(
 		&order.ClientOrderID,
+		&order.Status,
 		&order.Symbol,
 		&order.Side,
+		&order.Intent,
 		&order.Type,
 		&order.Quantity,
 		&order.Price,
@@ -160,16 +219,31 @@ func (om *OrderManager) executeOrder(ctx context.Context, orderID uuid.UUID, sig
 
 	if err != nil {
 		om.logger.WithError(err).Error("Failed to get order details")
-		om.updateOrderStatus(ctx, orderID, models.OrderStatusFailed, "", decimal.Zero, nil, nil)
+		om.updateOrderStatus(ctx, orderID, Transition{
+			To:     models.OrderStatusFailed,
+			Source: SourceOrderManager,
+			Reason: err.Error(),
+		})
+		return
+	}
+
+	// Cancelled, e.g. by the kill switch, before it was sent
+	if order.Status != models.OrderStatusPending {
+		om.logger.WithFields(logrus.Fields{
+			"order_id": orderID,
+			"status":   order.Status,
+		}).Info("Order no longer pending, not sending it")
 		return
 	}
 
 	// Build exchange order request
 	req := &exchange.OrderRequest{
-		Symbol:   order.Symbol,
-		Side:     models.OrderSide(order.Side),
-		Type:     models.OrderType(order.Type),
-		Quantity: order.Quantity,
+		ClientOrderID: order.ClientOrderID,
+		Symbol:        order.Symbol,
+		Side:          models.OrderSide(order.Side),
+		Intent:        models.PositionIntent(order.Intent),
+		Type:          models.OrderType(order.Type),
+		Quantity:      order.Quantity,
 	}
 
 	if order.Price.Valid {
@@ -185,25 +259,47 @@ func (om *OrderManager) executeOrder(ctx context.Context, orderID uuid.UUID, sig
 	// Place order on exchange
 	resp, err := om.exchange.PlaceOrder(ctx, req)
 	if err != nil {
-		om.logger.WithError(err).WithField("order_id", orderID).Error("Failed to place order on exchange")
-		om.updateOrderStatus(ctx, orderID, models.OrderStatusFailed, "", decimal.Zero, nil, nil)
+		// The order may have reached the exchange even if the response didn't
+		existing, lookupErr := om.exchange.GetOrderByClientID(ctx, order.ClientOrderID)
+		switch {
+		case lookupErr == nil:
+			resp = existing
+		case errors.Is(lookupErr, exchange.ErrOrderNotFound):
+			om.logger.WithError(err).WithField("order_id", orderID).Error("Failed to place order on exchange")
+			om.updateOrderStatus(ctx, orderID, Transition{
+				To:     models.OrderStatusFailed,
+				Source: SourceExchange,
+				Reason: err.Error(),
+			})
+
+			// Publish failed event
+			om.publishOrderEvent(events.EventTypeOrderFailed, orderID, order.ClientOrderID, "", signal)
+			return
+		default:
+			om.logger.WithError(err).WithFields(logrus.Fields{
+				"order_id":     orderID,
+				"lookup_error": lookupErr.Error(),
+			}).Warn("Order outcome unknown, leaving it PENDING for recovery")
+			return
+		}
+	}
+
+	om.applyExchangeOrder(ctx, orderID, order.ClientOrderID, resp, signal, SourceExchange)
+}
 
-		// Publish failed event
-		om.publishOrderEvent(events.EventTypeOrderFailed, orderID, order.ClientOrderID, "", signal)
+// applyExchangeOrder records the exchange's view of an order
+func (om *OrderManager) applyExchangeOrder(
+	ctx context.Context,
+	orderID uuid.UUID,
+	clientOrderID string,
+	resp *exchange.OrderResponse,
+	signal *events.TradeSignalEvent,
+	source string,
+) {
+	if err := om.recordExchangeOrder(ctx, orderID, resp, source); err != nil {
 		return
 	}
 
-	// Update order with exchange order ID and status
-	om.updateOrderStatus(
-		ctx,
-		orderID,
-		resp.Status,
-		resp.ExchangeOrderID,
-		resp.FilledQuantity,
-		resp.AverageFillPrice,
-		&resp.Fees,
-	)
-
 	om.logger.WithFields(logrus.Fields{
 		"order_id":          orderID,
 		"exchange_order_id": resp.ExchangeOrderID,
@@ -212,7 +308,7 @@ func (om *OrderManager) executeOrder(ctx context.Context, orderID uuid.UUID, sig
 	}).Info("Order placed on exchange")
 
 	// Publish order placed event
-	om.publishOrderEvent(events.EventTypeOrderPlaced, orderID, order.ClientOrderID, resp.ExchangeOrderID, signal)
+	om.publishOrderEvent(events.EventTypeOrderPlaced, orderID, clientOrderID, resp.ExchangeOrderID, signal)
 
 	// If order is filled immediately (market order), handle it
 	if resp.Status == models.OrderStatusFilled {
@@ -220,31 +316,238 @@ func (om *OrderManager) executeOrder(ctx context.Context, orderID uuid.UUID, sig
 	}
 }
 
-// updateOrderStatus updates the order status in the database
-func (om *OrderManager) updateOrderStatus(
-	ctx context.Context,
-	orderID uuid.UUID,
-	status models.OrderStatus,
-	exchangeOrderID string,
-	filledQuantity decimal.Decimal,
-	avgFillPrice *decimal.Decimal,
-	fees *decimal.Decimal,
-) error {
-	query := `
-		UPDATE orders
-		SET status = $2,
-		    exchange_order_id = COALESCE(NULLIF($3, ''), exchange_order_id),
-		    filled_quantity = COALESCE($4, filled_quantity),
-		    average_fill_price = COALESCE($5, average_fill_price),
-		    fees = COALESCE($6, fees),
-		    filled_at = CASE WHEN $2 = 'FILLED' THEN NOW() ELSE filled_at END,
-		    updated_at = NOW()
-		WHERE id = $1
-	`
-
-	_, err := om.db.ExecContext(ctx, query, orderID, status, exchangeOrderID, filledQuantity, avgFillPrice, fees)
+// recordExchangeOrder moves an order to the status the exchange reports,
+// with the fill totals derived from its executions
+func (om *OrderManager) recordExchangeOrder(ctx context.Context, orderID uuid.UUID, resp *exchange.OrderResponse, source string) error {
+	t := Transition{
+		To:               resp.Status,
+		Source:           source,
+		ExchangeOrderID:  resp.ExchangeOrderID,
+		FilledQuantity:   &resp.FilledQuantity,
+		AverageFillPrice: resp.AverageFillPrice,
+		Fees:             &resp.Fees,
+	}
+
+	// Derive the fill aggregates from the individual executions
+	if resp.ExchangeOrderID != "" && resp.FilledQuantity.IsPositive() {
+		totals, err := om.recordFills(ctx, orderID, resp.ExchangeOrderID)
+		if err != nil {
+			om.logger.WithError(err).WithField("order_id", orderID).Warn("Failed to record fills, using the order's totals")
+		} else if totals != nil {
+			t.FilledQuantity = &totals.quantity
+			t.AverageFillPrice = totals.averagePrice
+			t.Fees = &totals.fees
+			t.FeeCurrency = totals.feeCurrency
+		}
+	}
+
+	// Update order with exchange order ID and status
+	return om.updateOrderStatus(ctx, orderID, t)
+}
+
+// ReconcileOpenOrders looks up the orders resting on the exchange, such as
+// limit orders and trailing stops, and records the fills and cancellations
+// that happened there since
+func (om *OrderManager) ReconcileOpenOrders(ctx context.Context) error {
+	rows, err := om.db.QueryContext(ctx, `
+		SELECT id, exchange_order_id, filled_quantity
+		FROM orders
+		WHERE status = 'OPEN' AND exchange_order_id IS NOT NULL
+		ORDER BY created_at
+	`)
 	if err != nil {
-		om.logger.WithError(err).Error("Failed to update order status")
+		return fmt.Errorf("failed to get open orders: %w", err)
+	}
+
+	type openOrder struct {
+		id              uuid.UUID
+		exchangeOrderID string
+		filledQuantity  decimal.Decimal
+	}
+
+	var open []openOrder
+	for rows.Next() {
+		var order openOrder
+		if err := rows.Scan(&order.id, &order.exchangeOrderID, &order.filledQuantity); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan open order: %w", err)
+		}
+		open = append(open, order)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, order := range open {
+		logger := om.logger.WithFields(logrus.Fields{
+			"order_id":          order.id,
+			"exchange_order_id": order.exchangeOrderID,
+		})
+
+		resp, err := om.exchange.GetOrder(ctx, order.exchangeOrderID)
+		if err != nil {
+			logger.WithError(err).Error("Failed to look up open order")
+			continue
+		}
+
+		if resp.Status == models.OrderStatusOpen && resp.FilledQuantity.Equal(order.filledQuantity) {
+			continue
+		}
+
+		if err := om.recordExchangeOrder(ctx, order.id, resp, SourceReconciler); err != nil {
+			continue
+		}
+
+		logger.WithFields(logrus.Fields{
+			"status":          resp.Status,
+			"filled_quantity": resp.FilledQuantity.String(),
+		}).Info("Open order updated from exchange")
+
+		if resp.Status == models.OrderStatusFilled {
+			om.handleFilledOrder(ctx, order.id, resp)
+		}
+	}
+
+	return nil
+}
+
+// RecoverPendingOrders resolves orders left PENDING, e.g. by a restart
+// between creating an order and hearing back from the exchange. Orders the
+// exchange has are recorded; recent ones it never received are resent with
+// the same client order ID and older ones are failed.
+func (om *OrderManager) RecoverPendingOrders(ctx context.Context) error {
+	rows, err := om.db.QueryContext(ctx, `
+		SELECT o.id, o.client_order_id, o.strategy_id, o.symbol, o.side, o.intent, o.type,
+		       o.quantity, o.price, o.stop_loss_price, o.exit_reason, o.close_trade_id, o.created_at,
+		       (SELECT e.reason FROM order_events e
+		        WHERE e.order_id = o.id AND e.from_status IS NULL
+		        LIMIT 1)
+		FROM orders o
+		WHERE o.status = 'PENDING' AND o.created_at < $1
+		ORDER BY o.created_at
+	`, time.Now().Add(-pendingOrderRecoveryAge))
+	if err != nil {
+		return fmt.Errorf("failed to get pending orders: %w", err)
+	}
+
+	type pendingOrder struct {
+		id            uuid.UUID
+		clientOrderID string
+		createdAt     time.Time
+		signal        *events.TradeSignalEvent
+	}
+
+	var pending []pendingOrder
+	for rows.Next() {
+		var order pendingOrder
+		var strategyID uuid.UUID
+		var quantity decimal.Decimal
+		var price, stopLossPrice decimal.NullDecimal
+		var exitReason, reason sql.NullString
+		var closeTradeID uuid.NullUUID
+		signal := &events.TradeSignalEvent{}
+
+		if err := rows.Scan(
+			&order.id,
+			&order.clientOrderID,
+			&strategyID,
+			&signal.Symbol,
+			&signal.Side,
+			&signal.Intent,
+			&signal.Type,
+			&quantity,
+			&price,
+			&stopLossPrice,
+			&exitReason,
+			&closeTradeID,
+			&order.createdAt,
+			&reason,
+		); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan pending order: %w", err)
+		}
+
+		signal.StrategyID = strategyID.String()
+		signal.Quantity = quantity.InexactFloat64()
+		if price.Valid {
+			p := price.Decimal.InexactFloat64()
+			signal.Price = &p
+		}
+		if stopLossPrice.Valid {
+			signal.StopLossPrice = stopLossPrice.Decimal.InexactFloat64()
+		}
+		signal.Reason = reason.String
+		signal.ExitReason = exitReason.String
+		if closeTradeID.Valid {
+			signal.TradeID = closeTradeID.UUID.String()
+		}
+		order.signal = signal
+
+		pending = append(pending, order)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, order := range pending {
+		if _, running := om.executing.Load(order.id); running {
+			continue
+		}
+
+		logger := om.logger.WithFields(logrus.Fields{
+			"order_id":        order.id,
+			"client_order_id": order.clientOrderID,
+		})
+
+		resp, err := om.exchange.GetOrderByClientID(ctx, order.clientOrderID)
+		switch {
+		case err == nil:
+			logger.Info("Recovered pending order from exchange")
+			om.applyExchangeOrder(ctx, order.id, order.clientOrderID, resp, order.signal, SourceReconciler)
+
+		case !errors.Is(err, exchange.ErrOrderNotFound):
+			logger.WithError(err).Error("Failed to look up pending order")
+
+		case time.Since(order.createdAt) < pendingOrderMaxRetryAge:
+			// Resent in the background so the caller doesn't wait on the
+			// exchange; executing keeps it from being sent twice
+			logger.Info("Resending pending order the exchange never received")
+			go om.executeOrder(context.Background(), order.id, order.signal)
+
+		default:
+			logger.Warn("Pending order never reached the exchange, failing it")
+			om.updateOrderStatus(ctx, order.id, Transition{
+				To:     models.OrderStatusFailed,
+				Source: SourceReconciler,
+				Reason: "order never reached the exchange",
+			})
+			om.publishOrderEvent(events.EventTypeOrderFailed, order.id, order.clientOrderID, "", order.signal)
+		}
+	}
+
+	return nil
+}
+
+// updateOrderStatus moves the order to a new status through the state
+// machine, logging rejected transitions
+func (om *OrderManager) updateOrderStatus(ctx context.Context, orderID uuid.UUID, t Transition) error {
+	from, err := TransitionOrder(ctx, om.db, orderID, t)
+	if err != nil {
+		entry := om.logger.WithError(err).WithFields(logrus.Fields{
+			"order_id": orderID,
+			"from":     from,
+			"to":       t.To,
+			"source":   t.Source,
+		})
+		if errors.Is(err, ErrInvalidTransition) {
+			entry.Warn("Rejected order status transition")
+		} else {
+			entry.Error("Failed to update order status")
+		}
 		return err
 	}
 
@@ -258,21 +561,31 @@ func (om *OrderManager) handleFilledOrder(ctx context.Context, orderID uuid.UUID
 		StrategyID       uuid.UUID
 		Symbol           string
 		Side             string
+		Intent           models.PositionIntent
 		Quantity         decimal.Decimal
 		AverageFillPrice decimal.Decimal
 		Fees             decimal.Decimal
+		FeeCurrency      sql.NullString
+		ExitReason       sql.NullString
+		CloseTradeID     uuid.NullUUID
 	}
 
 	err := om.db.QueryRowContext(ctx, `
-		SELECT strategy_id, symbol, side, quantity, average_fill_price, fees
+		SELECT strategy_id, symbol, side, intent,
+		       CASE WHEN filled_quantity > 0 THEN filled_quantity ELSE quantity END,
+		       average_fill_price, fees, fee_currency, exit_reason, close_trade_id
 		FROM orders WHERE id = $1
 	`, orderID).Scan(
 		&order.StrategyID,
 		&order.Symbol,
 		&order.Side,
+		&order.Intent,
 		&order.Quantity,
 		&order.AverageFillPrice,
 		&order.Fees,
+		&order.FeeCurrency,
+		&order.ExitReason,
+		&order.CloseTradeID,
 	)
 
 	if err != nil {
@@ -281,12 +594,32 @@ func (om *OrderManager) handleFilledOrder(ctx context.Context, orderID uuid.UUID
 	}
 
 	// Check if this is opening or closing a trade
-	if order.Side == string(models.OrderSideBuy) {
-		// Opening a LONG position
-		om.createTrade(ctx, orderID, order.StrategyID, order.Symbol, order.AverageFillPrice, order.Quantity, models.TradeSideLong)
+	if order.Intent.IsOpening() {
+		om.createTrade(ctx, orderID, order.StrategyID, order.Symbol, order.AverageFillPrice, order.Quantity, order.Fees, order.Intent.TradeSide())
 	} else {
-		// Closing a position
-		om.closeTrade(ctx, orderID, order.StrategyID, order.Symbol, order.AverageFillPrice, order.Fees)
+		exitReason, err := models.ParseExitReason(order.ExitReason.String)
+		if err != nil {
+			om.logger.WithError(err).WithField("order_id", orderID).Warn("Unknown exit reason, recording SIGNAL")
+			exitReason = models.ExitReasonSignal
+		}
+
+		om.closeTrade(ctx, position.Exit{
+			OrderID:    orderID,
+			StrategyID: order.StrategyID,
+			Symbol:     order.Symbol,
+			Side:       order.Intent.TradeSide(),
+			Price:      order.AverageFillPrice,
+			Quantity:   order.Quantity,
+			Fees:       order.Fees,
+			Reason:     exitReason,
+			TradeID:    order.CloseTradeID.UUID,
+		})
+
+		// A partial take-profit moves what is left of the position on to
+		// its next target
+		if exitReason == models.ExitReasonTakeProfit {
+			om.recordTakeProfitHit(ctx, order.StrategyID, order.Symbol)
+		}
 	}
 
 	// Publish order filled event
@@ -296,18 +629,20 @@ func (om *OrderManager) handleFilledOrder(ctx context.Context, orderID uuid.UUID
 		ExchangeOrderID:  resp.ExchangeOrderID,
 		Symbol:           order.Symbol,
 		Side:             order.Side,
+		Intent:           string(order.Intent),
 		FilledQuantity:   order.Quantity.InexactFloat64(),
 		AverageFillPrice: order.AverageFillPrice.InexactFloat64(),
 		Fees:             order.Fees.InexactFloat64(),
+		FeeCurrency:      order.FeeCurrency.String,
 		FilledAt:         time.Now(),
 	}
 
-	if err := om.nats.Publish(events.EventTypeOrderFilled, filledEvent); err != nil {
+	if err := om.bus.Publish(events.EventTypeOrderFilled, filledEvent); err != nil {
 		om.logger.WithError(err).Error("Failed to publish order filled event")
 	}
 }
 
-// createTrade creates a new trade record
+// createTrade records the lot opened by a fill
 func (om *OrderManager) createTrade(
 	ctx context.Context,
 	orderID uuid.UUID,
@@ -315,20 +650,10 @@ func (om *OrderManager) createTrade(
 	symbol string,
 	entryPrice decimal.Decimal,
 	quantity decimal.Decimal,
+	fees decimal.Decimal,
 	side models.TradeSide,
 ) {
-	tradeID := uuid.New()
-	metadata := map[string]interface{}{
-		"entry_order_id": orderID.String(),
-	}
-	metadataJSON, _ := json.Marshal(metadata)
-
-	_, err := om.db.ExecContext(ctx, `
-		INSERT INTO trades (
-			id, entry_order_id, strategy_id, symbol, entry_price, quantity, side, entry_time, metadata
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), $8)
-	`, tradeID, orderID, strategyID, symbol, entryPrice, quantity, side, metadataJSON)
-
+	tradeID, err := om.ledger.OpenLot(ctx, orderID, strategyID, symbol, side, entryPrice, quantity, fees)
 	if err != nil {
 		om.logger.WithError(err).Error("Failed to create trade")
 		return
@@ -354,128 +679,88 @@ func (om *OrderManager) createTrade(
 		EntryTime:  time.Now(),
 	}
 
-	if err := om.nats.Publish(events.EventTypeTradeOpened, tradeEvent); err != nil {
+	if err := om.bus.Publish(events.EventTypeTradeOpened, tradeEvent); err != nil {
 		om.logger.WithError(err).Error("Failed to publish trade opened event")
 	}
 }
 
-// closeTrade closes an open trade
-func (om *OrderManager) closeTrade(
-	ctx context.Context,
-	exitOrderID uuid.UUID,
-	strategyID uuid.UUID,
-	symbol string,
-	exitPrice decimal.Decimal,
-	exitFees decimal.Decimal,
-) {
-	// Get open trade
-	var trade struct {
-		ID         uuid.UUID
-		EntryPrice decimal.Decimal
-		Quantity   decimal.Decimal
-		Side       string
-		EntryTime  time.Time
-		EntryFees  decimal.Decimal
-	}
-
-	err := om.db.QueryRowContext(ctx, `
-		SELECT t.id, t.entry_price, t.quantity, t.side, t.entry_time, COALESCE(o.fees, 0) as entry_fees
-		FROM trades t
-		LEFT JOIN orders o ON t.entry_order_id = o.id
-		WHERE t.strategy_id = $1 AND t.symbol = $2 AND t.exit_time IS NULL
-		ORDER BY t.entry_time DESC
-		LIMIT 1
-	`, strategyID, symbol).Scan(
-		&trade.ID,
-		&trade.EntryPrice,
-		&trade.Quantity,
-		&trade.Side,
-		&trade.EntryTime,
-		&trade.EntryFees,
-	)
-
+// closeTrade matches an exit fill against the open lots of the position
+func (om *OrderManager) closeTrade(ctx context.Context, exit position.Exit) {
+	closed, err := om.ledger.Close(ctx, exit)
 	if err != nil {
-		om.logger.WithError(err).Error("Failed to get open trade for closing")
+		om.logger.WithError(err).WithField("order_id", exit.OrderID).Error("Failed to close trade")
 		return
 	}
 
-	// Calculate P&L
-	totalFees := trade.EntryFees.Add(exitFees)
-	var pnl decimal.Decimal
-
-	if trade.Side == string(models.TradeSideLong) {
-		// Long: P&L = (exit_price - entry_price) * quantity - fees
-		pnl = exitPrice.Sub(trade.EntryPrice).Mul(trade.Quantity).Sub(totalFees)
-	} else {
-		// Short: P&L = (entry_price - exit_price) * quantity - fees
-		pnl = trade.EntryPrice.Sub(exitPrice).Mul(trade.Quantity).Sub(totalFees)
+	for _, lot := range closed {
+		om.logger.WithFields(logrus.Fields{
+			"trade_id":      lot.TradeID,
+			"entry_price":   lot.EntryPrice.String(),
+			"exit_price":    lot.ExitPrice.String(),
+			"quantity":      lot.Quantity.String(),
+			"pnl":           lot.PnL.String(),
+			"pnl_percent":   lot.PnLPercent.String(),
+			"hold_duration": lot.HoldDuration,
+			"partial":       lot.Partial,
+			"exit_reason":   exit.Reason,
+		}).Info("Trade closed")
+
+		// Publish trade closed event
+		tradeEvent := &events.TradeClosedEvent{
+			TradeID:      lot.TradeID.String(),
+			StrategyID:   exit.StrategyID.String(),
+			Symbol:       exit.Symbol,
+			EntryPrice:   lot.EntryPrice.InexactFloat64(),
+			ExitPrice:    lot.ExitPrice.InexactFloat64(),
+			Quantity:     lot.Quantity.InexactFloat64(),
+			PnL:          lot.PnL.InexactFloat64(),
+			PnLPercent:   lot.PnLPercent.InexactFloat64(),
+			ExitReason:   string(exit.Reason),
+			ExitTime:     time.Now(),
+			HoldDuration: lot.HoldDuration.String(),
+		}
+
+		if err := om.bus.Publish(events.EventTypeTradeClosed, tradeEvent); err != nil {
+			om.logger.WithError(err).Error("Failed to publish trade closed event")
+		}
 	}
+}
 
-	pnlPercent := pnl.Div(trade.EntryPrice.Mul(trade.Quantity)).Mul(decimal.NewFromInt(100))
-	holdDuration := time.Since(trade.EntryTime)
-
-	// Update trade
-	_, err = om.db.ExecContext(ctx, `
+// recordTakeProfitHit counts a filled take-profit on the lots of the
+// position it left open, if any
+func (om *OrderManager) recordTakeProfitHit(ctx context.Context, strategyID uuid.UUID, symbol string) {
+	_, err := om.db.ExecContext(ctx, `
 		UPDATE trades
-		SET exit_order_id = $2,
-		    exit_price = $3,
-		    exit_time = NOW(),
-		    pnl = $4,
-		    pnl_percent = $5,
-		    fees_total = $6,
-		    hold_duration = $7,
-		    exit_reason = 'SIGNAL'
-		WHERE id = $1
-	`, trade.ID, exitOrderID, exitPrice, pnl, pnlPercent, totalFees, holdDuration)
-
+		SET metadata = jsonb_set(
+			COALESCE(metadata, '{}'::jsonb), '{take_profit_hits}',
+			to_jsonb(COALESCE((metadata->>'take_profit_hits')::int, 0) + 1))
+		WHERE strategy_id = $1 AND symbol = $2 AND exit_time IS NULL
+	`, strategyID, symbol)
 	if err != nil {
-		om.logger.WithError(err).Error("Failed to update closed trade")
-		return
-	}
-
-	om.logger.WithFields(logrus.Fields{
-		"trade_id":      trade.ID,
-		"entry_price":   trade.EntryPrice.String(),
-		"exit_price":    exitPrice.String(),
-		"pnl":           pnl.String(),
-		"pnl_percent":   pnlPercent.String(),
-		"hold_duration": holdDuration,
-	}).Info("Trade closed")
-
-	// Publish trade closed event
-	tradeEvent := &events.TradeClosedEvent{
-		TradeID:      trade.ID.String(),
-		StrategyID:   strategyID.String(),
-		Symbol:       symbol,
-		EntryPrice:   trade.EntryPrice.InexactFloat64(),
-		ExitPrice:    exitPrice.InexactFloat64(),
-		Quantity:     trade.Quantity.InexactFloat64(),
-		PnL:          pnl.InexactFloat64(),
-		PnLPercent:   pnlPercent.InexactFloat64(),
-		ExitReason:   "SIGNAL",
-		ExitTime:     time.Now(),
-		HoldDuration: holdDuration.String(),
-	}
-
-	if err := om.nats.Publish(events.EventTypeTradeClosed, tradeEvent); err != nil {
-		om.logger.WithError(err).Error("Failed to publish trade closed event")
+		om.logger.WithError(err).WithField("strategy_id", strategyID).Error("Failed to record take profit")
 	}
 }
 
 // Helper methods
 
+// generateClientOrderID derives the client order ID from the signal, so a
+// redelivered signal maps to the same order. Exchanges require a UUID.
 func (om *OrderManager) generateClientOrderID(signal *events.TradeSignalEvent) string {
+	if signal.ID != "" {
+		return uuid.NewSHA1(clientOrderIDNamespace, []byte(signal.ID)).String()
+	}
+
 	// Create deterministic ID from signal properties
-	data := fmt.Sprintf("%s-%s-%s-%f-%f",
+	data := fmt.Sprintf("%s-%s-%s-%s-%f-%f",
 		signal.StrategyID,
 		signal.Symbol,
 		signal.Side,
+		signal.Intent,
 		signal.Quantity,
 		signal.Indicators["price"],
 	)
 
-	hash := sha256.Sum256([]byte(data))
-	return hex.EncodeToString(hash[:16]) // Use first 16 bytes
+	return uuid.NewSHA1(clientOrderIDNamespace, []byte(data)).String()
 }
 
 func (om *OrderManager) publishOrderEvent(
@@ -492,13 +777,14 @@ func (om *OrderManager) publishOrderEvent(
 		StrategyID:      signal.StrategyID,
 		Symbol:          signal.Symbol,
 		Side:            signal.Side,
+		Intent:          signal.Intent,
 		Type:            signal.Type,
 		Quantity:        signal.Quantity,
 		Price:           signal.Price,
 		StopLossPrice:   &signal.StopLossPrice,
 	}
 
-	if err := om.nats.Publish(eventType, event); err != nil {
+	if err := om.bus.Publish(eventType, event); err != nil {
 		om.logger.WithError(err).WithField("event_type", eventType).Error("Failed to publish order event")
 	}
 }
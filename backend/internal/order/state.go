@@ -0,0 +1,138 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Sources of order status transitions, recorded in order_events
+const (
+	SourceOrderManager = "order_manager" // Order creation and local failures
+	SourceExchange     = "exchange"      // Responses to placing an order
+	SourceReconciler   = "reconciler"    // Recovery of PENDING orders and updates of OPEN ones
+	SourceKillSwitch   = "kill_switch"
+	SourceUser         = "user"
+)
+
+// ErrInvalidTransition is returned for a status change the state machine
+// doesn't allow
+var ErrInvalidTransition = errors.New("invalid order status transition")
+
+// orderTransitions lists the statuses each status can move to. PENDING and
+// OPEN may repeat to record exchange IDs and partial fills; FILLED, FAILED
+// and CANCELLED are final.
+var orderTransitions = map[models.OrderStatus][]models.OrderStatus{
+	models.OrderStatusPending: {
+		models.OrderStatusPending,
+		models.OrderStatusOpen,
+		models.OrderStatusFilled,
+		models.OrderStatusCancelled,
+		models.OrderStatusFailed,
+	},
+	models.OrderStatusOpen: {
+		models.OrderStatusOpen,
+		models.OrderStatusFilled,
+		models.OrderStatusCancelled,
+	},
+}
+
+// Transition is a status change of an order with the execution details
+// reported alongside it
+type Transition struct {
+	To               models.OrderStatus
+	Source           string
+	Reason           string
+	ExchangeOrderID  string
+	FilledQuantity   *decimal.Decimal
+	AverageFillPrice *decimal.Decimal
+	Fees             *decimal.Decimal
+	FeeCurrency      string
+}
+
+// CanTransition reports whether an order may move between the statuses.
+// A local cancel can lose the race against the exchange filling the order,
+// so the exchange may still report a cancelled order as filled.
+func CanTransition(from, to models.OrderStatus, source string) bool {
+	if from == models.OrderStatusCancelled && to == models.OrderStatusFilled {
+		return source == SourceExchange || source == SourceReconciler
+	}
+
+	for _, allowed := range orderTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionOrder validates and applies a status change and records it in
+// the order's history. It returns the status the order moved from.
+func TransitionOrder(ctx context.Context, db *sql.DB, orderID uuid.UUID, t Transition) (models.OrderStatus, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var from models.OrderStatus
+	err = tx.QueryRowContext(ctx, `
+		SELECT status FROM orders WHERE id = $1 FOR UPDATE
+	`, orderID).Scan(&from)
+	if err != nil {
+		return "", fmt.Errorf("failed to get order status: %w", err)
+	}
+
+	if !CanTransition(from, t.To, t.Source) {
+		return from, fmt.Errorf("%w: %s -> %s (%s)", ErrInvalidTransition, from, t.To, t.Source)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE orders
+		SET status = $2,
+		    exchange_order_id = COALESCE(NULLIF($3, ''), exchange_order_id),
+		    filled_quantity = COALESCE($4, filled_quantity),
+		    average_fill_price = COALESCE($5, average_fill_price),
+		    fees = COALESCE($6, fees),
+		    fee_currency = COALESCE(NULLIF($7, ''), fee_currency),
+		    filled_at = CASE WHEN $2 = 'FILLED' THEN NOW() ELSE filled_at END,
+		    updated_at = NOW()
+		WHERE id = $1
+	`, orderID, t.To, t.ExchangeOrderID, t.FilledQuantity, t.AverageFillPrice, t.Fees, t.FeeCurrency)
+	if err != nil {
+		return from, fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	if err := insertOrderEvent(ctx, tx, orderID, &from, t); err != nil {
+		return from, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return from, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return from, nil
+}
+
+// insertOrderEvent records a status change; from is nil for a new order
+func insertOrderEvent(ctx context.Context, tx *sql.Tx, orderID uuid.UUID, from *models.OrderStatus, t Transition) error {
+	var reason *string
+	if t.Reason != "" {
+		reason = &t.Reason
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO order_events (order_id, from_status, to_status, source, reason, filled_quantity)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, orderID, from, t.To, t.Source, reason, t.FilledQuantity)
+	if err != nil {
+		return fmt.Errorf("failed to record order event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/crypto-trading-bot/internal/models"
+)
+
+func TestCanTransition(t *testing.T) {
+	const (
+		pending   = models.OrderStatusPending
+		open      = models.OrderStatusOpen
+		filled    = models.OrderStatusFilled
+		cancelled = models.OrderStatusCancelled
+		failed    = models.OrderStatusFailed
+	)
+
+	tests := []struct {
+		from   models.OrderStatus
+		to     models.OrderStatus
+		source string
+		want   bool
+	}{
+		{pending, pending, SourceExchange, true},
+		{pending, open, SourceExchange, true},
+		{pending, filled, SourceExchange, true},
+		{pending, cancelled, SourceUser, true},
+		{pending, failed, SourceOrderManager, true},
+		{open, open, SourceReconciler, true},
+		{open, filled, SourceReconciler, true},
+		{open, cancelled, SourceKillSwitch, true},
+		{open, pending, SourceExchange, false},
+		{open, failed, SourceOrderManager, false},
+		{filled, filled, SourceExchange, false},
+		{filled, cancelled, SourceUser, false},
+		{failed, open, SourceReconciler, false},
+		{failed, filled, SourceExchange, false},
+		{cancelled, open, SourceExchange, false},
+		{cancelled, cancelled, SourceUser, false},
+
+		// A cancel can lose the race against a fill
+		{cancelled, filled, SourceExchange, true},
+		{cancelled, filled, SourceReconciler, true},
+		{cancelled, filled, SourceUser, false},
+		{cancelled, filled, SourceKillSwitch, false},
+		{cancelled, filled, SourceOrderManager, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to)+"/"+tt.source, func(t *testing.T) {
+			if got := CanTransition(tt.from, tt.to, tt.source); got != tt.want {
+				t.Errorf("CanTransition(%s, %s, %s) = %v, want %v", tt.from, tt.to, tt.source, got, tt.want)
+			}
+		})
+	}
+}
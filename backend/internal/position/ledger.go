@@ -0,0 +1,396 @@
+package position
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// Method is how exits are matched against the open lots of a position
+type Method string
+
+// Lot matching methods
+const (
+	MethodFIFO    Method = "FIFO"    // Oldest lot first
+	MethodLIFO    Method = "LIFO"    // Newest lot first
+	MethodAverage Method = "AVERAGE" // Lots pooled at their average entry price
+)
+
+// ErrNoOpenLots is returned when an exit has no open lot to close
+var ErrNoOpenLots = errors.New("no open lots")
+
+// Exit is a fill that reduces a position
+type Exit struct {
+	OrderID    uuid.UUID
+	StrategyID uuid.UUID
+	Symbol     string
+	Side       models.TradeSide // Side of the position being reduced
+	Price      decimal.Decimal
+	Quantity   decimal.Decimal
+	Fees       decimal.Decimal // Fees of the whole exit, split across the lots it closes
+	Reason     models.ExitReason
+	TradeID    uuid.UUID // Lot to close; uuid.Nil matches the open lots by the ledger's method
+}
+
+// ClosedLot is the part of a lot closed by an exit. It is stored as a
+// closed trade; a partially closed lot is split and stays open with the
+// remaining quantity.
+type ClosedLot struct {
+	TradeID      uuid.UUID
+	EntryPrice   decimal.Decimal
+	ExitPrice    decimal.Decimal
+	Quantity     decimal.Decimal
+	Fees         decimal.Decimal // Proportional entry and exit fees
+	PnL          decimal.Decimal
+	PnLPercent   decimal.Decimal
+	HoldDuration time.Duration
+	Partial      bool // The lot still has quantity open
+}
+
+// lot is an open trade
+type lot struct {
+	id         uuid.UUID
+	entryPrice decimal.Decimal
+	quantity   decimal.Decimal
+	entryFees  decimal.Decimal
+	entryTime  time.Time
+	metadata   []byte
+}
+
+// Ledger tracks positions as lots, one open trade per opening fill
+type Ledger struct {
+	db     *sql.DB
+	method Method
+	logger *logrus.Entry
+}
+
+// NewLedger creates a ledger matching exits with the given method
+func NewLedger(db *sql.DB, method Method, logger *logrus.Logger) *Ledger {
+	return &Ledger{
+		db:     db,
+		method: method,
+		logger: logger.WithField("component", "position-ledger"),
+	}
+}
+
+// OpenLot records the lot opened by a fill and returns its trade ID
+func (l *Ledger) OpenLot(
+	ctx context.Context,
+	orderID uuid.UUID,
+	strategyID uuid.UUID,
+	symbol string,
+	side models.TradeSide,
+	price decimal.Decimal,
+	quantity decimal.Decimal,
+	fees decimal.Decimal,
+) (uuid.UUID, error) {
+	tradeID := uuid.New()
+	metadata := map[string]interface{}{
+		"entry_order_id": orderID.String(),
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	_, err := l.db.ExecContext(ctx, `
+		INSERT INTO trades (
+			id, entry_order_id, strategy_id, symbol, entry_price, quantity, entry_fees, side, entry_time, metadata
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), $9)
+	`, tradeID, orderID, strategyID, symbol, price, quantity, fees, side, metadataJSON)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create trade: %w", err)
+	}
+
+	return tradeID, nil
+}
+
+// Close matches an exit against the open lots of the position and realizes
+// the PnL of each lot it closes
+func (l *Ledger) Close(ctx context.Context, exit Exit) ([]ClosedLot, error) {
+	if !exit.Quantity.IsPositive() {
+		return nil, fmt.Errorf("exit quantity must be positive")
+	}
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	lots, err := l.openLots(ctx, tx, exit)
+	if err != nil {
+		return nil, err
+	}
+	if len(lots) == 0 {
+		return nil, fmt.Errorf("%w for %s %s", ErrNoOpenLots, exit.Symbol, exit.Side)
+	}
+
+	if l.method == MethodAverage {
+		if err := poolLots(ctx, tx, lots); err != nil {
+			return nil, err
+		}
+	}
+
+	if exit.TradeID != uuid.Nil {
+		lots = targetLot(lots, exit.TradeID)
+		if len(lots) == 0 {
+			return nil, fmt.Errorf("%w: trade %s is not open", ErrNoOpenLots, exit.TradeID)
+		}
+	}
+
+	now := time.Now()
+	matches, remaining := matchLots(lots, exit, now)
+	closed := make([]ClosedLot, 0, len(matches))
+
+	for _, m := range matches {
+		closedLot := m.closed
+		if closedLot.Partial {
+			closedLot.TradeID, err = splitLot(ctx, tx, m.open, exit, closedLot, m.entryFees, now)
+		} else {
+			err = closeLot(ctx, tx, m.open.id, exit, closedLot, now)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		closed = append(closed, closedLot)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if remaining.IsPositive() {
+		l.logger.WithFields(logrus.Fields{
+			"strategy_id": exit.StrategyID,
+			"symbol":      exit.Symbol,
+			"side":        exit.Side,
+			"unmatched":   remaining.String(),
+		}).Warn("Exit quantity exceeds the open lots")
+	}
+
+	return closed, nil
+}
+
+// openLots locks the open lots of the position in matching order
+func (l *Ledger) openLots(ctx context.Context, tx *sql.Tx, exit Exit) ([]*lot, error) {
+	order := "ASC"
+	if l.method == MethodLIFO {
+		order = "DESC"
+	}
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, entry_price, quantity, entry_fees, entry_time, metadata
+		FROM trades
+		WHERE strategy_id = $1 AND symbol = $2 AND side = $3 AND exit_time IS NULL
+		ORDER BY entry_time %s, id %s
+		FOR UPDATE
+	`, order, order), exit.StrategyID, exit.Symbol, exit.Side)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open lots: %w", err)
+	}
+	defer rows.Close()
+
+	var lots []*lot
+	for rows.Next() {
+		open := &lot{}
+		if err := rows.Scan(
+			&open.id,
+			&open.entryPrice,
+			&open.quantity,
+			&open.entryFees,
+			&open.entryTime,
+			&open.metadata,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan lot: %w", err)
+		}
+		lots = append(lots, open)
+	}
+
+	return lots, rows.Err()
+}
+
+// targetLot returns the lot with the given trade ID, if it is open
+func targetLot(lots []*lot, tradeID uuid.UUID) []*lot {
+	for _, open := range lots {
+		if open.id == tradeID {
+			return []*lot{open}
+		}
+	}
+	return nil
+}
+
+// poolLots moves every open lot to the position's average entry price
+func poolLots(ctx context.Context, tx *sql.Tx, lots []*lot) error {
+	average, ok := averageEntryPrice(lots)
+	if !ok {
+		return nil
+	}
+
+	for _, open := range lots {
+		if open.entryPrice.Equal(average) {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE trades SET entry_price = $2 WHERE id = $1
+		`, open.id, average); err != nil {
+			return fmt.Errorf("failed to pool lot: %w", err)
+		}
+		open.entryPrice = average
+	}
+
+	return nil
+}
+
+// averageEntryPrice returns the quantity-weighted entry price of the lots,
+// false if they hold no quantity
+func averageEntryPrice(lots []*lot) (decimal.Decimal, bool) {
+	cost, quantity := decimal.Zero, decimal.Zero
+	for _, open := range lots {
+		cost = cost.Add(open.entryPrice.Mul(open.quantity))
+		quantity = quantity.Add(open.quantity)
+	}
+	if !quantity.IsPositive() {
+		return decimal.Zero, false
+	}
+	return cost.Div(quantity).Round(8), true
+}
+
+// lotMatch is the part of an open lot an exit closes
+type lotMatch struct {
+	open      *lot
+	entryFees decimal.Decimal // Share of the lot's entry fees
+	closed    ClosedLot
+}
+
+// matchLots splits an exit across the lots in matching order, prorating the
+// lots' entry fees and the exit's fees by quantity. It returns the matches
+// and the exit quantity left unmatched.
+func matchLots(lots []*lot, exit Exit, now time.Time) ([]lotMatch, decimal.Decimal) {
+	remaining := exit.Quantity
+	var matches []lotMatch
+
+	for _, open := range lots {
+		if !remaining.IsPositive() {
+			break
+		}
+
+		quantity := decimal.Min(open.quantity, remaining)
+		entryFees := open.entryFees.Mul(quantity).Div(open.quantity)
+		exitFees := exit.Fees.Mul(quantity).Div(exit.Quantity)
+
+		closed := realize(open, exit, quantity, entryFees.Add(exitFees), now)
+		if quantity.Equal(open.quantity) {
+			closed.TradeID = open.id
+		} else {
+			closed.Partial = true
+		}
+
+		matches = append(matches, lotMatch{open: open, entryFees: entryFees, closed: closed})
+		remaining = remaining.Sub(quantity)
+	}
+
+	return matches, remaining
+}
+
+// realize computes the PnL of closing part of a lot
+func realize(open *lot, exit Exit, quantity, fees decimal.Decimal, now time.Time) ClosedLot {
+	gross := exit.Price.Sub(open.entryPrice).Mul(quantity)
+	if exit.Side == models.TradeSideShort {
+		gross = gross.Neg()
+	}
+	pnl := gross.Sub(fees)
+
+	pnlPercent := decimal.Zero
+	if cost := open.entryPrice.Mul(quantity); cost.IsPositive() {
+		pnlPercent = pnl.Div(cost).Mul(decimal.NewFromInt(100))
+	}
+
+	return ClosedLot{
+		EntryPrice:   open.entryPrice,
+		ExitPrice:    exit.Price,
+		Quantity:     quantity,
+		Fees:         fees,
+		PnL:          pnl,
+		PnLPercent:   pnlPercent,
+		HoldDuration: now.Sub(open.entryTime),
+	}
+}
+
+// closeLot closes a whole lot
+func closeLot(ctx context.Context, tx *sql.Tx, tradeID uuid.UUID, exit Exit, closed ClosedLot, now time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE trades
+		SET exit_order_id = $2,
+		    exit_price = $3,
+		    exit_time = $4,
+		    pnl = $5,
+		    pnl_percent = $6,
+		    fees_total = $7,
+		    hold_duration = $8,
+		    exit_reason = $9
+		WHERE id = $1
+	`, tradeID, exit.OrderID, exit.Price, now, closed.PnL, closed.PnLPercent.Round(4),
+		closed.Fees, interval(closed.HoldDuration), exit.Reason)
+	if err != nil {
+		return fmt.Errorf("failed to close trade: %w", err)
+	}
+	return nil
+}
+
+// splitLot records the closed part of a lot as a closed trade and leaves the
+// rest open. It returns the ID of the closed trade.
+func splitLot(
+	ctx context.Context,
+	tx *sql.Tx,
+	open *lot,
+	exit Exit,
+	closed ClosedLot,
+	entryFees decimal.Decimal,
+	now time.Time,
+) (uuid.UUID, error) {
+	metadata := map[string]interface{}{}
+	if len(open.metadata) > 0 {
+		json.Unmarshal(open.metadata, &metadata)
+	}
+	metadata["split_from"] = open.id.String()
+	metadataJSON, _ := json.Marshal(metadata)
+
+	tradeID := uuid.New()
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO trades (
+			id, entry_order_id, exit_order_id, strategy_id, symbol, entry_price, exit_price,
+			quantity, entry_fees, side, entry_time, exit_time, pnl, pnl_percent, fees_total,
+			hold_duration, exit_reason, metadata
+		)
+		SELECT $2, entry_order_id, $3, strategy_id, symbol, entry_price, $4,
+		       $5, $6, side, entry_time, $7, $8, $9, $10,
+		       $11, $12, $13
+		FROM trades WHERE id = $1
+	`, open.id, tradeID, exit.OrderID, exit.Price,
+		closed.Quantity, entryFees, now, closed.PnL, closed.PnLPercent.Round(4), closed.Fees,
+		interval(closed.HoldDuration), exit.Reason, metadataJSON)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to record partial close: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE trades SET quantity = $2, entry_fees = $3 WHERE id = $1
+	`, open.id, open.quantity.Sub(closed.Quantity), open.entryFees.Sub(entryFees))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to reduce lot: %w", err)
+	}
+
+	return tradeID, nil
+}
+
+// interval formats a duration for a Postgres INTERVAL column
+func interval(d time.Duration) string {
+	return fmt.Sprintf("%f seconds", d.Seconds())
+}
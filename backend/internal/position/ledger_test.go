@@ -0,0 +1,223 @@
+package position
+
+import (
+	"testing"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestMatchLots(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	first, second := uuid.New(), uuid.New()
+
+	newLots := func() []*lot {
+		return []*lot{
+			{id: first, entryPrice: dec("100"), quantity: dec("2"), entryFees: dec("0.4"), entryTime: now.Add(-2 * time.Hour)},
+			{id: second, entryPrice: dec("110"), quantity: dec("1"), entryFees: dec("0.3"), entryTime: now.Add(-time.Hour)},
+		}
+	}
+
+	type want struct {
+		tradeID   uuid.UUID // uuid.Nil for a partial close
+		quantity  string
+		entryFees string
+		fees      string
+		pnl       string
+	}
+
+	tests := []struct {
+		name      string
+		side      models.TradeSide
+		price     string
+		quantity  string
+		fees      string
+		want      []want
+		unmatched string
+	}{
+		{
+			name:     "partial close of the first lot",
+			side:     models.TradeSideLong,
+			price:    "120",
+			quantity: "1",
+			fees:     "0.5",
+			want: []want{
+				{tradeID: uuid.Nil, quantity: "1", entryFees: "0.2", fees: "0.7", pnl: "19.3"},
+			},
+			unmatched: "0",
+		},
+		{
+			name:     "spans lots and splits the second",
+			side:     models.TradeSideLong,
+			price:    "120",
+			quantity: "2.5",
+			fees:     "1",
+			want: []want{
+				{tradeID: first, quantity: "2", entryFees: "0.4", fees: "1.2", pnl: "38.8"},
+				{tradeID: uuid.Nil, quantity: "0.5", entryFees: "0.15", fees: "0.35", pnl: "4.65"},
+			},
+			unmatched: "0",
+		},
+		{
+			name:     "exceeds the open lots",
+			side:     models.TradeSideLong,
+			price:    "90",
+			quantity: "4",
+			fees:     "0.8",
+			want: []want{
+				{tradeID: first, quantity: "2", entryFees: "0.4", fees: "0.8", pnl: "-20.8"},
+				{tradeID: second, quantity: "1", entryFees: "0.3", fees: "0.5", pnl: "-20.5"},
+			},
+			unmatched: "1",
+		},
+		{
+			name:     "short profits from a falling price",
+			side:     models.TradeSideShort,
+			price:    "90",
+			quantity: "2",
+			fees:     "0",
+			want: []want{
+				{tradeID: first, quantity: "2", entryFees: "0.4", fees: "0.4", pnl: "19.6"},
+			},
+			unmatched: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exit := Exit{
+				Side:     tt.side,
+				Price:    dec(tt.price),
+				Quantity: dec(tt.quantity),
+				Fees:     dec(tt.fees),
+			}
+
+			matches, unmatched := matchLots(newLots(), exit, now)
+
+			if !unmatched.Equal(dec(tt.unmatched)) {
+				t.Errorf("unmatched = %s, want %s", unmatched, tt.unmatched)
+			}
+			if len(matches) != len(tt.want) {
+				t.Fatalf("got %d matches, want %d", len(matches), len(tt.want))
+			}
+			for i, w := range tt.want {
+				m := matches[i]
+				if m.closed.TradeID != w.tradeID {
+					t.Errorf("match %d: trade ID = %s, want %s", i, m.closed.TradeID, w.tradeID)
+				}
+				if m.closed.Partial != (w.tradeID == uuid.Nil) {
+					t.Errorf("match %d: partial = %v", i, m.closed.Partial)
+				}
+				if !m.closed.Quantity.Equal(dec(w.quantity)) {
+					t.Errorf("match %d: quantity = %s, want %s", i, m.closed.Quantity, w.quantity)
+				}
+				if !m.entryFees.Equal(dec(w.entryFees)) {
+					t.Errorf("match %d: entry fees = %s, want %s", i, m.entryFees, w.entryFees)
+				}
+				if !m.closed.Fees.Equal(dec(w.fees)) {
+					t.Errorf("match %d: fees = %s, want %s", i, m.closed.Fees, w.fees)
+				}
+				if !m.closed.PnL.Equal(dec(w.pnl)) {
+					t.Errorf("match %d: PnL = %s, want %s", i, m.closed.PnL, w.pnl)
+				}
+			}
+		})
+	}
+}
+
+func TestRealize(t *testing.T) {
+	entryTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := entryTime.Add(90 * time.Minute)
+
+	tests := []struct {
+		name       string
+		side       models.TradeSide
+		entry      string
+		exit       string
+		quantity   string
+		fees       string
+		pnl        string
+		pnlPercent string
+	}{
+		{"long gain", models.TradeSideLong, "100", "110", "2", "1", "19", "9.5"},
+		{"long loss", models.TradeSideLong, "100", "95", "2", "1", "-11", "-5.5"},
+		{"short gain", models.TradeSideShort, "100", "95", "2", "1", "9", "4.5"},
+		{"short loss", models.TradeSideShort, "100", "110", "2", "0", "-20", "-10"},
+		{"zero cost", models.TradeSideLong, "0", "10", "1", "0", "10", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open := &lot{entryPrice: dec(tt.entry), quantity: dec(tt.quantity), entryTime: entryTime}
+			exit := Exit{Side: tt.side, Price: dec(tt.exit)}
+
+			closed := realize(open, exit, dec(tt.quantity), dec(tt.fees), now)
+
+			if !closed.PnL.Equal(dec(tt.pnl)) {
+				t.Errorf("PnL = %s, want %s", closed.PnL, tt.pnl)
+			}
+			if !closed.PnLPercent.Equal(dec(tt.pnlPercent)) {
+				t.Errorf("PnL percent = %s, want %s", closed.PnLPercent, tt.pnlPercent)
+			}
+			if closed.HoldDuration != 90*time.Minute {
+				t.Errorf("hold duration = %s, want 1h30m", closed.HoldDuration)
+			}
+		})
+	}
+}
+
+func TestTargetLot(t *testing.T) {
+	a, b := &lot{id: uuid.New()}, &lot{id: uuid.New()}
+	lots := []*lot{a, b}
+
+	if got := targetLot(lots, b.id); len(got) != 1 || got[0] != b {
+		t.Errorf("targetLot(b) = %v, want [b]", got)
+	}
+	if got := targetLot(lots, uuid.New()); got != nil {
+		t.Errorf("targetLot(unknown) = %v, want nil", got)
+	}
+}
+
+func TestAverageEntryPrice(t *testing.T) {
+	tests := []struct {
+		name string
+		lots []*lot
+		want string
+		ok   bool
+	}{
+		{
+			name: "weighted by quantity",
+			lots: []*lot{
+				{entryPrice: dec("100"), quantity: dec("3")},
+				{entryPrice: dec("120"), quantity: dec("1")},
+			},
+			want: "105",
+			ok:   true,
+		},
+		{
+			name: "rounded to 8 places",
+			lots: []*lot{
+				{entryPrice: dec("1"), quantity: dec("1")},
+				{entryPrice: dec("2"), quantity: dec("2")},
+			},
+			want: "1.66666667",
+			ok:   true,
+		},
+		{name: "no lots", want: "0", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := averageEntryPrice(tt.lots)
+			if ok != tt.ok || !got.Equal(dec(tt.want)) {
+				t.Errorf("averageEntryPrice = %s, %v, want %s, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// TokenBucket allows bursts up to its capacity and refills at a steady rate
+type TokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	rate     float64 // Tokens per second
+	last     time.Time
+}
+
+// NewTokenBucket creates a full bucket holding capacity tokens, refilled
+// at rate tokens per second
+func NewTokenBucket(capacity int, rate float64) *TokenBucket {
+	return &TokenBucket{
+		capacity: float64(capacity),
+		tokens:   float64(capacity),
+		rate:     rate,
+		last:     time.Now(),
+	}
+}
+
+// PerMinute creates a bucket allowing n events per minute, all at once at most
+func PerMinute(n int) *TokenBucket {
+	return NewTokenBucket(n, float64(n)/60)
+}
+
+// Allow takes a token if one is available
+func (tb *TokenBucket) Allow() bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill(time.Now())
+	if tb.tokens < 1 {
+		return false
+	}
+	tb.tokens--
+	return true
+}
+
+// Return gives back a token taken by Allow for an event that didn't happen
+func (tb *TokenBucket) Return() {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill(time.Now())
+	tb.tokens = math.Min(tb.tokens+1, tb.capacity)
+}
+
+// SetLimit changes the capacity and refill rate, keeping the tokens already
+// earned up to the new capacity
+func (tb *TokenBucket) SetLimit(capacity int, rate float64) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill(time.Now())
+	tb.capacity = float64(capacity)
+	tb.rate = rate
+	tb.tokens = math.Min(tb.tokens, tb.capacity)
+}
+
+// Wait blocks until a token is available and takes it
+func (tb *TokenBucket) Wait(ctx context.Context) error {
+	for {
+		tb.mu.Lock()
+		tb.refill(time.Now())
+		if tb.tokens >= 1 {
+			tb.tokens--
+			tb.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - tb.tokens) / tb.rate * float64(time.Second))
+		tb.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// refill adds the tokens earned since the last refill. Must be called with tb.mu held.
+func (tb *TokenBucket) refill(now time.Time) {
+	tb.tokens += now.Sub(tb.last).Seconds() * tb.rate
+	if tb.tokens > tb.capacity {
+		tb.tokens = tb.capacity
+	}
+	tb.last = now
+}
@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketRefill(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		capacity int
+		rate     float64
+		tokens   float64
+		elapsed  time.Duration
+		want     float64
+	}{
+		{"earns tokens at the rate", 10, 2, 0, 1500 * time.Millisecond, 3},
+		{"keeps fractions", 10, 2, 0, 250 * time.Millisecond, 0.5},
+		{"capped at capacity", 10, 2, 9, 10 * time.Second, 10},
+		{"per minute", 60, 1, 0, 30 * time.Second, 30},
+		{"no time passed", 10, 2, 4, 0, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := NewTokenBucket(tt.capacity, tt.rate)
+			tb.tokens = tt.tokens
+			tb.last = start
+
+			tb.refill(start.Add(tt.elapsed))
+
+			if tb.tokens != tt.want {
+				t.Errorf("tokens = %v, want %v", tb.tokens, tt.want)
+			}
+			if !tb.last.Equal(start.Add(tt.elapsed)) {
+				t.Errorf("last refill = %v, want %v", tb.last, start.Add(tt.elapsed))
+			}
+		})
+	}
+}
+
+func TestTokenBucketAllow(t *testing.T) {
+	tb := PerMinute(3)
+
+	for i := 0; i < 3; i++ {
+		if !tb.Allow() {
+			t.Fatalf("Allow() #%d = false, want a burst of 3", i+1)
+		}
+	}
+	if tb.Allow() {
+		t.Error("Allow() on an empty bucket = true")
+	}
+
+	// A second of 3/min earns a twentieth of a token
+	tb.last = tb.last.Add(-time.Second)
+	if tb.Allow() {
+		t.Error("Allow() after a second = true, want false")
+	}
+
+	tb.last = tb.last.Add(-20 * time.Second)
+	if !tb.Allow() {
+		t.Error("Allow() after 20 seconds = false, want true")
+	}
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	tb := NewTokenBucket(1, 100)
+	if err := tb.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait on a full bucket: %v", err)
+	}
+
+	// The next token takes 10ms
+	start := time.Now()
+	if err := tb.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if waited := time.Since(start); waited < 5*time.Millisecond {
+		t.Errorf("Wait returned after %s, want about 10ms", waited)
+	}
+
+	slow := PerMinute(1)
+	slow.Allow()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := slow.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Wait on an empty bucket = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestTokenBucketReturn(t *testing.T) {
+	tb := PerMinute(2)
+	tb.Allow()
+	tb.Allow()
+
+	tb.Return()
+	if !tb.Allow() {
+		t.Error("Allow() after Return = false, want the returned token")
+	}
+
+	// A full bucket stays at capacity
+	full := PerMinute(2)
+	full.Return()
+	if full.tokens != 2 {
+		t.Errorf("tokens after Return on a full bucket = %v, want 2", full.tokens)
+	}
+}
+
+func TestTokenBucketSetLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		capacity   int
+		tokens     float64
+		newLimit   int
+		wantTokens float64
+		wantRate   float64
+	}{
+		{"raised limit keeps the tokens", 5, 2, 10, 2, 10.0 / 60},
+		{"lowered limit caps the tokens", 10, 8, 3, 3, 3.0 / 60},
+		{"empty bucket stays empty", 5, 0, 10, 0, 10.0 / 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := PerMinute(tt.capacity)
+			tb.tokens = tt.tokens
+
+			tb.SetLimit(tt.newLimit, float64(tt.newLimit)/60)
+
+			// Allow for the tokens earned while the test runs
+			if math.Abs(tb.tokens-tt.wantTokens) > 0.001 || tb.capacity != float64(tt.newLimit) || tb.rate != tt.wantRate {
+				t.Errorf("tokens %v, capacity %v, rate %v, want %v, %d, %v",
+					tb.tokens, tb.capacity, tb.rate, tt.wantTokens, tt.newLimit, tt.wantRate)
+			}
+		})
+	}
+}
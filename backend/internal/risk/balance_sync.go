@@ -0,0 +1,271 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/currency"
+	"github.com/crypto-trading-bot/internal/exchange"
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// balanceDust is the smallest balance difference counted as drift, the
+// precision of the balances table
+var balanceDust = decimal.New(1, -8)
+
+// balanceBaseline is the last synced set of exchange balances the fills
+// recorded since are applied to
+type balanceBaseline struct {
+	exchangeID uuid.UUID
+	totals     map[string]decimal.Decimal // Total per currency
+	fillCursor time.Time                  // created_at of the last fill applied
+	drifting   bool                       // The previous sync found drift
+}
+
+// SyncBalances stores the exchange's balances in the balances table and
+// compares them with the balances expected from the fills recorded since the
+// previous sync. Drift is only reported when it persists over two syncs, so
+// a fill the exchange has applied but the order manager has not recorded yet
+// is not mistaken for it; after reporting it, the exchange's balances become
+// the new baseline.
+func (rm *RiskManager) SyncBalances(ctx context.Context) error {
+	rm.balanceMu.Lock()
+	defer rm.balanceMu.Unlock()
+
+	var exchangeID uuid.UUID
+	err := rm.db.QueryRowContext(ctx, `
+		SELECT id FROM exchanges WHERE is_active = true LIMIT 1
+	`).Scan(&exchangeID)
+	if err != nil {
+		return fmt.Errorf("failed to get exchange ID: %w", err)
+	}
+
+	balances, err := rm.exchange.GetBalance(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get exchange balances: %w", err)
+	}
+
+	if err := rm.storeBalances(ctx, exchangeID, balances); err != nil {
+		return err
+	}
+
+	actual := make(map[string]decimal.Decimal, len(balances))
+	for code, balance := range balances {
+		actual[strings.ToUpper(code)] = balance.Available.Add(balance.Locked)
+	}
+
+	baseline := rm.balanceBaseline
+	if baseline == nil || baseline.exchangeID != exchangeID {
+		cursor, err := rm.lastFillTime(ctx, exchangeID)
+		if err != nil {
+			return err
+		}
+		rm.balanceBaseline = &balanceBaseline{exchangeID: exchangeID, totals: actual, fillCursor: cursor}
+		return nil
+	}
+
+	expected, cursor, err := rm.expectedBalances(ctx, baseline)
+	if err != nil {
+		return err
+	}
+
+	drift := balanceDrift(expected, actual, decimal.NewFromFloat(rm.config.BalanceDriftPercent))
+	if len(drift) > 0 && !baseline.drifting {
+		baseline.drifting = true
+		return nil
+	}
+
+	if len(drift) > 0 {
+		rm.logger.WithFields(logrus.Fields{
+			"exchange_id": exchangeID,
+			"drift":       drift,
+		}).Warn("Exchange balances differ from fills")
+
+		rm.logRiskEvent(ctx, uuid.Nil, "BALANCE_DRIFT",
+			fmt.Sprintf("Exchange balances differ from the balances expected from fills: %s", strings.Join(drift, ", ")),
+			"Balances resynced from exchange")
+	}
+
+	rm.balanceBaseline = &balanceBaseline{exchangeID: exchangeID, totals: actual, fillCursor: cursor}
+	return nil
+}
+
+// storeBalances upserts the exchange's balances and zeroes the currencies it
+// no longer reports
+func (rm *RiskManager) storeBalances(ctx context.Context, exchangeID uuid.UUID, balances map[string]*exchange.Balance) error {
+	reported := make(map[string]bool, len(balances))
+	for code, balance := range balances {
+		code = strings.ToUpper(code)
+		reported[code] = true
+
+		_, err := rm.db.ExecContext(ctx, `
+			INSERT INTO balances (exchange_id, currency, available, locked)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (exchange_id, currency) DO UPDATE
+			SET available = EXCLUDED.available, locked = EXCLUDED.locked
+		`, exchangeID, code, balance.Available, balance.Locked)
+		if err != nil {
+			return fmt.Errorf("failed to store %s balance: %w", code, err)
+		}
+	}
+
+	rows, err := rm.db.QueryContext(ctx, `
+		SELECT currency FROM balances WHERE exchange_id = $1 AND total <> 0
+	`, exchangeID)
+	if err != nil {
+		return fmt.Errorf("failed to get stored balances: %w", err)
+	}
+
+	var stale []string
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan balance: %w", err)
+		}
+		if !reported[code] {
+			stale = append(stale, code)
+		}
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, code := range stale {
+		_, err := rm.db.ExecContext(ctx, `
+			UPDATE balances SET available = 0, locked = 0
+			WHERE exchange_id = $1 AND currency = $2
+		`, exchangeID, code)
+		if err != nil {
+			return fmt.Errorf("failed to clear %s balance: %w", code, err)
+		}
+	}
+
+	return nil
+}
+
+// lastFillTime returns when the exchange's latest fill was recorded
+func (rm *RiskManager) lastFillTime(ctx context.Context, exchangeID uuid.UUID) (time.Time, error) {
+	var cursor time.Time
+	err := rm.db.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(created_at), NOW()) FROM fills WHERE exchange_id = $1
+	`, exchangeID).Scan(&cursor)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get last fill: %w", err)
+	}
+	return cursor, nil
+}
+
+// expectedBalances applies the fills recorded since the baseline to its
+// balances. It returns the expected balances and the time of the last fill
+// applied.
+func (rm *RiskManager) expectedBalances(ctx context.Context, baseline *balanceBaseline) (map[string]decimal.Decimal, time.Time, error) {
+	expected := make(map[string]decimal.Decimal, len(baseline.totals))
+	for code, total := range baseline.totals {
+		expected[code] = total
+	}
+	cursor := baseline.fillCursor
+
+	rows, err := rm.db.QueryContext(ctx, `
+		SELECT f.symbol, f.side, f.price, f.quantity, f.fee, f.fee_currency, o.intent, f.created_at
+		FROM fills f
+		JOIN orders o ON o.id = f.order_id
+		WHERE f.exchange_id = $1 AND f.created_at > $2
+		ORDER BY f.created_at
+	`, baseline.exchangeID, baseline.fillCursor)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get fills: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fill balanceFill
+		var createdAt time.Time
+		if err := rows.Scan(&fill.symbol, &fill.side, &fill.price, &fill.quantity, &fill.fee, &fill.feeCurrency, &fill.intent, &createdAt); err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to scan fill: %w", err)
+		}
+
+		applyFill(expected, fill)
+		cursor = createdAt
+	}
+
+	return expected, cursor, rows.Err()
+}
+
+// balanceFill is a recorded fill as it changes the exchange's balances
+type balanceFill struct {
+	symbol      string
+	side        models.OrderSide
+	price       decimal.Decimal
+	quantity    decimal.Decimal
+	fee         decimal.Decimal
+	feeCurrency string
+	intent      models.PositionIntent
+}
+
+// applyFill applies a fill's trade and fee to the expected balances
+func applyFill(expected map[string]decimal.Decimal, fill balanceFill) {
+	base, quote := currency.Split(fill.symbol)
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+	notional := fill.price.Mul(fill.quantity)
+
+	// A short borrows its base currency as a margin loan, which
+	// leaves the base balance unchanged
+	short := fill.intent == models.PositionIntentOpenShort || fill.intent == models.PositionIntentCloseShort
+
+	if fill.side == models.OrderSideBuy {
+		if !short {
+			expected[base] = expected[base].Add(fill.quantity)
+		}
+		expected[quote] = expected[quote].Sub(notional)
+	} else {
+		if !short {
+			expected[base] = expected[base].Sub(fill.quantity)
+		}
+		expected[quote] = expected[quote].Add(notional)
+	}
+
+	if fill.feeCurrency != "" {
+		feeCurrency := strings.ToUpper(fill.feeCurrency)
+		expected[feeCurrency] = expected[feeCurrency].Sub(fill.fee)
+	}
+}
+
+// balanceDrift describes the currencies whose balance differs from the
+// expected balance by more than the tolerance, as a percentage of the
+// expected balance
+func balanceDrift(expected, actual map[string]decimal.Decimal, tolerancePercent decimal.Decimal) []string {
+	codes := make(map[string]bool, len(expected)+len(actual))
+	for code := range expected {
+		codes[code] = true
+	}
+	for code := range actual {
+		codes[code] = true
+	}
+
+	var drift []string
+	for code := range codes {
+		difference := actual[code].Sub(expected[code])
+
+		tolerance := expected[code].Abs().Mul(tolerancePercent).Div(decimal.NewFromInt(100))
+		if tolerance.LessThan(balanceDust) {
+			tolerance = balanceDust
+		}
+
+		if difference.Abs().GreaterThan(tolerance) {
+			drift = append(drift, fmt.Sprintf("%s expected %s, exchange %s",
+				code, expected[code].Round(8).String(), actual[code].Round(8).String()))
+		}
+	}
+
+	sort.Strings(drift)
+	return drift
+}
@@ -0,0 +1,142 @@
+package risk
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+func balances(pairs ...string) map[string]decimal.Decimal {
+	m := make(map[string]decimal.Decimal, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		m[pairs[i]] = decimal.RequireFromString(pairs[i+1])
+	}
+	return m
+}
+
+func TestApplyFill(t *testing.T) {
+	fill := func(side models.OrderSide, intent models.PositionIntent, fee, feeCurrency string) balanceFill {
+		return balanceFill{
+			symbol:      "btc-usd",
+			side:        side,
+			price:       decimal.RequireFromString("50000"),
+			quantity:    decimal.RequireFromString("0.1"),
+			fee:         decimal.RequireFromString(fee),
+			feeCurrency: feeCurrency,
+			intent:      intent,
+		}
+	}
+
+	tests := []struct {
+		name string
+		fill balanceFill
+		want map[string]decimal.Decimal
+	}{
+		{"buy", fill(models.OrderSideBuy, "", "0", ""), balances("BTC", "1.1", "USD", "5000")},
+		{"sell", fill(models.OrderSideSell, "", "0", ""), balances("BTC", "0.9", "USD", "15000")},
+		{"open long", fill(models.OrderSideBuy, models.PositionIntentOpenLong, "0", ""), balances("BTC", "1.1", "USD", "5000")},
+		{"open short leaves the base", fill(models.OrderSideSell, models.PositionIntentOpenShort, "0", ""), balances("BTC", "1", "USD", "15000")},
+		{"close short leaves the base", fill(models.OrderSideBuy, models.PositionIntentCloseShort, "0", ""), balances("BTC", "1", "USD", "5000")},
+		{"fee in the quote", fill(models.OrderSideBuy, "", "12.5", "usd"), balances("BTC", "1.1", "USD", "4987.5")},
+		{"fee in the base", fill(models.OrderSideBuy, "", "0.0001", "BTC"), balances("BTC", "1.0999", "USD", "5000")},
+		{"fee in another currency", fill(models.OrderSideSell, "", "0.5", "BNB"), balances("BTC", "0.9", "USD", "15000", "BNB", "-0.5")},
+		{"fee without a currency", fill(models.OrderSideSell, "", "7", ""), balances("BTC", "0.9", "USD", "15000")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := balances("BTC", "1", "USD", "10000")
+			applyFill(expected, tt.fill)
+
+			if len(expected) != len(tt.want) {
+				t.Fatalf("balances = %v, want %v", expected, tt.want)
+			}
+			for code, want := range tt.want {
+				if !expected[code].Equal(want) {
+					t.Errorf("%s = %s, want %s", code, expected[code], want)
+				}
+			}
+		})
+	}
+}
+
+func TestBalanceDrift(t *testing.T) {
+	tests := []struct {
+		name      string
+		expected  map[string]decimal.Decimal
+		actual    map[string]decimal.Decimal
+		tolerance string
+		want      []string
+	}{
+		{
+			name:      "matching",
+			expected:  balances("BTC", "1", "USD", "10000"),
+			actual:    balances("BTC", "1", "USD", "10000"),
+			tolerance: "0.5",
+		},
+		{
+			name:      "within the tolerance",
+			expected:  balances("USD", "10000"),
+			actual:    balances("USD", "10049"),
+			tolerance: "0.5",
+		},
+		{
+			name:      "beyond the tolerance",
+			expected:  balances("USD", "10000"),
+			actual:    balances("USD", "10051"),
+			tolerance: "0.5",
+			want:      []string{"USD expected 10000, exchange 10051"},
+		},
+		{
+			name:      "dust without a tolerance",
+			expected:  balances("BTC", "1"),
+			actual:    balances("BTC", "1.00000001"),
+			tolerance: "0",
+		},
+		{
+			name:      "beyond dust without a tolerance",
+			expected:  balances("BTC", "1"),
+			actual:    balances("BTC", "1.00000002"),
+			tolerance: "0",
+			want:      []string{"BTC expected 1, exchange 1.00000002"},
+		},
+		{
+			name:      "dust on an empty balance",
+			expected:  balances(),
+			actual:    balances("ETH", "0.00000001"),
+			tolerance: "1",
+		},
+		{
+			name:      "unexpected currency",
+			expected:  balances("USD", "100"),
+			actual:    balances("USD", "100", "ETH", "0.5"),
+			tolerance: "1",
+			want:      []string{"ETH expected 0, exchange 0.5"},
+		},
+		{
+			name:      "missing currency",
+			expected:  balances("USD", "100", "SOL", "2"),
+			actual:    balances("USD", "100"),
+			tolerance: "1",
+			want:      []string{"SOL expected 2, exchange 0"},
+		},
+		{
+			name:      "sorted by currency",
+			expected:  balances("USD", "100", "BTC", "1"),
+			actual:    balances("USD", "90", "BTC", "2"),
+			tolerance: "1",
+			want:      []string{"BTC expected 1, exchange 2", "USD expected 100, exchange 90"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := balanceDrift(tt.expected, tt.actual, decimal.RequireFromString(tt.tolerance))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("balanceDrift = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
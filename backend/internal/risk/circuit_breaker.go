@@ -0,0 +1,320 @@
+package risk
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/config"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// breakerTrip is a circuit breaker rule that pauses a strategy
+type breakerTrip struct {
+	eventType   string
+	description string
+	until       time.Time
+}
+
+// CheckCircuitBreakers pauses strategies that tripped a breaker and re-arms
+// those whose pause has expired
+func (rm *RiskManager) CheckCircuitBreakers(ctx context.Context) error {
+	rows, err := rm.db.QueryContext(ctx, `SELECT id FROM strategies`)
+	if err != nil {
+		return fmt.Errorf("failed to get strategies: %w", err)
+	}
+
+	var strategyIDs []uuid.UUID
+	for rows.Next() {
+		var strategyID uuid.UUID
+		if err := rows.Scan(&strategyID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan strategy: %w", err)
+		}
+		strategyIDs = append(strategyIDs, strategyID)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, strategyID := range strategyIDs {
+		if _, err := rm.updateStrategyPause(ctx, strategyID); err != nil {
+			rm.logger.WithError(err).WithField("strategy_id", strategyID).Error("Failed to check circuit breakers")
+		}
+	}
+
+	return nil
+}
+
+// checkStrategyPause returns an error if the strategy is paused by a breaker
+func (rm *RiskManager) checkStrategyPause(ctx context.Context, strategyID uuid.UUID) error {
+	pausedUntil, err := rm.updateStrategyPause(ctx, strategyID)
+	if err != nil {
+		return err
+	}
+
+	if pausedUntil != nil {
+		return fmt.Errorf("strategy is paused until %s", pausedUntil.In(rm.tradingDayLocation).Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// updateStrategyPause re-arms an expired pause, evaluates the breakers and
+// extends the pause if one tripped. It returns when the strategy is paused
+// until, or nil if it isn't paused.
+func (rm *RiskManager) updateStrategyPause(ctx context.Context, strategyID uuid.UUID) (*time.Time, error) {
+	var pausedUntil sql.NullTime
+	var pauseReason sql.NullString
+	err := rm.db.QueryRowContext(ctx, `
+		SELECT paused_until, pause_reason FROM strategies WHERE id = $1
+	`, strategyID).Scan(&pausedUntil, &pauseReason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get strategy pause: %w", err)
+	}
+
+	now := time.Now()
+
+	// Re-arm once the pause expires
+	if pausedUntil.Valid && !pausedUntil.Time.After(now) {
+		if _, err := rm.db.ExecContext(ctx, `
+			UPDATE strategies SET paused_until = NULL, pause_reason = NULL WHERE id = $1
+		`, strategyID); err != nil {
+			return nil, fmt.Errorf("failed to re-arm strategy: %w", err)
+		}
+
+		rm.logRiskEvent(ctx, strategyID, "STRATEGY_REARMED",
+			fmt.Sprintf("Pause expired: %s", pauseReason.String), "Strategy re-armed")
+		pausedUntil.Valid = false
+	}
+
+	trips, err := rm.evaluateBreakers(ctx, strategyID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, trip := range trips {
+		if !trip.until.After(now) || (pausedUntil.Valid && !trip.until.After(pausedUntil.Time)) {
+			continue // Already paused at least this long
+		}
+
+		if _, err := rm.db.ExecContext(ctx, `
+			UPDATE strategies SET paused_until = $2, pause_reason = $3 WHERE id = $1
+		`, strategyID, trip.until, trip.description); err != nil {
+			return nil, fmt.Errorf("failed to pause strategy: %w", err)
+		}
+
+		if trip.eventType == "DRAWDOWN_BREAKER" {
+			// Measure drawdown from a fresh peak once re-armed
+			if _, err := rm.db.ExecContext(ctx, `
+				UPDATE strategies SET drawdown_reset_at = $2 WHERE id = $1
+			`, strategyID, now); err != nil {
+				return nil, fmt.Errorf("failed to reset drawdown: %w", err)
+			}
+		}
+
+		rm.logRiskEvent(ctx, strategyID, trip.eventType, trip.description,
+			fmt.Sprintf("Strategy paused until %s", trip.until.In(rm.tradingDayLocation).Format(time.RFC3339)))
+
+		rm.logger.WithFields(logrus.Fields{
+			"strategy_id": strategyID,
+			"breaker":     trip.eventType,
+			"until":       trip.until,
+		}).Warn("Strategy paused by circuit breaker")
+
+		pausedUntil = sql.NullTime{Time: trip.until, Valid: true}
+	}
+
+	if pausedUntil.Valid {
+		return &pausedUntil.Time, nil
+	}
+	return nil, nil
+}
+
+// evaluateBreakers returns the breakers tripped by the strategy's closed trades
+func (rm *RiskManager) evaluateBreakers(ctx context.Context, strategyID uuid.UUID, now time.Time) ([]breakerTrip, error) {
+	var trips []breakerTrip
+	limits := rm.Limits(strategyID)
+
+	// Daily loss: realized PnL of the trading day, paused until the next one
+	startOfDay := rm.startOfTradingDay(now)
+
+	var dailyPnL decimal.Decimal
+	err := rm.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(pnl), 0)
+		FROM trades
+		WHERE strategy_id = $1 AND exit_time >= $2
+	`, strategyID, startOfDay).Scan(&dailyPnL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily P&L: %w", err)
+	}
+
+	// The daily loss and drawdown breakers are relative to equity; without
+	// it they are skipped until the next evaluation rather than misjudged
+	equity, equityErr := rm.Equity(ctx)
+	if equityErr != nil {
+		rm.logger.WithError(equityErr).WithField("strategy_id", strategyID).
+			Warn("Skipping daily loss and drawdown breakers")
+	}
+
+	if equityErr == nil {
+		lossLimit := equity.Mul(decimal.NewFromFloat(limits.DailyLossLimitPercent)).Div(decimal.NewFromInt(100))
+		if dailyPnL.LessThan(lossLimit.Neg()) {
+			trips = append(trips, breakerTrip{
+				eventType: "DAILY_LOSS_LIMIT",
+				description: fmt.Sprintf("Daily loss limit exceeded: %.2f (limit: %.2f)",
+					dailyPnL.InexactFloat64(), lossLimit.InexactFloat64()),
+				until: startOfDay.AddDate(0, 0, 1),
+			})
+		}
+	}
+
+	// Loss streak: cooldown after the last of N consecutive losing trades
+	if limits.LossStreakLength > 0 {
+		trip, err := rm.evaluateLossStreak(ctx, strategyID, limits)
+		if err != nil {
+			return nil, err
+		}
+		if trip != nil {
+			trips = append(trips, *trip)
+		}
+	}
+
+	// Drawdown: peak-to-trough of realized equity since the last trip
+	if limits.MaxDrawdownPercent > 0 && equityErr == nil {
+		trip, err := rm.evaluateDrawdown(ctx, strategyID, limits, equity, now)
+		if err != nil {
+			return nil, err
+		}
+		if trip != nil {
+			trips = append(trips, *trip)
+		}
+	}
+
+	return trips, nil
+}
+
+// evaluateLossStreak trips if the latest closed trades are all losses
+func (rm *RiskManager) evaluateLossStreak(
+	ctx context.Context,
+	strategyID uuid.UUID,
+	limits *config.RiskConfig,
+) (*breakerTrip, error) {
+	rows, err := rm.db.QueryContext(ctx, `
+		SELECT pnl, exit_time
+		FROM trades
+		WHERE strategy_id = $1 AND exit_time IS NOT NULL
+		ORDER BY exit_time DESC
+		LIMIT $2
+	`, strategyID, limits.LossStreakLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent trades: %w", err)
+	}
+	defer rows.Close()
+
+	losses := 0
+	var lastLoss time.Time
+	for rows.Next() {
+		var pnl decimal.NullDecimal
+		var exitTime time.Time
+		if err := rows.Scan(&pnl, &exitTime); err != nil {
+			return nil, fmt.Errorf("failed to scan trade: %w", err)
+		}
+		if !pnl.Valid || !pnl.Decimal.IsNegative() {
+			return nil, nil
+		}
+		if losses == 0 {
+			lastLoss = exitTime
+		}
+		losses++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if losses < limits.LossStreakLength {
+		return nil, nil
+	}
+
+	return &breakerTrip{
+		eventType:   "LOSS_STREAK_COOLDOWN",
+		description: fmt.Sprintf("%d consecutive losing trades", losses),
+		until:       lastLoss.Add(time.Duration(limits.LossStreakCooldownMinutes) * time.Minute),
+	}, nil
+}
+
+// evaluateDrawdown trips if realized PnL fell too far from its peak,
+// relative to equity at the peak
+func (rm *RiskManager) evaluateDrawdown(
+	ctx context.Context,
+	strategyID uuid.UUID,
+	limits *config.RiskConfig,
+	equity decimal.Decimal,
+	now time.Time,
+) (*breakerTrip, error) {
+	rows, err := rm.db.QueryContext(ctx, `
+		SELECT t.pnl
+		FROM trades t
+		JOIN strategies s ON s.id = t.strategy_id
+		WHERE t.strategy_id = $1
+		  AND t.exit_time IS NOT NULL
+		  AND t.pnl IS NOT NULL
+		  AND (s.drawdown_reset_at IS NULL OR t.exit_time > s.drawdown_reset_at)
+		ORDER BY t.exit_time
+	`, strategyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get closed trades: %w", err)
+	}
+	defer rows.Close()
+
+	// Equity now includes all realized PnL; rebuild the curve from its start
+	var pnls []decimal.Decimal
+	cumulative := decimal.Zero
+	for rows.Next() {
+		var pnl decimal.Decimal
+		if err := rows.Scan(&pnl); err != nil {
+			return nil, fmt.Errorf("failed to scan trade: %w", err)
+		}
+		pnls = append(pnls, pnl)
+		cumulative = cumulative.Add(pnl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	current := equity.Sub(cumulative)
+	peak := current
+	for _, pnl := range pnls {
+		current = current.Add(pnl)
+		if current.GreaterThan(peak) {
+			peak = current
+		}
+	}
+
+	// Drawdown from the peak to the current trough; a recovered drawdown doesn't trip
+	if !peak.IsPositive() {
+		return nil, nil
+	}
+	drawdown := peak.Sub(current).Div(peak).Mul(decimal.NewFromInt(100))
+	if drawdown.LessThanOrEqual(decimal.NewFromFloat(limits.MaxDrawdownPercent)) {
+		return nil, nil
+	}
+
+	return &breakerTrip{
+		eventType: "DRAWDOWN_BREAKER",
+		description: fmt.Sprintf("Drawdown %.2f%% exceeds limit %.2f%%",
+			drawdown.InexactFloat64(), limits.MaxDrawdownPercent),
+		until: now.Add(time.Duration(limits.DrawdownCooldownHours) * time.Hour),
+	}, nil
+}
+
+// startOfTradingDay returns the start of the trading day containing t
+func (rm *RiskManager) startOfTradingDay(t time.Time) time.Time {
+	local := t.In(rm.tradingDayLocation)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, rm.tradingDayLocation)
+}
@@ -0,0 +1,412 @@
+package risk
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/config"
+	"github.com/google/uuid"
+)
+
+// riskLimitsConfigKey is the system_config key holding global limit overrides
+const riskLimitsConfigKey = "risk_limits"
+
+// Errors returned by UpdateLimits
+var (
+	ErrInvalidLimits    = errors.New("invalid risk limits")
+	ErrStrategyNotFound = errors.New("strategy not found")
+)
+
+// Limits are the risk limits that can be changed at runtime. Overrides are
+// stored as partial JSON documents: globally in system_config on top of the
+// environment, and per strategy in strategies.risk_limits on top of the
+// global limits.
+type Limits struct {
+	MaxPositionSizeUSD         float64 `json:"max_position_size_usd"`
+	MaxOpenPositions           int     `json:"max_open_positions"`
+	DailyLossLimitPercent      float64 `json:"daily_loss_limit_percent"`
+	StopLossPercent            float64 `json:"stop_loss_percent"`
+	MaxHoldTimeHours           int     `json:"max_hold_time_hours"`
+	MaxRiskPerTradePercent     float64 `json:"max_risk_per_trade_percent"`
+	MaxDrawdownPercent         float64 `json:"max_drawdown_percent"`
+	DrawdownCooldownHours      int     `json:"drawdown_cooldown_hours"`
+	LossStreakLength           int     `json:"loss_streak_length"`
+	LossStreakCooldownMinutes  int     `json:"loss_streak_cooldown_minutes"`
+	MaxOrdersPerMinuteStrategy int     `json:"max_orders_per_minute_strategy"`
+	MaxPositionNotionalUSD     float64 `json:"max_position_notional_usd"`
+
+	// Global only
+	MinBalanceUSD              float64 `json:"min_balance_usd"`
+	MaxPriceDeviationPercent   float64 `json:"max_price_deviation_percent"`
+	MaxPriceAgeSeconds         int     `json:"max_price_age_seconds"`
+	MaxGrossExposureUSD        float64 `json:"max_gross_exposure_usd"`
+	MaxSymbolExposureUSD       float64 `json:"max_symbol_exposure_usd"`
+	MaxAssetSharePercent       float64 `json:"max_asset_share_percent"`
+	MaxCorrelatedExposureUSD   float64 `json:"max_correlated_exposure_usd"`
+	CorrelationThreshold       float64 `json:"correlation_threshold"`
+	CorrelationLookback        int     `json:"correlation_lookback"`
+	MaxOrdersPerMinuteExchange int     `json:"max_orders_per_minute_exchange"`
+	MaxVaRUSD                  float64 `json:"max_var_usd"`
+	VaRMethod                  string  `json:"var_method"`
+	VaRConfidence              float64 `json:"var_confidence"`
+	VaRHorizonMinutes          int     `json:"var_horizon_minutes"`
+	VaRLookback                int     `json:"var_lookback"`
+}
+
+// globalOnlyLimits are the limits that apply across strategies and can't be
+// overridden per strategy
+var globalOnlyLimits = map[string]bool{
+	"min_balance_usd":                true,
+	"max_price_deviation_percent":    true,
+	"max_price_age_seconds":          true,
+	"max_gross_exposure_usd":         true,
+	"max_symbol_exposure_usd":        true,
+	"max_asset_share_percent":        true,
+	"max_correlated_exposure_usd":    true,
+	"correlation_threshold":          true,
+	"correlation_lookback":           true,
+	"max_orders_per_minute_exchange": true,
+	"max_var_usd":                    true,
+	"var_method":                     true,
+	"var_confidence":                 true,
+	"var_horizon_minutes":            true,
+	"var_lookback":                   true,
+}
+
+// LimitChange is an audited change of a single limit
+type LimitChange struct {
+	StrategyID *uuid.UUID      `json:"strategy_id,omitempty"` // Nil for global limits
+	Field      string          `json:"field"`
+	OldValue   json.RawMessage `json:"old_value"`
+	NewValue   json.RawMessage `json:"new_value"`
+	ChangedBy  string          `json:"changed_by"`
+	ChangedAt  time.Time       `json:"changed_at"`
+}
+
+// LimitsFromConfig returns the limits set in the configuration
+func LimitsFromConfig(cfg *config.RiskConfig) Limits {
+	return Limits{
+		MaxPositionSizeUSD:         cfg.MaxPositionSizeUSD,
+		MaxOpenPositions:           cfg.MaxOpenPositions,
+		DailyLossLimitPercent:      cfg.DailyLossLimitPercent,
+		StopLossPercent:            cfg.StopLossPercent,
+		MaxHoldTimeHours:           cfg.MaxHoldTimeHours,
+		MaxRiskPerTradePercent:     cfg.MaxRiskPerTradePercent,
+		MaxDrawdownPercent:         cfg.MaxDrawdownPercent,
+		DrawdownCooldownHours:      cfg.DrawdownCooldownHours,
+		LossStreakLength:           cfg.LossStreakLength,
+		LossStreakCooldownMinutes:  cfg.LossStreakCooldownMinutes,
+		MaxOrdersPerMinuteStrategy: cfg.MaxOrdersPerMinuteStrategy,
+		MaxPositionNotionalUSD:     cfg.MaxPositionNotionalUSD,
+		MinBalanceUSD:              cfg.MinBalanceUSD,
+		MaxPriceDeviationPercent:   cfg.MaxPriceDeviationPercent,
+		MaxPriceAgeSeconds:         cfg.MaxPriceAgeSeconds,
+		MaxGrossExposureUSD:        cfg.MaxGrossExposureUSD,
+		MaxSymbolExposureUSD:       cfg.MaxSymbolExposureUSD,
+		MaxAssetSharePercent:       cfg.MaxAssetSharePercent,
+		MaxCorrelatedExposureUSD:   cfg.MaxCorrelatedExposureUSD,
+		CorrelationThreshold:       cfg.CorrelationThreshold,
+		CorrelationLookback:        cfg.CorrelationLookback,
+		MaxOrdersPerMinuteExchange: cfg.MaxOrdersPerMinuteExchange,
+		MaxVaRUSD:                  cfg.MaxVaRUSD,
+		VaRMethod:                  cfg.VaRMethod,
+		VaRConfidence:              cfg.VaRConfidence,
+		VaRHorizonMinutes:          cfg.VaRHorizonMinutes,
+		VaRLookback:                cfg.VaRLookback,
+	}
+}
+
+// Apply returns a copy of the configuration with the limits set
+func (l Limits) Apply(cfg *config.RiskConfig) *config.RiskConfig {
+	updated := *cfg
+	updated.MaxPositionSizeUSD = l.MaxPositionSizeUSD
+	updated.MaxOpenPositions = l.MaxOpenPositions
+	updated.DailyLossLimitPercent = l.DailyLossLimitPercent
+	updated.StopLossPercent = l.StopLossPercent
+	updated.MaxHoldTimeHours = l.MaxHoldTimeHours
+	updated.MaxRiskPerTradePercent = l.MaxRiskPerTradePercent
+	updated.MaxDrawdownPercent = l.MaxDrawdownPercent
+	updated.DrawdownCooldownHours = l.DrawdownCooldownHours
+	updated.LossStreakLength = l.LossStreakLength
+	updated.LossStreakCooldownMinutes = l.LossStreakCooldownMinutes
+	updated.MaxOrdersPerMinuteStrategy = l.MaxOrdersPerMinuteStrategy
+	updated.MaxPositionNotionalUSD = l.MaxPositionNotionalUSD
+	updated.MinBalanceUSD = l.MinBalanceUSD
+	updated.MaxPriceDeviationPercent = l.MaxPriceDeviationPercent
+	updated.MaxPriceAgeSeconds = l.MaxPriceAgeSeconds
+	updated.MaxGrossExposureUSD = l.MaxGrossExposureUSD
+	updated.MaxSymbolExposureUSD = l.MaxSymbolExposureUSD
+	updated.MaxAssetSharePercent = l.MaxAssetSharePercent
+	updated.MaxCorrelatedExposureUSD = l.MaxCorrelatedExposureUSD
+	updated.CorrelationThreshold = l.CorrelationThreshold
+	updated.CorrelationLookback = l.CorrelationLookback
+	updated.MaxOrdersPerMinuteExchange = l.MaxOrdersPerMinuteExchange
+	updated.MaxVaRUSD = l.MaxVaRUSD
+	updated.VaRMethod = l.VaRMethod
+	updated.VaRConfidence = l.VaRConfidence
+	updated.VaRHorizonMinutes = l.VaRHorizonMinutes
+	updated.VaRLookback = l.VaRLookback
+	return &updated
+}
+
+// WithOverrides returns a copy of the limits with the fields present in
+// data replaced. Unknown fields are rejected.
+func (l Limits) WithOverrides(data []byte) (Limits, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return l, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	updated := l
+	if err := decoder.Decode(&updated); err != nil {
+		return l, fmt.Errorf("%w: %v", ErrInvalidLimits, err)
+	}
+
+	return updated, nil
+}
+
+// fields returns the JSON value of every limit by name
+func (l Limits) fields() map[string]json.RawMessage {
+	data, _ := json.Marshal(l)
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+// LimitsSnapshot holds the effective global limits and the overrides of
+// every strategy that has any
+type LimitsSnapshot struct {
+	Global    Limits                        `json:"global"`
+	Overrides map[uuid.UUID]json.RawMessage `json:"strategy_overrides"`
+}
+
+// LoadLimits reads the global limits and per-strategy overrides
+func LoadLimits(ctx context.Context, db *sql.DB, base *config.RiskConfig) (*LimitsSnapshot, error) {
+	var globalOverrides []byte
+	err := db.QueryRowContext(ctx, `
+		SELECT value FROM system_config WHERE key = $1
+	`, riskLimitsConfigKey).Scan(&globalOverrides)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get risk limits: %w", err)
+	}
+
+	global, err := LimitsFromConfig(base).WithOverrides(globalOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, risk_limits FROM strategies WHERE risk_limits IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get strategy risk limits: %w", err)
+	}
+	defer rows.Close()
+
+	snapshot := &LimitsSnapshot{
+		Global:    global,
+		Overrides: make(map[uuid.UUID]json.RawMessage),
+	}
+	for rows.Next() {
+		var strategyID uuid.UUID
+		var overrides []byte
+		if err := rows.Scan(&strategyID, &overrides); err != nil {
+			return nil, fmt.Errorf("failed to scan strategy risk limits: %w", err)
+		}
+		snapshot.Overrides[strategyID] = overrides
+	}
+
+	return snapshot, rows.Err()
+}
+
+// StrategyLimits returns the effective limits of a strategy
+func (s *LimitsSnapshot) StrategyLimits(strategyID uuid.UUID) (Limits, error) {
+	return s.Global.WithOverrides(s.Overrides[strategyID])
+}
+
+// UpdateLimits merges changes into the global limits, or into a strategy's
+// overrides if strategyID is set, and removes the overrides named in reset.
+// The result is validated and every changed limit is recorded in the audit trail.
+func UpdateLimits(
+	ctx context.Context,
+	db *sql.DB,
+	base *config.RiskConfig,
+	strategyID *uuid.UUID,
+	changes map[string]json.RawMessage,
+	reset []string,
+	changedBy string,
+) ([]LimitChange, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the current overrides
+	var globalJSON, strategyJSON []byte
+	err = tx.QueryRowContext(ctx, `
+		SELECT value FROM system_config WHERE key = $1 FOR UPDATE
+	`, riskLimitsConfigKey).Scan(&globalJSON)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get risk limits: %w", err)
+	}
+
+	if strategyID != nil {
+		err = tx.QueryRowContext(ctx, `
+			SELECT risk_limits FROM strategies WHERE id = $1 FOR UPDATE
+		`, *strategyID).Scan(&strategyJSON)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrStrategyNotFound, *strategyID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get strategy risk limits: %w", err)
+		}
+	}
+
+	global, err := LimitsFromConfig(base).WithOverrides(globalJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	// The overrides being edited and the limits they apply on top of
+	target, parent := globalJSON, LimitsFromConfig(base)
+	if strategyID != nil {
+		target, parent = strategyJSON, global
+	}
+
+	overrides := map[string]json.RawMessage{}
+	if len(target) > 0 {
+		if err := json.Unmarshal(target, &overrides); err != nil {
+			return nil, fmt.Errorf("invalid stored risk limits: %w", err)
+		}
+	}
+
+	before, err := parent.WithOverrides(target)
+	if err != nil {
+		return nil, err
+	}
+
+	for field, value := range changes {
+		if strategyID != nil && globalOnlyLimits[field] {
+			return nil, fmt.Errorf("%w: %s can only be set globally", ErrInvalidLimits, field)
+		}
+		overrides[field] = value
+	}
+	for _, field := range reset {
+		delete(overrides, field)
+	}
+
+	updatedJSON, err := json.Marshal(overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := parent.WithOverrides(updatedJSON)
+	if err != nil {
+		return nil, err
+	}
+	if err := after.Apply(base).Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLimits, err)
+	}
+
+	// Store the overrides
+	if strategyID != nil {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE strategies SET risk_limits = $2 WHERE id = $1
+		`, *strategyID, updatedJSON)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO system_config (key, value, updated_at)
+			VALUES ($1, $2, NOW())
+			ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()
+		`, riskLimitsConfigKey, updatedJSON)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save risk limits: %w", err)
+	}
+
+	// Record each changed limit
+	oldFields, newFields := before.fields(), after.fields()
+
+	names := make([]string, 0, len(newFields))
+	for name := range newFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	var recorded []LimitChange
+	for _, name := range names {
+		if bytes.Equal(oldFields[name], newFields[name]) {
+			continue
+		}
+
+		change := LimitChange{
+			StrategyID: strategyID,
+			Field:      name,
+			OldValue:   oldFields[name],
+			NewValue:   newFields[name],
+			ChangedBy:  changedBy,
+			ChangedAt:  now,
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO risk_limit_changes (strategy_id, field, old_value, new_value, changed_by, changed_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, strategyID, change.Field, []byte(change.OldValue), []byte(change.NewValue), change.ChangedBy, change.ChangedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record risk limit change: %w", err)
+		}
+
+		recorded = append(recorded, change)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit risk limits: %w", err)
+	}
+
+	return recorded, nil
+}
+
+// ReloadLimits loads the runtime limits from the database
+func (rm *RiskManager) ReloadLimits(ctx context.Context) error {
+	snapshot, err := LoadLimits(ctx, rm.db, rm.config)
+	if err != nil {
+		return err
+	}
+
+	rm.limitsMu.Lock()
+	rm.limits = snapshot
+	rm.limitsMu.Unlock()
+
+	rm.logger.WithField("strategy_overrides", len(snapshot.Overrides)).Info("Risk limits loaded")
+	return nil
+}
+
+// Limits returns the effective risk configuration of a strategy, or the
+// global one for uuid.Nil
+func (rm *RiskManager) Limits(strategyID uuid.UUID) *config.RiskConfig {
+	rm.limitsMu.RLock()
+	snapshot := rm.limits
+	rm.limitsMu.RUnlock()
+
+	if snapshot == nil {
+		return rm.config
+	}
+
+	limits, err := snapshot.StrategyLimits(strategyID)
+	if err != nil {
+		rm.logger.WithError(err).WithField("strategy_id", strategyID).Error("Invalid strategy risk limits, using global limits")
+		limits = snapshot.Global
+	}
+
+	return limits.Apply(rm.config)
+}
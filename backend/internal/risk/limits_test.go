@@ -0,0 +1,59 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/crypto-trading-bot/internal/config"
+)
+
+func TestLimitsWithOverrides(t *testing.T) {
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	base := LimitsFromConfig(&cfg.Risk)
+
+	if applied := base.Apply(&cfg.Risk); *applied != cfg.Risk {
+		t.Errorf("Apply(LimitsFromConfig) = %+v, want the configuration %+v", *applied, cfg.Risk)
+	}
+
+	limits, err := base.WithOverrides([]byte(`{
+		"correlation_threshold": 0.9,
+		"correlation_lookback": 60,
+		"var_method": "parametric",
+		"var_confidence": 0.95,
+		"var_horizon_minutes": 15,
+		"var_lookback": 720
+	}`))
+	if err != nil {
+		t.Fatalf("WithOverrides: %v", err)
+	}
+
+	applied := limits.Apply(&cfg.Risk)
+	if applied.CorrelationThreshold != 0.9 || applied.CorrelationLookback != 60 {
+		t.Errorf("correlation threshold %v over %d candles, want 0.9 over 60",
+			applied.CorrelationThreshold, applied.CorrelationLookback)
+	}
+	if applied.VaRMethod != VaRMethodParametric || applied.VaRConfidence != 0.95 ||
+		applied.VaRHorizonMinutes != 15 || applied.VaRLookback != 720 {
+		t.Errorf("VaR %s at %v over %d minutes from %d candles, want parametric at 0.95 over 15 from 720",
+			applied.VaRMethod, applied.VaRConfidence, applied.VaRHorizonMinutes, applied.VaRLookback)
+	}
+	if applied.MaxPositionSizeUSD != cfg.Risk.MaxPositionSizeUSD {
+		t.Errorf("max position size = %v, want %v", applied.MaxPositionSizeUSD, cfg.Risk.MaxPositionSizeUSD)
+	}
+
+	invalid, err := base.WithOverrides([]byte(`{"var_method": "monte carlo"}`))
+	if err != nil {
+		t.Fatalf("WithOverrides: %v", err)
+	}
+	if err := invalid.Apply(&cfg.Risk).Validate(); err == nil {
+		t.Error("Validate() of an unknown VaR method succeeded")
+	}
+
+	for _, field := range []string{"correlation_threshold", "correlation_lookback", "var_method", "var_confidence", "var_horizon_minutes", "var_lookback"} {
+		if !globalOnlyLimits[field] {
+			t.Errorf("%s can be overridden per strategy", field)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/crypto-trading-bot/internal/config"
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// minCorrelationSamples is the number of returns needed to trust a correlation
+const minCorrelationSamples = 30
+
+// exposure is the notional of an open trade
+type exposure struct {
+	symbol   string
+	side     models.TradeSide
+	notional decimal.Decimal
+}
+
+// checkPortfolioLimits validates a new position against the portfolio-wide
+// exposure limits. A limit of zero is disabled.
+func (rm *RiskManager) checkPortfolioLimits(ctx context.Context, signal *models.TradeSignal, notional decimal.Decimal) error {
+	exposures, err := rm.loadExposures(ctx)
+	if err != nil {
+		return err
+	}
+
+	side := signal.Intent.TradeSide()
+	limits := rm.Limits(signal.StrategyID)
+
+	// Total gross exposure
+	if limits.MaxGrossExposureUSD > 0 {
+		gross := notional
+		for _, e := range exposures {
+			gross = gross.Add(e.notional)
+		}
+		if limit := decimal.NewFromFloat(limits.MaxGrossExposureUSD); gross.GreaterThan(limit) {
+			err := fmt.Errorf("gross exposure %.2f would exceed limit %.2f",
+				gross.InexactFloat64(), limits.MaxGrossExposureUSD)
+			rm.logRiskEvent(ctx, signal.StrategyID, "GROSS_EXPOSURE", err.Error(), "Trade rejected")
+			return err
+		}
+	}
+
+	// Exposure per symbol across strategies
+	if limits.MaxSymbolExposureUSD > 0 {
+		symbolExposure := notional
+		for _, e := range exposures {
+			if e.symbol == signal.Symbol {
+				symbolExposure = symbolExposure.Add(e.notional)
+			}
+		}
+		if limit := decimal.NewFromFloat(limits.MaxSymbolExposureUSD); symbolExposure.GreaterThan(limit) {
+			err := fmt.Errorf("%s exposure %.2f would exceed limit %.2f",
+				signal.Symbol, symbolExposure.InexactFloat64(), limits.MaxSymbolExposureUSD)
+			rm.logRiskEvent(ctx, signal.StrategyID, "SYMBOL_EXPOSURE", err.Error(), "Trade rejected")
+			return err
+		}
+	}
+
+	// Share of equity per asset, across quote currencies
+	if limits.MaxAssetSharePercent > 0 {
+		asset := baseCurrency(signal.Symbol)
+		assetExposure := notional
+		for _, e := range exposures {
+			if baseCurrency(e.symbol) == asset {
+				assetExposure = assetExposure.Add(e.notional)
+			}
+		}
+
+		equity, err := rm.Equity(ctx)
+		if err != nil {
+			return err
+		}
+		maxShare := equity.Mul(decimal.NewFromFloat(limits.MaxAssetSharePercent)).Div(decimal.NewFromInt(100))
+		if assetExposure.GreaterThan(maxShare) {
+			err := fmt.Errorf("%s exposure %.2f would exceed %.2f%% of equity (%.2f)",
+				asset, assetExposure.InexactFloat64(), limits.MaxAssetSharePercent, maxShare.InexactFloat64())
+			rm.logRiskEvent(ctx, signal.StrategyID, "ASSET_CONCENTRATION", err.Error(), "Trade rejected")
+			return err
+		}
+	}
+
+	// Exposure moving together with the new position
+	if limits.MaxCorrelatedExposureUSD > 0 {
+		correlated, err := rm.correlatedExposure(ctx, limits, signal.Symbol, side, notional, exposures)
+		if err != nil {
+			return err
+		}
+		if limit := decimal.NewFromFloat(limits.MaxCorrelatedExposureUSD); correlated.GreaterThan(limit) {
+			err := fmt.Errorf("exposure correlated with %s %.2f would exceed limit %.2f",
+				signal.Symbol, correlated.InexactFloat64(), limits.MaxCorrelatedExposureUSD)
+			rm.logRiskEvent(ctx, signal.StrategyID, "CORRELATED_EXPOSURE", err.Error(), "Trade rejected")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// correlatedExposure sums the new position with the open positions whose
+// returns, adjusted for direction, correlate with it above the threshold.
+// A long and a short in correlated symbols hedge each other.
+func (rm *RiskManager) correlatedExposure(
+	ctx context.Context,
+	limits *config.RiskConfig,
+	symbol string,
+	side models.TradeSide,
+	notional decimal.Decimal,
+	exposures []exposure,
+) (decimal.Decimal, error) {
+	total := notional
+	correlations := map[string]float64{symbol: 1}
+
+	for _, e := range exposures {
+		correlation, exists := correlations[e.symbol]
+		if !exists {
+			var err error
+			correlation, err = rm.getCorrelation(ctx, symbol, e.symbol, limits.CorrelationLookback)
+			if err != nil {
+				return decimal.Zero, err
+			}
+			correlations[e.symbol] = correlation
+		}
+
+		if e.side != side {
+			correlation = -correlation
+		}
+
+		if correlation >= limits.CorrelationThreshold {
+			total = total.Add(e.notional)
+		}
+	}
+
+	return total, nil
+}
+
+// loadExposures returns the notional of every open trade, valued at the
+// latest price when known and at the entry price otherwise
+func (rm *RiskManager) loadExposures(ctx context.Context) ([]exposure, error) {
+	rows, err := rm.db.QueryContext(ctx, `
+		SELECT symbol, side, quantity, entry_price
+		FROM trades
+		WHERE exit_time IS NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open trades: %w", err)
+	}
+	defer rows.Close()
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	var exposures []exposure
+	for rows.Next() {
+		var e exposure
+		var quantity, entryPrice decimal.Decimal
+		if err := rows.Scan(&e.symbol, &e.side, &quantity, &entryPrice); err != nil {
+			return nil, fmt.Errorf("failed to scan trade: %w", err)
+		}
+
+		price, exists := rm.lastPrices[e.symbol]
+		if !exists {
+			price = entryPrice
+		}
+		e.notional = quantity.Mul(price)
+
+		exposures = append(exposures, e)
+	}
+
+	return exposures, rows.Err()
+}
+
+// getCorrelation returns the correlation of the 1m returns of two symbols
+// over the lookback in 1m candles. Without enough shared history the symbols
+// are assumed to be perfectly correlated.
+func (rm *RiskManager) getCorrelation(ctx context.Context, symbolA, symbolB string, lookback int) (float64, error) {
+	rows, err := rm.db.QueryContext(ctx, `
+		SELECT a.close, b.close
+		FROM price_data a
+		JOIN price_data b
+		  ON b.time = a.time AND b.exchange = a.exchange AND b.interval = a.interval AND b.symbol = $2
+		WHERE a.symbol = $1 AND a.interval = '1m'
+		ORDER BY a.time DESC
+		LIMIT $3
+	`, symbolA, symbolB, lookback+1)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load prices for correlation: %w", err)
+	}
+	defer rows.Close()
+
+	var pricesA, pricesB []float64
+	for rows.Next() {
+		var closeA, closeB decimal.Decimal
+		if err := rows.Scan(&closeA, &closeB); err != nil {
+			return 0, fmt.Errorf("failed to scan prices: %w", err)
+		}
+		pricesA = append(pricesA, closeA.InexactFloat64())
+		pricesB = append(pricesB, closeB.InexactFloat64())
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	returnsA, returnsB := returns(pricesA), returns(pricesB)
+	if len(returnsA) < minCorrelationSamples {
+		rm.logger.WithFields(logrus.Fields{
+			"symbol_a": symbolA,
+			"symbol_b": symbolB,
+			"samples":  len(returnsA),
+		}).Debug("Not enough price history for correlation, assuming correlated")
+		return 1, nil
+	}
+
+	return pearson(returnsA, returnsB), nil
+}
+
+// returns converts prices to simple returns. The order of prices doesn't
+// matter as long as both series share it.
+func returns(prices []float64) []float64 {
+	if len(prices) < 2 {
+		return nil
+	}
+
+	result := make([]float64, 0, len(prices)-1)
+	for i := 1; i < len(prices); i++ {
+		if prices[i-1] == 0 {
+			continue
+		}
+		result = append(result, prices[i]/prices[i-1]-1)
+	}
+	return result
+}
+
+// pearson returns the Pearson correlation coefficient of two series
+func pearson(xs, ys []float64) float64 {
+	n := len(xs)
+	if len(ys) < n {
+		n = len(ys)
+	}
+	if n == 0 {
+		return 0
+	}
+
+	var meanX, meanY float64
+	for i := 0; i < n; i++ {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	var cov, varX, varY float64
+	for i := 0; i < n; i++ {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+// baseCurrency extracts the base currency from a symbol, e.g. "BTC-USD" -> "BTC"
+func baseCurrency(symbol string) string {
+	for i := 0; i < len(symbol); i++ {
+		if symbol[i] == '-' || symbol[i] == '/' {
+			return symbol[:i]
+		}
+	}
+	return symbol
+}
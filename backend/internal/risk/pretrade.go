@@ -0,0 +1,111 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// checkMarketPrice validates the signal price against the latest tick: the
+// tick must be fresh and the signal price within the deviation band
+func (rm *RiskManager) checkMarketPrice(ctx context.Context, signal *models.TradeSignal) error {
+	limits := rm.Limits(signal.StrategyID)
+
+	rm.mu.Lock()
+	marketPrice, exists := rm.lastPrices[signal.Symbol]
+	tickTime := rm.lastPriceTimes[signal.Symbol]
+	rm.mu.Unlock()
+
+	if !exists {
+		err := fmt.Errorf("no market price received for %s", signal.Symbol)
+		rm.logRiskEvent(ctx, signal.StrategyID, "STALE_PRICE", err.Error(), "Trade rejected")
+		return err
+	}
+
+	// Stale tick
+	if limits.MaxPriceAgeSeconds > 0 {
+		maxAge := time.Duration(limits.MaxPriceAgeSeconds) * time.Second
+		if age := time.Since(tickTime); age > maxAge {
+			err := fmt.Errorf("latest %s price is %s old (max %s)",
+				signal.Symbol, age.Round(time.Second), maxAge)
+			rm.logRiskEvent(ctx, signal.StrategyID, "STALE_PRICE", err.Error(), "Trade rejected")
+			return err
+		}
+	}
+
+	// Fat finger: signal price far from the market
+	if limits.MaxPriceDeviationPercent > 0 {
+		signalPrice := decimal.NewFromFloat(signal.Indicators["price"])
+		deviation := signalPrice.Sub(marketPrice).Abs().Div(marketPrice).Mul(decimal.NewFromInt(100))
+		if deviation.GreaterThan(decimal.NewFromFloat(limits.MaxPriceDeviationPercent)) {
+			err := fmt.Errorf("signal price %s deviates %.2f%% from market price %s (max %.2f%%)",
+				signalPrice.String(), deviation.InexactFloat64(), marketPrice.String(), limits.MaxPriceDeviationPercent)
+			rm.logRiskEvent(ctx, signal.StrategyID, "PRICE_DEVIATION", err.Error(), "Trade rejected")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkOrderSize rounds the signal quantity down to the exchange's step size
+// and validates it against the exchange's minimum order size
+func (rm *RiskManager) checkOrderSize(ctx context.Context, signal *models.TradeSignal) error {
+	info, err := rm.exchange.GetSymbolInfo(ctx, signal.Symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get symbol info: %w", err)
+	}
+
+	quantity := info.RoundQuantity(signal.Quantity)
+	if !quantity.Equal(signal.Quantity) {
+		rm.logger.WithFields(logrus.Fields{
+			"symbol":    signal.Symbol,
+			"quantity":  signal.Quantity.String(),
+			"rounded":   quantity.String(),
+			"step_size": info.StepSize.String(),
+		}).Debug("Quantity rounded to step size")
+		signal.Quantity = quantity
+	}
+
+	if err := info.ValidateQuantity(quantity, decimal.NewFromFloat(signal.Indicators["price"])); err != nil {
+		rm.logRiskEvent(ctx, signal.StrategyID, "ORDER_SIZE", err.Error(), "Trade rejected")
+		return err
+	}
+
+	return nil
+}
+
+// checkMinBalance validates that the USD cash left after the order stays
+// above the minimum balance. Shorts are credited their proceeds, so only
+// longs spend cash.
+func (rm *RiskManager) checkMinBalance(ctx context.Context, signal *models.TradeSignal, notional decimal.Decimal) error {
+	if signal.Intent != models.PositionIntentOpenLong {
+		return nil
+	}
+
+	limits := rm.Limits(signal.StrategyID)
+
+	balances, err := rm.exchange.GetBalance(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get balances: %w", err)
+	}
+
+	available := decimal.Zero
+	if usd, exists := balances["USD"]; exists {
+		available = usd.Available
+	}
+
+	remaining := available.Sub(notional)
+	if remaining.LessThan(decimal.NewFromFloat(limits.MinBalanceUSD)) {
+		err := fmt.Errorf("cash balance %.2f after the order would be below the minimum %.2f",
+			remaining.InexactFloat64(), limits.MinBalanceUSD)
+		rm.logRiskEvent(ctx, signal.StrategyID, "MIN_BALANCE", err.Error(), "Trade rejected")
+		return err
+	}
+
+	return nil
+}
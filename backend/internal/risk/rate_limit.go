@@ -0,0 +1,77 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/crypto-trading-bot/internal/ratelimit"
+)
+
+// checkRateLimits takes a token from the strategy's order bucket and one
+// from the exchange's, or neither if either is empty, so a throttled signal
+// doesn't spend the budget of the bucket that allowed it. The strategy's
+// bucket is checked first so a misbehaving strategy exhausts its own budget
+// before the shared one.
+func (rm *RiskManager) checkRateLimits(ctx context.Context, signal *models.TradeSignal) error {
+	limits := rm.Limits(signal.StrategyID)
+
+	var strategyBucket, exchangeBucket *ratelimit.TokenBucket
+
+	rm.mu.Lock()
+	if limits.MaxOrdersPerMinuteStrategy > 0 {
+		strategyBucket = orderBucket(rm.strategyBuckets, signal.StrategyID.String(), limits.MaxOrdersPerMinuteStrategy)
+	}
+	if limits.MaxOrdersPerMinuteExchange > 0 {
+		exchangeBucket = orderBucket(rm.exchangeBuckets, rm.exchange.Name(), limits.MaxOrdersPerMinuteExchange)
+	}
+	empty := takeTokens(strategyBucket, exchangeBucket)
+	rm.mu.Unlock()
+
+	var err error
+	switch empty {
+	case 0:
+		err = fmt.Errorf("strategy order rate limit exceeded (%d per minute)", limits.MaxOrdersPerMinuteStrategy)
+	case 1:
+		err = fmt.Errorf("%s order rate limit exceeded (%d per minute)", rm.exchange.Name(), limits.MaxOrdersPerMinuteExchange)
+	default:
+		return nil
+	}
+
+	rm.logRiskEvent(ctx, signal.StrategyID, "RATE_LIMIT", err.Error(), "Signal throttled")
+	return err
+}
+
+// takeTokens takes a token from every bucket, or from none if one is empty.
+// Nil buckets are unlimited. It returns the index of the first empty bucket,
+// -1 if the tokens were taken. Must be called with rm.mu held.
+func takeTokens(buckets ...*ratelimit.TokenBucket) int {
+	for i, bucket := range buckets {
+		if bucket == nil || bucket.Allow() {
+			continue
+		}
+
+		for _, taken := range buckets[:i] {
+			if taken != nil {
+				taken.Return()
+			}
+		}
+		return i
+	}
+	return -1
+}
+
+// orderBucket returns the bucket for a key, creating it on first use. A
+// changed limit resizes the bucket, keeping the tokens it holds, so the
+// budget isn't reset. Must be called with rm.mu held.
+func orderBucket(buckets map[string]*ratelimit.TokenBucket, key string, perMinute int) *ratelimit.TokenBucket {
+	bucket, exists := buckets[key]
+	if !exists {
+		bucket = ratelimit.PerMinute(perMinute)
+		buckets[key] = bucket
+		return bucket
+	}
+
+	bucket.SetLimit(perMinute, float64(perMinute)/60)
+	return bucket
+}
@@ -0,0 +1,70 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/crypto-trading-bot/internal/ratelimit"
+)
+
+func TestTakeTokens(t *testing.T) {
+	tests := []struct {
+		name         string
+		strategy     int // Tokens per minute, 0 for no limit
+		exchange     int
+		strategyUsed int // Tokens taken before the check
+		exchangeUsed int
+		wantEmpty    int
+		wantStrategy bool // A strategy token remains afterwards
+		wantExchange bool
+	}{
+		{name: "both allow", strategy: 2, exchange: 2, wantEmpty: -1, wantStrategy: true, wantExchange: true},
+		{name: "strategy empty", strategy: 1, exchange: 2, strategyUsed: 1, wantEmpty: 0, wantStrategy: false, wantExchange: true},
+		{name: "exchange empty returns the strategy token", strategy: 1, exchange: 1, exchangeUsed: 1, wantEmpty: 1, wantStrategy: true, wantExchange: false},
+		{name: "no strategy limit", exchange: 1, exchangeUsed: 1, wantEmpty: 1, wantExchange: false},
+		{name: "no limits", wantEmpty: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var strategy, exchange *ratelimit.TokenBucket
+			if tt.strategy > 0 {
+				strategy = ratelimit.PerMinute(tt.strategy)
+				for i := 0; i < tt.strategyUsed; i++ {
+					strategy.Allow()
+				}
+			}
+			if tt.exchange > 0 {
+				exchange = ratelimit.PerMinute(tt.exchange)
+				for i := 0; i < tt.exchangeUsed; i++ {
+					exchange.Allow()
+				}
+			}
+
+			if got := takeTokens(strategy, exchange); got != tt.wantEmpty {
+				t.Fatalf("takeTokens = %d, want %d", got, tt.wantEmpty)
+			}
+			if strategy != nil && strategy.Allow() != tt.wantStrategy {
+				t.Errorf("strategy token left = %v, want %v", !tt.wantStrategy, tt.wantStrategy)
+			}
+			if exchange != nil && exchange.Allow() != tt.wantExchange {
+				t.Errorf("exchange token left = %v, want %v", !tt.wantExchange, tt.wantExchange)
+			}
+		})
+	}
+}
+
+func TestOrderBucketKeepsBudgetAcrossLimitChanges(t *testing.T) {
+	buckets := make(map[string]*ratelimit.TokenBucket)
+
+	bucket := orderBucket(buckets, "strategy", 2)
+	bucket.Allow()
+	bucket.Allow()
+
+	changed := orderBucket(buckets, "strategy", 5)
+	if changed != bucket || len(buckets) != 1 {
+		t.Fatalf("changed limit created a new bucket (%d buckets)", len(buckets))
+	}
+	if changed.Allow() {
+		t.Error("Allow() after raising the limit = true, want the spent budget kept")
+	}
+}
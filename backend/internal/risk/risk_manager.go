@@ -4,12 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/crypto-trading-bot/internal/config"
+	"github.com/crypto-trading-bot/internal/currency"
 	"github.com/crypto-trading-bot/internal/events"
+	"github.com/crypto-trading-bot/internal/exchange"
 	"github.com/crypto-trading-bot/internal/models"
+	"github.com/crypto-trading-bot/internal/order"
+	"github.com/crypto-trading-bot/internal/ratelimit"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
@@ -19,11 +25,33 @@ import (
 type RiskManager struct {
 	config     *config.RiskConfig
 	db         *sql.DB
-	nats       *events.NATSClient
+	bus        events.Bus
+	exchange   exchange.Exchange
+	converter  *currency.Converter
 	logger     *logrus.Entry
 	killSwitch *KillSwitch
+
+	tradingDayLocation *time.Location // Timezone in which trading days start
+
+	mu             sync.Mutex
+	lastPrices     map[string]decimal.Decimal // Latest price per symbol
+	lastPriceTimes map[string]time.Time       // Time of the latest tick per symbol
+	closing        map[uuid.UUID]time.Time    // When a close signal was sent per trade
+
+	limitsMu sync.RWMutex
+	limits   *LimitsSnapshot // Runtime limits, the configuration until loaded
+
+	strategyBuckets map[string]*ratelimit.TokenBucket // Order rate limits by strategy ID
+	exchangeBuckets map[string]*ratelimit.TokenBucket // Order rate limits by exchange name
+
+	balanceMu       sync.Mutex
+	balanceBaseline *balanceBaseline // Balances the next sync expects fills on top of
 }
 
+// closeRetryInterval is how long to wait for a trade to close before
+// publishing another close signal for it
+const closeRetryInterval = 2 * time.Minute
+
 // KillSwitch manages the emergency stop functionality
 type KillSwitch struct {
 	enabled   bool
@@ -32,19 +60,35 @@ type KillSwitch struct {
 }
 
 // NewRiskManager creates a new risk manager
-func NewRiskManager(cfg *config.RiskConfig, db *sql.DB, natsClient *events.NATSClient, logger *logrus.Logger) *RiskManager {
+func NewRiskManager(
+	cfg *config.RiskConfig,
+	db *sql.DB,
+	bus events.Bus,
+	exch exchange.Exchange,
+	converter *currency.Converter,
+	logger *logrus.Logger,
+) *RiskManager {
 	return &RiskManager{
-		config: cfg,
-		db:     db,
-		nats:   natsClient,
-		logger: logger.WithField("component", "risk-manager"),
+		config:    cfg,
+		db:        db,
+		bus:       bus,
+		exchange:  exch,
+		converter: converter,
+		logger:    logger.WithField("component", "risk-manager"),
 		killSwitch: &KillSwitch{
 			enabled: false,
 		},
+		tradingDayLocation: cfg.TradingDayLocation(),
+		lastPrices:         make(map[string]decimal.Decimal),
+		lastPriceTimes:     make(map[string]time.Time),
+		closing:            make(map[uuid.UUID]time.Time),
+		strategyBuckets:    make(map[string]*ratelimit.TokenBucket),
+		exchangeBuckets:    make(map[string]*ratelimit.TokenBucket),
 	}
 }
 
-// ValidateTradeSignal validates a trade signal against risk parameters
+// ValidateTradeSignal validates a trade signal against risk parameters.
+// The quantity of an entry is rounded down to the exchange's step size.
 func (rm *RiskManager) ValidateTradeSignal(ctx context.Context, signal *models.TradeSignal) error {
 	// Check kill switch first
 	if rm.killSwitch.enabled {
@@ -52,27 +96,68 @@ func (rm *RiskManager) ValidateTradeSignal(ctx context.Context, signal *models.T
 		return fmt.Errorf("kill switch is enabled")
 	}
 
-	// Check daily loss limit
-	if err := rm.checkDailyLossLimit(ctx, signal.StrategyID); err != nil {
-		rm.logRiskEvent(ctx, signal.StrategyID, "DAILY_LOSS_LIMIT", err.Error(), "Trade rejected")
+	// Closing signals reduce exposure, so entry limits don't apply to them
+	if isClosingSignal(signal) {
+		rm.logger.WithFields(logrus.Fields{
+			"strategy_id": signal.StrategyID,
+			"symbol":      signal.Symbol,
+		}).Info("Closing trade signal validated")
+		return nil
+	}
+
+	// Check the strategy isn't paused by a circuit breaker
+	if err := rm.checkStrategyPause(ctx, signal.StrategyID); err != nil {
+		rm.logger.WithError(err).WithField("strategy_id", signal.StrategyID).Warn("Trade rejected: strategy paused")
 		return err
 	}
 
 	// Check max open positions
-	if err := rm.checkMaxOpenPositions(ctx, signal.StrategyID); err != nil {
+	if err := rm.checkMaxOpenPositions(ctx, signal); err != nil {
 		rm.logRiskEvent(ctx, signal.StrategyID, "MAX_POSITIONS", err.Error(), "Trade rejected")
 		return err
 	}
 
+	// Check the signal price against the market
+	if err := rm.checkMarketPrice(ctx, signal); err != nil {
+		return err
+	}
+
+	// Check the exchange's order size rules
+	if err := rm.checkOrderSize(ctx, signal); err != nil {
+		return err
+	}
+
+	limits := rm.Limits(signal.StrategyID)
+
 	// Validate position size
 	positionValue := signal.Quantity.Mul(decimal.NewFromFloat(signal.Indicators["price"]))
-	if positionValue.GreaterThan(decimal.NewFromFloat(rm.config.MaxPositionSizeUSD)) {
+	if positionValue.GreaterThan(decimal.NewFromFloat(limits.MaxPositionSizeUSD)) {
 		err := fmt.Errorf("position size %.2f exceeds limit %.2f",
-			positionValue.InexactFloat64(), rm.config.MaxPositionSizeUSD)
+			positionValue.InexactFloat64(), limits.MaxPositionSizeUSD)
 		rm.logRiskEvent(ctx, signal.StrategyID, "POSITION_SIZE", err.Error(), "Trade rejected")
 		return err
 	}
 
+	// Check the position across all its tranches
+	if err := rm.checkPositionNotional(ctx, signal, positionValue); err != nil {
+		return err
+	}
+
+	// Check the cash left after the order
+	if err := rm.checkMinBalance(ctx, signal, positionValue); err != nil {
+		return err
+	}
+
+	// Check portfolio-wide exposure limits
+	if err := rm.checkPortfolioLimits(ctx, signal, positionValue); err != nil {
+		return err
+	}
+
+	// Check the portfolio VaR with the new position
+	if err := rm.checkVaRLimit(ctx, signal, positionValue); err != nil {
+		return err
+	}
+
 	// Validate stop-loss is set
 	if signal.StopLossPrice.IsZero() {
 		err := fmt.Errorf("stop-loss price is required")
@@ -80,23 +165,54 @@ func (rm *RiskManager) ValidateTradeSignal(ctx context.Context, signal *models.T
 		return err
 	}
 
-	// Validate stop-loss percentage
+	// Validate stop-loss is on the losing side of the entry
 	entryPrice := decimal.NewFromFloat(signal.Indicators["price"])
+	if signal.Intent.TradeSide() == models.TradeSideShort && signal.StopLossPrice.LessThanOrEqual(entryPrice) ||
+		signal.Intent.TradeSide() == models.TradeSideLong && signal.StopLossPrice.GreaterThanOrEqual(entryPrice) {
+		err := fmt.Errorf("stop-loss %s is on the wrong side of entry %s for a %s position",
+			signal.StopLossPrice.String(), entryPrice.String(), signal.Intent.TradeSide())
+		rm.logRiskEvent(ctx, signal.StrategyID, "STOP_LOSS_INVALID", err.Error(), "Trade rejected")
+		return err
+	}
+
+	// Validate stop-loss percentage
 	stopLossDiff := entryPrice.Sub(signal.StopLossPrice).Abs()
 	stopLossPercent := stopLossDiff.Div(entryPrice).Mul(decimal.NewFromInt(100))
 
-	if stopLossPercent.GreaterThan(decimal.NewFromFloat(rm.config.StopLossPercent * 2)) {
+	if stopLossPercent.GreaterThan(decimal.NewFromFloat(limits.StopLossPercent * 2)) {
 		err := fmt.Errorf("stop-loss %.2f%% is too wide (max %.2f%%)",
-			stopLossPercent.InexactFloat64(), rm.config.StopLossPercent*2)
+			stopLossPercent.InexactFloat64(), limits.StopLossPercent*2)
 		rm.logRiskEvent(ctx, signal.StrategyID, "STOP_LOSS_TOO_WIDE", err.Error(), "Trade rejected")
 		return err
 	}
 
+	// Validate the loss if the stop-loss is hit
+	riskAmount := signal.Quantity.Mul(stopLossDiff)
+	if limits.MaxRiskPerTradePercent > 0 {
+		equity, err := rm.Equity(ctx)
+		if err != nil {
+			return err
+		}
+		maxRisk := equity.Mul(decimal.NewFromFloat(limits.MaxRiskPerTradePercent)).Div(decimal.NewFromInt(100))
+		if riskAmount.GreaterThan(maxRisk) {
+			err := fmt.Errorf("risk %.2f at stop-loss exceeds limit %.2f (%.2f%% of equity %.2f)",
+				riskAmount.InexactFloat64(), maxRisk.InexactFloat64(), limits.MaxRiskPerTradePercent, equity.InexactFloat64())
+			rm.logRiskEvent(ctx, signal.StrategyID, "RISK_PER_TRADE", err.Error(), "Trade rejected")
+			return err
+		}
+	}
+
+	// Throttle strategies placing too many orders
+	if err := rm.checkRateLimits(ctx, signal); err != nil {
+		return err
+	}
+
 	rm.logger.WithFields(logrus.Fields{
 		"strategy_id":    signal.StrategyID,
 		"symbol":         signal.Symbol,
 		"position_value": positionValue.String(),
 		"stop_loss":      signal.StopLossPrice.String(),
+		"risk_amount":    riskAmount.String(),
 	}).Info("Trade signal validated")
 
 	return nil
@@ -115,6 +231,7 @@ func (rm *RiskManager) CheckOpenTrades(ctx context.Context) error {
 	}
 	defer rows.Close()
 
+	openTrades := make(map[uuid.UUID]bool)
 	for rows.Next() {
 		var trade models.Trade
 		var metadataJSON []byte
@@ -134,20 +251,21 @@ func (rm *RiskManager) CheckOpenTrades(ctx context.Context) error {
 			continue
 		}
 
+		openTrades[trade.ID] = true
+
 		// Parse metadata
 		if err := json.Unmarshal(metadataJSON, &trade.Metadata); err != nil {
 			rm.logger.WithError(err).Error("Failed to unmarshal metadata")
 			continue
 		}
 
-		// Get current price (would come from market data service)
-		// For now, skip price check - this will be implemented in market data integration
+		// Price-based stops are enforced in OnPriceUpdate
 
 		// Check max hold time
 		holdDuration := time.Since(trade.EntryTime)
-		maxHoldDuration := time.Duration(rm.config.MaxHoldTimeHours) * time.Hour
+		maxHoldDuration := time.Duration(rm.Limits(trade.StrategyID).MaxHoldTimeHours) * time.Hour
 
-		if holdDuration > maxHoldDuration {
+		if holdDuration > maxHoldDuration && !rm.isClosing(trade.ID) {
 			rm.logger.WithFields(logrus.Fields{
 				"trade_id":      trade.ID,
 				"hold_duration": holdDuration,
@@ -155,25 +273,182 @@ func (rm *RiskManager) CheckOpenTrades(ctx context.Context) error {
 			}).Warn("Trade exceeded max hold time")
 
 			// Publish event to close trade
-			closeSignal := &events.TradeSignalEvent{
-				ID:         uuid.New().String(),
-				StrategyID: trade.StrategyID.String(),
-				Symbol:     trade.Symbol,
-				Side:       oppositeOrderSide(trade.Side),
-				Type:       "MARKET",
-				Quantity:   trade.Quantity.InexactFloat64(),
-			}
-
-			if err := rm.nats.Publish(events.EventTypeTradeSignal, closeSignal); err != nil {
-				rm.logger.WithError(err).Error("Failed to publish close signal")
-			}
+			rm.markClosing(trade.ID)
+			rm.publishCloseSignal(&trade, "Max hold time exceeded", models.ExitReasonTimeout)
 
 			rm.logRiskEvent(ctx, trade.StrategyID, "MAX_HOLD_TIME",
 				fmt.Sprintf("Trade held for %s", holdDuration), "Closing trade")
 		}
 	}
 
-	return rows.Err()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Forget pending closes of trades that have been closed
+	rm.mu.Lock()
+	for tradeID := range rm.closing {
+		if !openTrades[tradeID] {
+			delete(rm.closing, tradeID)
+		}
+	}
+	rm.mu.Unlock()
+
+	return nil
+}
+
+// OnPriceUpdate enforces stop-losses and trailing stops of the open trades
+// of a symbol against the latest price, ticked at the given time
+func (rm *RiskManager) OnPriceUpdate(ctx context.Context, symbol string, price decimal.Decimal, at time.Time) error {
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	rm.mu.Lock()
+	rm.lastPrices[symbol] = price
+	rm.lastPriceTimes[symbol] = at
+	rm.mu.Unlock()
+
+	rows, err := rm.db.QueryContext(ctx, `
+		SELECT t.id, t.strategy_id, t.symbol, t.entry_price, t.quantity, t.side, t.metadata, o.stop_loss_price
+		FROM trades t
+		LEFT JOIN orders o ON t.entry_order_id = o.id
+		WHERE t.symbol = $1 AND t.exit_time IS NULL
+	`, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get open trades: %w", err)
+	}
+
+	var trades []models.Trade
+	var stopLosses []decimal.NullDecimal
+	for rows.Next() {
+		var trade models.Trade
+		var metadataJSON []byte
+		var stopLoss decimal.NullDecimal
+
+		err := rows.Scan(
+			&trade.ID,
+			&trade.StrategyID,
+			&trade.Symbol,
+			&trade.EntryPrice,
+			&trade.Quantity,
+			&trade.Side,
+			&metadataJSON,
+			&stopLoss,
+		)
+		if err != nil {
+			rm.logger.WithError(err).Error("Failed to scan trade")
+			continue
+		}
+
+		if len(metadataJSON) > 0 {
+			if err := json.Unmarshal(metadataJSON, &trade.Metadata); err != nil {
+				rm.logger.WithError(err).Error("Failed to unmarshal metadata")
+			}
+		}
+
+		trades = append(trades, trade)
+		stopLosses = append(stopLosses, stopLoss)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range trades {
+		trade := &trades[i]
+		if rm.isClosing(trade.ID) {
+			continue
+		}
+
+		// Fixed stop-loss from the entry order
+		if stopLoss := stopLosses[i]; stopLoss.Valid && stopLossHit(trade.Side, stopLoss.Decimal, price) {
+			rm.closeOnStop(ctx, trade, "STOP_LOSS", "Stop-loss hit",
+				fmt.Sprintf("Price %s crossed stop-loss %s", price.String(), stopLoss.Decimal.String()))
+			continue
+		}
+
+		hit, err := rm.checkTrailingStop(ctx, trade, price)
+		if err != nil {
+			rm.logger.WithError(err).WithField("trade_id", trade.ID).Warn("Failed to check trailing stop")
+			continue
+		}
+		if hit {
+			rm.closeOnStop(ctx, trade, "TRAILING_STOP", "Trailing stop hit",
+				fmt.Sprintf("Price %s crossed trailing stop", price.String()))
+		}
+	}
+
+	return nil
+}
+
+// closeOnStop closes a trade whose stop was hit, once
+func (rm *RiskManager) closeOnStop(ctx context.Context, trade *models.Trade, eventType, reason, description string) {
+	rm.markClosing(trade.ID)
+
+	rm.logger.WithFields(logrus.Fields{
+		"trade_id": trade.ID,
+		"symbol":   trade.Symbol,
+		"reason":   reason,
+	}).Warn("Stop hit, closing trade")
+
+	rm.publishCloseSignal(trade, reason, models.ExitReasonStopLoss)
+	rm.logRiskEvent(ctx, trade.StrategyID, eventType, description, "Closing trade")
+}
+
+// markClosing records that a close signal was sent for the trade
+func (rm *RiskManager) markClosing(tradeID uuid.UUID) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.closing[tradeID] = time.Now()
+}
+
+// isClosing returns true if a close signal was recently sent for the trade
+func (rm *RiskManager) isClosing(tradeID uuid.UUID) bool {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	sentAt, exists := rm.closing[tradeID]
+	return exists && time.Since(sentAt) < closeRetryInterval
+}
+
+// CloseOpenTrades publishes close signals for all open trades of a strategy
+func (rm *RiskManager) CloseOpenTrades(ctx context.Context, strategyID uuid.UUID, reason string, exitReason models.ExitReason) error {
+	rows, err := rm.db.QueryContext(ctx, `
+		SELECT id, strategy_id, symbol, quantity, side
+		FROM trades
+		WHERE strategy_id = $1 AND exit_time IS NULL
+	`, strategyID)
+	if err != nil {
+		return fmt.Errorf("failed to get open trades: %w", err)
+	}
+	defer rows.Close()
+
+	closed := 0
+	for rows.Next() {
+		var trade models.Trade
+		if err := rows.Scan(&trade.ID, &trade.StrategyID, &trade.Symbol, &trade.Quantity, &trade.Side); err != nil {
+			rm.logger.WithError(err).Error("Failed to scan trade")
+			continue
+		}
+
+		rm.markClosing(trade.ID)
+		rm.publishCloseSignal(&trade, reason, exitReason)
+		closed++
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if closed > 0 {
+		rm.logRiskEvent(ctx, strategyID, "STRATEGY_CLOSE_POSITIONS",
+			fmt.Sprintf("%s: closing %d open trade(s)", reason, closed), "Closing trades")
+	}
+
+	return nil
 }
 
 // EnableKillSwitch enables the emergency kill switch
@@ -197,9 +472,7 @@ func (rm *RiskManager) EnableKillSwitch(ctx context.Context, reason string) erro
 	}
 
 	// Cancel all open orders
-	if _, err := rm.db.ExecContext(ctx, `
-		UPDATE orders SET status = 'CANCELLED' WHERE status IN ('PENDING', 'OPEN')
-	`); err != nil {
+	if err := rm.cancelOpenOrders(ctx, reason); err != nil {
 		rm.logger.WithError(err).Error("Failed to cancel open orders")
 	}
 
@@ -208,7 +481,7 @@ func (rm *RiskManager) EnableKillSwitch(ctx context.Context, reason string) erro
 		Enabled: true,
 		Reason:  reason,
 	}
-	if err := rm.nats.Publish(events.EventTypeKillSwitch, killSwitchEvent); err != nil {
+	if err := rm.bus.Publish(events.EventTypeKillSwitch, killSwitchEvent); err != nil {
 		rm.logger.WithError(err).Error("Failed to publish kill switch event")
 	}
 
@@ -220,6 +493,48 @@ func (rm *RiskManager) EnableKillSwitch(ctx context.Context, reason string) erro
 	return nil
 }
 
+// cancelOpenOrders cancels every pending and open order through the order
+// state machine, skipping orders that filled in the meantime
+func (rm *RiskManager) cancelOpenOrders(ctx context.Context, reason string) error {
+	rows, err := rm.db.QueryContext(ctx, `
+		SELECT id FROM orders WHERE status IN ('PENDING', 'OPEN')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to get open orders: %w", err)
+	}
+
+	var orderIDs []uuid.UUID
+	for rows.Next() {
+		var orderID uuid.UUID
+		if err := rows.Scan(&orderID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan order: %w", err)
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, orderID := range orderIDs {
+		_, err := order.TransitionOrder(ctx, rm.db, orderID, order.Transition{
+			To:     models.OrderStatusCancelled,
+			Source: order.SourceKillSwitch,
+			Reason: reason,
+		})
+		if errors.Is(err, order.ErrInvalidTransition) {
+			continue
+		}
+		if err != nil {
+			rm.logger.WithError(err).WithField("order_id", orderID).Error("Failed to cancel order")
+		}
+	}
+
+	return nil
+}
+
 // DisableKillSwitch disables the kill switch
 func (rm *RiskManager) DisableKillSwitch(ctx context.Context) error {
 	rm.killSwitch.enabled = false
@@ -244,7 +559,7 @@ func (rm *RiskManager) DisableKillSwitch(ctx context.Context) error {
 		Enabled: false,
 		Reason:  "",
 	}
-	if err := rm.nats.Publish(events.EventTypeKillSwitch, killSwitchEvent); err != nil {
+	if err := rm.bus.Publish(events.EventTypeKillSwitch, killSwitchEvent); err != nil {
 		rm.logger.WithError(err).Error("Failed to publish kill switch event")
 	}
 
@@ -277,58 +592,108 @@ func (rm *RiskManager) GetKillSwitchStatus() *models.KillSwitchStatus {
 
 // Helper methods
 
-func (rm *RiskManager) checkDailyLossLimit(ctx context.Context, strategyID uuid.UUID) error {
-	startOfDay := time.Now().Truncate(24 * time.Hour)
-
-	// Get daily P&L
-	var dailyPnL decimal.NullDecimal
-	err := rm.db.QueryRowContext(ctx, `
-		SELECT COALESCE(SUM(pnl), 0) as daily_pnl
-		FROM trades
-		WHERE strategy_id = $1 AND entry_time >= $2
-	`, strategyID, startOfDay).Scan(&dailyPnL)
-
+// Equity returns the account equity in USD, the currency of the limits:
+// every balance converted at the latest prices. Balances without a rate are
+// left out.
+func (rm *RiskManager) Equity(ctx context.Context) (decimal.Decimal, error) {
+	equity, err := rm.balancesValue(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get daily P&L: %w", err)
+		return decimal.Zero, fmt.Errorf("failed to value equity: %w", err)
 	}
 
-	// Get portfolio value (simplified - get total balance)
-	var portfolioValue decimal.Decimal
-	err = rm.db.QueryRowContext(ctx, `
-		SELECT COALESCE(SUM(total), 0) FROM balances
-	`).Scan(&portfolioValue)
+	return equity, nil
+}
 
+// balancesValue returns the USD value of all balances
+func (rm *RiskManager) balancesValue(ctx context.Context) (decimal.Decimal, error) {
+	rows, err := rm.db.QueryContext(ctx, `
+		SELECT currency, COALESCE(SUM(total), 0) FROM balances GROUP BY currency
+	`)
 	if err != nil {
-		portfolioValue = decimal.NewFromFloat(10000) // Default fallback
+		return decimal.Zero, fmt.Errorf("failed to get balances: %w", err)
 	}
 
-	// Calculate loss limit
-	lossLimit := portfolioValue.Mul(decimal.NewFromFloat(rm.config.DailyLossLimitPercent)).Div(decimal.NewFromInt(100))
+	totals := make(map[string]decimal.Decimal)
+	for rows.Next() {
+		var code string
+		var total decimal.Decimal
+		if err := rows.Scan(&code, &total); err != nil {
+			rows.Close()
+			return decimal.Zero, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		totals[code] = total
+	}
+	rows.Close()
 
-	// Check if daily loss exceeds limit
-	if dailyPnL.Valid && dailyPnL.Decimal.LessThan(lossLimit.Neg()) {
-		// Auto-enable kill switch
-		rm.EnableKillSwitch(ctx, fmt.Sprintf("Daily loss limit exceeded: %.2f", dailyPnL.Decimal.InexactFloat64()))
-		return fmt.Errorf("daily loss limit exceeded: %.2f (limit: %.2f)",
-			dailyPnL.Decimal.InexactFloat64(), lossLimit.InexactFloat64())
+	if err := rows.Err(); err != nil {
+		return decimal.Zero, err
 	}
 
-	return nil
+	value := decimal.Zero
+	for code, total := range totals {
+		converted, err := rm.converter.Convert(ctx, total, code, "USD")
+		if errors.Is(err, currency.ErrNoRate) {
+			rm.logger.WithField("currency", code).Warn("No rate for balance, leaving it out of equity")
+			continue
+		}
+		if err != nil {
+			return decimal.Zero, err
+		}
+		value = value.Add(converted)
+	}
+
+	return value, nil
 }
 
-func (rm *RiskManager) checkMaxOpenPositions(ctx context.Context, strategyID uuid.UUID) error {
+// checkMaxOpenPositions validates the strategy's number of open positions.
+// A position is the open lots of a symbol and side, so adding a tranche to
+// one doesn't count as a new position.
+func (rm *RiskManager) checkMaxOpenPositions(ctx context.Context, signal *models.TradeSignal) error {
 	var openPositions int
+	var scaleIn bool
 	err := rm.db.QueryRowContext(ctx, `
-		SELECT COUNT(*) FROM trades WHERE strategy_id = $1 AND exit_time IS NULL
-	`, strategyID).Scan(&openPositions)
+		SELECT COUNT(DISTINCT (symbol, side)),
+		       COALESCE(BOOL_OR(symbol = $2 AND side = $3), false)
+		FROM trades
+		WHERE strategy_id = $1 AND exit_time IS NULL
+	`, signal.StrategyID, signal.Symbol, signal.Intent.TradeSide()).Scan(&openPositions, &scaleIn)
 
 	if err != nil {
 		return fmt.Errorf("failed to get open positions: %w", err)
 	}
 
-	if openPositions >= rm.config.MaxOpenPositions {
+	if scaleIn {
+		return nil
+	}
+
+	maxOpenPositions := rm.Limits(signal.StrategyID).MaxOpenPositions
+	if openPositions >= maxOpenPositions {
 		return fmt.Errorf("max open positions reached: %d (limit: %d)",
-			openPositions, rm.config.MaxOpenPositions)
+			openPositions, maxOpenPositions)
+	}
+
+	return nil
+}
+
+// checkPositionNotional validates that the position including the new
+// tranche stays under the max position notional
+func (rm *RiskManager) checkPositionNotional(ctx context.Context, signal *models.TradeSignal, notional decimal.Decimal) error {
+	var openNotional decimal.Decimal
+	err := rm.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(quantity * entry_price), 0)
+		FROM trades
+		WHERE strategy_id = $1 AND symbol = $2 AND side = $3 AND exit_time IS NULL
+	`, signal.StrategyID, signal.Symbol, signal.Intent.TradeSide()).Scan(&openNotional)
+	if err != nil {
+		return fmt.Errorf("failed to get position notional: %w", err)
+	}
+
+	limit := rm.Limits(signal.StrategyID).PositionNotionalLimit()
+	if total := openNotional.Add(notional); total.GreaterThan(decimal.NewFromFloat(limit)) {
+		err := fmt.Errorf("position notional %.2f would exceed limit %.2f",
+			total.InexactFloat64(), limit)
+		rm.logRiskEvent(ctx, signal.StrategyID, "POSITION_NOTIONAL", err.Error(), "Trade rejected")
+		return err
 	}
 
 	return nil
@@ -360,11 +725,56 @@ func (rm *RiskManager) logRiskEvent(ctx context.Context, strategyID uuid.UUID, e
 		Description: description,
 		ActionTaken: actionTaken,
 	}
-	if err := rm.nats.Publish(events.EventTypeRiskViolation, riskEvent); err != nil {
+	if err := rm.bus.Publish(events.EventTypeRiskViolation, riskEvent); err != nil {
 		rm.logger.WithError(err).Error("Failed to publish risk event")
 	}
 }
 
+// publishCloseSignal publishes a market signal closing the trade
+func (rm *RiskManager) publishCloseSignal(trade *models.Trade, reason string, exitReason models.ExitReason) {
+	var indicators map[string]float64
+	rm.mu.Lock()
+	if price, exists := rm.lastPrices[trade.Symbol]; exists {
+		indicators = map[string]float64{"price": price.InexactFloat64()}
+	}
+	rm.mu.Unlock()
+
+	closeSignal := &events.TradeSignalEvent{
+		ID:         uuid.New().String(),
+		StrategyID: trade.StrategyID.String(),
+		Symbol:     trade.Symbol,
+		Side:       oppositeOrderSide(trade.Side),
+		Intent:     string(models.CloseIntent(trade.Side)),
+		Type:       "MARKET",
+		Quantity:   trade.Quantity.InexactFloat64(),
+		Reason:     reason,
+		Indicators: indicators,
+		ExitReason: string(exitReason),
+		TradeID:    trade.ID.String(),
+	}
+
+	if err := rm.bus.Publish(events.EventTypeTradeSignal, closeSignal); err != nil {
+		rm.logger.WithError(err).WithField("trade_id", trade.ID).Error("Failed to publish close signal")
+	}
+}
+
+// isClosingSignal returns true if the signal closes a position
+func isClosingSignal(signal *models.TradeSignal) bool {
+	intent := signal.Intent
+	if intent == "" {
+		intent = models.IntentForSide(signal.Side)
+	}
+	return !intent.IsOpening()
+}
+
+// stopLossHit returns true if the price crossed a fixed stop-loss
+func stopLossHit(side models.TradeSide, stopLoss, price decimal.Decimal) bool {
+	if side == models.TradeSideShort {
+		return price.GreaterThanOrEqual(stopLoss)
+	}
+	return price.LessThanOrEqual(stopLoss)
+}
+
 func oppositeOrderSide(tradeSide models.TradeSide) string {
 	if tradeSide == models.TradeSideLong {
 		return string(models.OrderSideSell)
@@ -0,0 +1,167 @@
+package risk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/crypto-trading-bot/internal/strategy"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// Trailing stop types
+const (
+	TrailingStopNone    = "none"
+	TrailingStopPercent = "percent"
+	TrailingStopATR     = "atr"
+)
+
+// trailingStopMetadataKey is the trades.metadata key holding the trailing stop
+const trailingStopMetadataKey = "trailing_stop"
+
+// TrailingStop tracks the trailing stop of an open trade. HighWaterMark is
+// the best price seen since entry: the highest for longs, the lowest for shorts.
+type TrailingStop struct {
+	Type          string          `json:"type"`
+	Distance      decimal.Decimal `json:"distance"` // Price distance from the high-water mark
+	HighWaterMark decimal.Decimal `json:"high_water_mark"`
+	StopPrice     decimal.Decimal `json:"stop_price"`
+}
+
+// Update moves the high-water mark and stop price with a new price.
+// It returns true if the stop moved.
+func (ts *TrailingStop) Update(side models.TradeSide, price decimal.Decimal) bool {
+	if side == models.TradeSideShort {
+		if !price.LessThan(ts.HighWaterMark) {
+			return false
+		}
+		ts.HighWaterMark = price
+		ts.StopPrice = price.Add(ts.Distance)
+		return true
+	}
+
+	if !price.GreaterThan(ts.HighWaterMark) {
+		return false
+	}
+	ts.HighWaterMark = price
+	ts.StopPrice = price.Sub(ts.Distance)
+	return true
+}
+
+// Triggered returns true if the price crossed the stop
+func (ts *TrailingStop) Triggered(side models.TradeSide, price decimal.Decimal) bool {
+	if side == models.TradeSideShort {
+		return price.GreaterThanOrEqual(ts.StopPrice)
+	}
+	return price.LessThanOrEqual(ts.StopPrice)
+}
+
+// newTrailingStop creates the trailing stop for a trade using the configured
+// type. It returns nil if trailing stops are disabled.
+func (rm *RiskManager) newTrailingStop(ctx context.Context, trade *models.Trade) (*TrailingStop, error) {
+	var distance decimal.Decimal
+
+	switch rm.config.TrailingStopType {
+	case TrailingStopPercent:
+		distance = trade.EntryPrice.Mul(decimal.NewFromFloat(rm.config.TrailingStopPercent)).Div(decimal.NewFromInt(100))
+	case TrailingStopATR:
+		atr, err := strategy.LoadATR(ctx, rm.db, trade.Symbol, rm.config.TrailingStopATRPeriod)
+		if err != nil {
+			return nil, err
+		}
+		if atr.IsZero() {
+			return nil, fmt.Errorf("not enough price data to calculate ATR for %s", trade.Symbol)
+		}
+		distance = atr.Mul(decimal.NewFromFloat(rm.config.TrailingStopATRMultiple))
+	default:
+		return nil, nil
+	}
+
+	ts := &TrailingStop{
+		Type:          rm.config.TrailingStopType,
+		Distance:      distance,
+		HighWaterMark: trade.EntryPrice,
+		StopPrice:     trade.EntryPrice.Sub(distance),
+	}
+	if trade.Side == models.TradeSideShort {
+		ts.StopPrice = trade.EntryPrice.Add(distance)
+	}
+
+	return ts, nil
+}
+
+// parseTrailingStop reads the trailing stop from trade metadata, or nil
+func parseTrailingStop(metadata map[string]interface{}) *TrailingStop {
+	raw, exists := metadata[trailingStopMetadataKey]
+	if !exists {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+
+	var ts TrailingStop
+	if err := json.Unmarshal(data, &ts); err != nil {
+		return nil
+	}
+
+	return &ts
+}
+
+// saveTrailingStop persists the trailing stop in the trade's metadata
+func (rm *RiskManager) saveTrailingStop(ctx context.Context, tradeID uuid.UUID, ts *TrailingStop) error {
+	data, err := json.Marshal(ts)
+	if err != nil {
+		return err
+	}
+
+	_, err = rm.db.ExecContext(ctx, `
+		UPDATE trades
+		SET metadata = jsonb_set(COALESCE(metadata, '{}'::jsonb), '{trailing_stop}', $2::jsonb)
+		WHERE id = $1
+	`, tradeID, data)
+	if err != nil {
+		return fmt.Errorf("failed to save trailing stop: %w", err)
+	}
+
+	return nil
+}
+
+// checkTrailingStop initializes or advances the trailing stop of a trade and
+// returns true if it was hit
+func (rm *RiskManager) checkTrailingStop(ctx context.Context, trade *models.Trade, price decimal.Decimal) (bool, error) {
+	ts := parseTrailingStop(trade.Metadata)
+	changed := false
+
+	if ts == nil {
+		var err error
+		ts, err = rm.newTrailingStop(ctx, trade)
+		if err != nil || ts == nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	if ts.Update(trade.Side, price) {
+		changed = true
+	}
+
+	if changed {
+		if err := rm.saveTrailingStop(ctx, trade.ID, ts); err != nil {
+			return false, err
+		}
+
+		rm.logger.WithFields(logrus.Fields{
+			"trade_id":        trade.ID,
+			"high_water_mark": ts.HighWaterMark.String(),
+			"stop_price":      ts.StopPrice.String(),
+		}).Debug("Trailing stop updated")
+	}
+
+	return ts.Triggered(trade.Side, price), nil
+}
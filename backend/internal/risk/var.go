@@ -0,0 +1,303 @@
+package risk
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// VaR methods
+const (
+	VaRMethodHistorical = "historical"
+	VaRMethodParametric = "parametric"
+)
+
+// minVaRSamples is the number of returns needed to trust a VaR estimate
+const minVaRSamples = 30
+
+// Position is the USD exposure of the open trades in a symbol, negative
+// for shorts
+type Position struct {
+	Symbol   string  `json:"symbol"`
+	Notional float64 `json:"notional"`
+}
+
+// VaRReport holds the value at risk and expected shortfall of a set of
+// positions, as positive USD losses over the horizon
+type VaRReport struct {
+	Confidence     float64    `json:"confidence"`
+	HorizonMinutes int        `json:"horizon_minutes"`
+	Samples        int        `json:"samples"` // Returns the estimate is based on
+	GrossExposure  float64    `json:"gross_exposure"`
+	HistoricalVaR  float64    `json:"historical_var"`
+	HistoricalES   float64    `json:"historical_es"`
+	ParametricVaR  float64    `json:"parametric_var"`
+	ParametricES   float64    `json:"parametric_es"`
+	Positions      []Position `json:"positions"`
+	CalculatedAt   time.Time  `json:"calculated_at"`
+}
+
+// VaR returns the VaR of the given method
+func (r *VaRReport) VaR(method string) float64 {
+	if method == VaRMethodParametric {
+		return r.ParametricVaR
+	}
+	return r.HistoricalVaR
+}
+
+// ComputeVaR estimates the VaR and expected shortfall of the positions from
+// the joint 1m returns of their symbols over the lookback, scaled to the
+// horizon by the square root of time
+func ComputeVaR(
+	ctx context.Context,
+	db *sql.DB,
+	positions []Position,
+	confidence float64,
+	horizonMinutes int,
+	lookback int,
+) (*VaRReport, error) {
+	report := &VaRReport{
+		Confidence:     confidence,
+		HorizonMinutes: horizonMinutes,
+		Positions:      positions,
+		CalculatedAt:   time.Now(),
+	}
+	if len(positions) == 0 {
+		return report, nil
+	}
+
+	// Net the exposure per symbol
+	notionals := make(map[string]float64)
+	for _, p := range positions {
+		notionals[p.Symbol] += p.Notional
+		report.GrossExposure += math.Abs(p.Notional)
+	}
+
+	closes := make(map[string]map[time.Time]float64, len(notionals))
+	for symbol := range notionals {
+		symbolCloses, err := loadCloses(ctx, db, symbol, lookback+1)
+		if err != nil {
+			return nil, err
+		}
+		closes[symbol] = symbolCloses
+	}
+
+	// Portfolio PnL per minute over the times every symbol has a close
+	var times []time.Time
+	for t := range closes[positions[0].Symbol] {
+		shared := true
+		for _, symbolCloses := range closes {
+			if _, exists := symbolCloses[t]; !exists {
+				shared = false
+				break
+			}
+		}
+		if shared {
+			times = append(times, t)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	pnls := make([]float64, 0, len(times))
+	for i := 1; i < len(times); i++ {
+		pnl := 0.0
+		for symbol, notional := range notionals {
+			previous := closes[symbol][times[i-1]]
+			if previous == 0 {
+				continue
+			}
+			pnl += notional * (closes[symbol][times[i]]/previous - 1)
+		}
+		pnls = append(pnls, pnl)
+	}
+
+	report.Samples = len(pnls)
+	if len(pnls) < minVaRSamples {
+		return report, nil
+	}
+
+	scale := math.Sqrt(float64(horizonMinutes))
+	report.HistoricalVaR, report.HistoricalES = historicalVaR(pnls, confidence)
+	report.HistoricalVaR *= scale
+	report.HistoricalES *= scale
+
+	mean, stdDev := meanStdDev(pnls)
+	report.ParametricVaR, report.ParametricES = parametricVaR(mean*float64(horizonMinutes), stdDev*scale, confidence)
+
+	return report, nil
+}
+
+// LoadPositions returns the exposure of the open trades per symbol, valued
+// at the latest 1m close or at the entry price without price data
+func LoadPositions(ctx context.Context, db *sql.DB) ([]Position, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT t.symbol, t.side, SUM(t.quantity * COALESCE(
+			(SELECT p.close FROM price_data p
+			 WHERE p.symbol = t.symbol AND p.interval = '1m'
+			 ORDER BY p.time DESC LIMIT 1),
+			t.entry_price))
+		FROM trades t
+		WHERE t.exit_time IS NULL
+		GROUP BY t.symbol, t.side
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open positions: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []Position
+	for rows.Next() {
+		var symbol string
+		var side models.TradeSide
+		var notional decimal.Decimal
+		if err := rows.Scan(&symbol, &side, &notional); err != nil {
+			return nil, fmt.Errorf("failed to scan position: %w", err)
+		}
+		positions = append(positions, newPosition(symbol, side, notional))
+	}
+
+	return positions, rows.Err()
+}
+
+// checkVaRLimit validates that the portfolio VaR including the new position
+// stays under the limit. Without enough price history the check is skipped.
+func (rm *RiskManager) checkVaRLimit(ctx context.Context, signal *models.TradeSignal, notional decimal.Decimal) error {
+	limits := rm.Limits(signal.StrategyID)
+	if limits.MaxVaRUSD <= 0 {
+		return nil
+	}
+
+	exposures, err := rm.loadExposures(ctx)
+	if err != nil {
+		return err
+	}
+
+	positions := make([]Position, 0, len(exposures)+1)
+	for _, e := range exposures {
+		positions = append(positions, newPosition(e.symbol, e.side, e.notional))
+	}
+	positions = append(positions, newPosition(signal.Symbol, signal.Intent.TradeSide(), notional))
+
+	report, err := ComputeVaR(ctx, rm.db, positions, limits.VaRConfidence, limits.VaRHorizonMinutes, limits.VaRLookback)
+	if err != nil {
+		return err
+	}
+
+	if report.Samples < minVaRSamples {
+		rm.logger.WithFields(logrus.Fields{
+			"symbol":  signal.Symbol,
+			"samples": report.Samples,
+		}).Debug("Not enough price history for VaR, skipping check")
+		return nil
+	}
+
+	if portfolioVaR := report.VaR(limits.VaRMethod); portfolioVaR > limits.MaxVaRUSD {
+		err := fmt.Errorf("portfolio %s VaR %.2f would exceed limit %.2f",
+			limits.VaRMethod, portfolioVaR, limits.MaxVaRUSD)
+		rm.logRiskEvent(ctx, signal.StrategyID, "VAR_LIMIT", err.Error(), "Trade rejected")
+		return err
+	}
+
+	return nil
+}
+
+// newPosition returns the signed exposure of a trade side
+func newPosition(symbol string, side models.TradeSide, notional decimal.Decimal) Position {
+	value := notional.InexactFloat64()
+	if side == models.TradeSideShort {
+		value = -value
+	}
+	return Position{Symbol: symbol, Notional: value}
+}
+
+// loadCloses returns the latest 1m closes of a symbol by time
+func loadCloses(ctx context.Context, db *sql.DB, symbol string, limit int) (map[time.Time]float64, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT time, close
+		FROM price_data
+		WHERE symbol = $1 AND interval = '1m'
+		ORDER BY time DESC
+		LIMIT $2
+	`, symbol, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prices for VaR: %w", err)
+	}
+	defer rows.Close()
+
+	closes := make(map[time.Time]float64)
+	for rows.Next() {
+		var t time.Time
+		var closePrice decimal.Decimal
+		if err := rows.Scan(&t, &closePrice); err != nil {
+			return nil, fmt.Errorf("failed to scan price: %w", err)
+		}
+		closes[t.UTC()] = closePrice.InexactFloat64()
+	}
+
+	return closes, rows.Err()
+}
+
+// historicalVaR returns the loss at the confidence quantile of the PnLs and
+// the average loss beyond it
+func historicalVaR(pnls []float64, confidence float64) (float64, float64) {
+	sorted := append([]float64(nil), pnls...)
+	sort.Float64s(sorted)
+
+	// Number of outcomes in the tail, at least one. The tolerance keeps the
+	// float error of 1-confidence from adding an outcome, e.g. 100 PnLs at
+	// 99% give 1.0000000000000009.
+	tail := int(math.Ceil(float64(len(sorted))*(1-confidence) - 1e-9))
+	if tail < 1 {
+		tail = 1
+	}
+
+	valueAtRisk := -sorted[tail-1]
+
+	sum := 0.0
+	for _, pnl := range sorted[:tail] {
+		sum += pnl
+	}
+	expectedShortfall := -sum / float64(tail)
+
+	return math.Max(valueAtRisk, 0), math.Max(expectedShortfall, 0)
+}
+
+// parametricVaR returns the VaR and expected shortfall of normally
+// distributed PnL
+func parametricVaR(mean, stdDev, confidence float64) (float64, float64) {
+	z := math.Sqrt2 * math.Erfinv(2*confidence-1)
+	density := math.Exp(-z*z/2) / math.Sqrt(2*math.Pi)
+
+	valueAtRisk := z*stdDev - mean
+	expectedShortfall := stdDev*density/(1-confidence) - mean
+
+	return math.Max(valueAtRisk, 0), math.Max(expectedShortfall, 0)
+}
+
+// meanStdDev returns the mean and sample standard deviation of a series
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) < 2 {
+		return 0, 0
+	}
+
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values) - 1)
+
+	return mean, math.Sqrt(variance)
+}
@@ -0,0 +1,81 @@
+package risk
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHistoricalVaR(t *testing.T) {
+	// 100 PnLs from -50 to 49
+	hundred := make([]float64, 100)
+	for i := range hundred {
+		hundred[i] = float64(i - 50)
+	}
+
+	tests := []struct {
+		name       string
+		pnls       []float64
+		confidence float64
+		wantVaR    float64
+		wantES     float64
+	}{
+		{"worst of 100 at 99%", hundred, 0.99, 50, 50},
+		{"five worst of 100 at 95%", hundred, 0.95, 46, 48},
+		{"thirty worst of 100 at 70%", hundred, 0.70, 21, 35.5},
+		{"tail rounds up", []float64{-10, -5, -3, 0, 1, 2, 3, 4, 5, 6}, 0.85, 5, 7.5},
+		{"at least one outcome", []float64{-4, 1, 2}, 0.999, 4, 4},
+		{"no losses", []float64{1, 2, 3}, 0.95, 0, 0},
+		{"unsorted input", []float64{3, -8, 1, -2}, 0.5, 2, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotVaR, gotES := historicalVaR(tt.pnls, tt.confidence)
+			if gotVaR != tt.wantVaR || gotES != tt.wantES {
+				t.Errorf("historicalVaR = %v, %v, want %v, %v", gotVaR, gotES, tt.wantVaR, tt.wantES)
+			}
+		})
+	}
+}
+
+func TestParametricVaR(t *testing.T) {
+	tests := []struct {
+		name       string
+		mean       float64
+		stdDev     float64
+		confidence float64
+		wantVaR    float64
+		wantES     float64
+	}{
+		{"standard normal at 95%", 0, 1, 0.95, 1.644854, 2.062713},
+		{"standard normal at 99%", 0, 1, 0.99, 2.326348, 2.665214},
+		{"scaled by the deviation", 0, 100, 0.95, 164.4854, 206.2713},
+		{"offset by the mean", 0.5, 1, 0.95, 1.144854, 1.562713},
+		{"gains only", 10, 1, 0.95, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotVaR, gotES := parametricVaR(tt.mean, tt.stdDev, tt.confidence)
+			if !approx(gotVaR, tt.wantVaR) || !approx(gotES, tt.wantES) {
+				t.Errorf("parametricVaR = %v, %v, want %v, %v", gotVaR, gotES, tt.wantVaR, tt.wantES)
+			}
+		})
+	}
+}
+
+func TestMeanStdDev(t *testing.T) {
+	mean, stdDev := meanStdDev([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	if !approx(mean, 5) || !approx(stdDev, math.Sqrt(32.0/7)) {
+		t.Errorf("meanStdDev = %v, %v, want 5, %v", mean, stdDev, math.Sqrt(32.0/7))
+	}
+
+	if mean, stdDev := meanStdDev([]float64{1}); mean != 0 || stdDev != 0 {
+		t.Errorf("meanStdDev of one value = %v, %v, want 0, 0", mean, stdDev)
+	}
+}
+
+// approx compares to six significant digits
+func approx(got, want float64) bool {
+	return math.Abs(got-want) <= 1e-6*math.Max(1, math.Abs(want))
+}
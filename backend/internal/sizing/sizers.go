@@ -0,0 +1,93 @@
+package sizing
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// fixedNotional buys the same USD amount every time
+type fixedNotional struct {
+	notional decimal.Decimal // Zero means the max position size
+}
+
+func (s *fixedNotional) Size(in Input) (decimal.Decimal, error) {
+	notional := s.notional
+	if notional.IsZero() {
+		notional = in.MaxNotionalUSD
+	}
+	return notionalToQuantity(notional, in.Price)
+}
+
+// fixedFraction invests a fixed percentage of equity
+type fixedFraction struct {
+	percent decimal.Decimal
+}
+
+func (s *fixedFraction) Size(in Input) (decimal.Decimal, error) {
+	return notionalToQuantity(percentOf(in.Equity, s.percent), in.Price)
+}
+
+// riskPerTrade sizes so that hitting the stop loses a percentage of equity
+type riskPerTrade struct {
+	percent decimal.Decimal
+}
+
+func (s *riskPerTrade) Size(in Input) (decimal.Decimal, error) {
+	stopDistance := in.Price.Sub(in.StopLossPrice).Abs()
+	if stopDistance.IsZero() {
+		return decimal.Zero, fmt.Errorf("risk-per-trade sizing requires a stop-loss")
+	}
+	return percentOf(in.Equity, s.percent).Div(stopDistance), nil
+}
+
+// volatility sizes so that a move of a multiple of ATR loses a percentage of equity
+type volatility struct {
+	percent  decimal.Decimal
+	multiple decimal.Decimal
+}
+
+func (s *volatility) Size(in Input) (decimal.Decimal, error) {
+	move := in.ATR.Mul(s.multiple)
+	if !move.IsPositive() {
+		return decimal.Zero, fmt.Errorf("volatility sizing requires ATR")
+	}
+	return percentOf(in.Equity, s.percent).Div(move), nil
+}
+
+// kelly invests a fraction of the Kelly criterion, f = W - (1-W)/R, where W
+// is the win rate and R the ratio of average win to average loss
+type kelly struct {
+	fraction    decimal.Decimal
+	maxPercent  decimal.Decimal
+	minTrades   int
+	warmupSizer Sizer // Used until enough trades have closed
+}
+
+func (s *kelly) Size(in Input) (decimal.Decimal, error) {
+	trades := in.Stats.Wins + in.Stats.Losses
+	if trades < s.minTrades || in.Stats.Wins == 0 || in.Stats.Losses == 0 || in.Stats.AvgLoss.IsZero() {
+		return s.warmupSizer.Size(in)
+	}
+
+	winRate := decimal.NewFromInt(int64(in.Stats.Wins)).Div(decimal.NewFromInt(int64(trades)))
+	payoff := in.Stats.AvgWin.Div(in.Stats.AvgLoss)
+	edge := winRate.Sub(decimal.NewFromInt(1).Sub(winRate).Div(payoff))
+	if !edge.IsPositive() {
+		return decimal.Zero, nil // No edge: don't trade
+	}
+
+	percent := edge.Mul(s.fraction).Mul(decimal.NewFromInt(100))
+	if percent.GreaterThan(s.maxPercent) {
+		percent = s.maxPercent
+	}
+
+	return notionalToQuantity(percentOf(in.Equity, percent), in.Price)
+}
+
+func notionalToQuantity(notional, price decimal.Decimal) (decimal.Decimal, error) {
+	if !price.IsPositive() {
+		return decimal.Zero, fmt.Errorf("invalid price: %s", price.String())
+	}
+	return notional.Div(price), nil
+}
@@ -0,0 +1,175 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestSizers(t *testing.T) {
+	kellyConfig := Config{
+		Type:               TypeKelly,
+		KellyFraction:      0.5,
+		MaxFractionPercent: 15,
+		FractionPercent:    5,
+		MinTrades:          10,
+	}
+
+	tests := []struct {
+		name    string
+		config  Config
+		in      Input
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "fixed notional defaults to the max position size",
+			config: Config{Type: TypeFixedNotional},
+			in:     Input{Price: dec("50"), MaxNotionalUSD: dec("1000")},
+			want:   "20",
+		},
+		{
+			name:   "fixed notional",
+			config: Config{Type: TypeFixedNotional, NotionalUSD: 500},
+			in:     Input{Price: dec("50"), MaxNotionalUSD: dec("1000")},
+			want:   "10",
+		},
+		{
+			name:    "fixed notional without a price",
+			config:  Config{Type: TypeFixedNotional},
+			in:      Input{MaxNotionalUSD: dec("1000")},
+			wantErr: true,
+		},
+		{
+			name:   "fixed fraction",
+			config: Config{Type: TypeFixedFraction, FractionPercent: 10},
+			in:     Input{Price: dec("100"), Equity: dec("10000")},
+			want:   "10",
+		},
+		{
+			name:   "risk per trade long",
+			config: Config{Type: TypeRiskPerTrade, RiskPercent: 1},
+			in:     Input{Price: dec("100"), StopLossPrice: dec("95"), Equity: dec("10000")},
+			want:   "20",
+		},
+		{
+			name:   "risk per trade short",
+			config: Config{Type: TypeRiskPerTrade, RiskPercent: 1},
+			in:     Input{Price: dec("100"), StopLossPrice: dec("104"), Equity: dec("10000")},
+			want:   "25",
+		},
+		{
+			name:    "risk per trade with the stop at the price",
+			config:  Config{Type: TypeRiskPerTrade, RiskPercent: 1},
+			in:      Input{Price: dec("100"), StopLossPrice: dec("100"), Equity: dec("10000")},
+			wantErr: true,
+		},
+		{
+			name:   "volatility",
+			config: Config{Type: TypeVolatility, RiskPercent: 1, ATRPeriod: 14, ATRMultiple: 2.5},
+			in:     Input{Price: dec("100"), ATR: dec("2"), Equity: dec("10000")},
+			want:   "20",
+		},
+		{
+			name:    "volatility without ATR",
+			config:  Config{Type: TypeVolatility, RiskPercent: 1, ATRPeriod: 14, ATRMultiple: 2.5},
+			in:      Input{Price: dec("100"), Equity: dec("10000")},
+			wantErr: true,
+		},
+		{
+			name:   "kelly warms up with the fixed fraction",
+			config: kellyConfig,
+			in: Input{Price: dec("100"), Equity: dec("10000"), Stats: TradeStats{
+				Wins: 4, Losses: 1, AvgWin: dec("200"), AvgLoss: dec("100"),
+			}},
+			want: "5",
+		},
+		{
+			name:   "kelly warms up until a trade is lost",
+			config: kellyConfig,
+			in: Input{Price: dec("100"), Equity: dec("10000"), Stats: TradeStats{
+				Wins: 12, AvgWin: dec("200"),
+			}},
+			want: "5",
+		},
+		{
+			name:   "kelly takes a fraction of the edge",
+			config: kellyConfig,
+			in: Input{Price: dec("100"), Equity: dec("10000"), Stats: TradeStats{
+				// Edge 0.6 - 0.4/1 = 0.2, half of it is 10% of equity
+				Wins: 6, Losses: 4, AvgWin: dec("100"), AvgLoss: dec("100"),
+			}},
+			want: "10",
+		},
+		{
+			name:   "kelly is capped",
+			config: kellyConfig,
+			in: Input{Price: dec("100"), Equity: dec("10000"), Stats: TradeStats{
+				// Edge 0.6 - 0.4/2 = 0.4, half of it is capped at 15%
+				Wins: 6, Losses: 4, AvgWin: dec("200"), AvgLoss: dec("100"),
+			}},
+			want: "15",
+		},
+		{
+			name:   "kelly skips trades without an edge",
+			config: kellyConfig,
+			in: Input{Price: dec("100"), Equity: dec("10000"), Stats: TradeStats{
+				Wins: 3, Losses: 7, AvgWin: dec("100"), AvgLoss: dec("100"),
+			}},
+			want: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sizer, err := New(tt.config)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			got, err := sizer.Size(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Size = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Size: %v", err)
+			}
+			if !got.Equal(dec(tt.want)) {
+				t.Errorf("Size = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"default", DefaultConfig(), false},
+		{"negative notional", Config{Type: TypeFixedNotional, NotionalUSD: -1}, true},
+		{"fraction over 100", Config{Type: TypeFixedFraction, FractionPercent: 101}, true},
+		{"zero risk", Config{Type: TypeRiskPerTrade}, true},
+		{"volatility without ATR period", Config{Type: TypeVolatility, RiskPercent: 1, ATRMultiple: 2}, true},
+		{"volatility without ATR multiple", Config{Type: TypeVolatility, RiskPercent: 1, ATRPeriod: 14}, true},
+		{"kelly fraction over 1", Config{Type: TypeKelly, KellyFraction: 1.5, MaxFractionPercent: 10, FractionPercent: 5, MinTrades: 10}, true},
+		{"kelly without min trades", Config{Type: TypeKelly, KellyFraction: 0.5, MaxFractionPercent: 10, FractionPercent: 5}, true},
+		{"unknown type", Config{Type: "martingale"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
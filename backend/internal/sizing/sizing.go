@@ -0,0 +1,165 @@
+package sizing
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Sizer types
+const (
+	TypeFixedNotional = "fixed_notional"
+	TypeFixedFraction = "fixed_fraction"
+	TypeRiskPerTrade  = "risk_per_trade"
+	TypeVolatility    = "volatility"
+	TypeKelly         = "kelly"
+)
+
+// Input holds the market and account state a sizer sizes a position from
+type Input struct {
+	Price          decimal.Decimal // Expected entry price
+	StopLossPrice  decimal.Decimal
+	Equity         decimal.Decimal // Account equity in USD
+	MaxNotionalUSD decimal.Decimal // Position size limit
+	ATR            decimal.Decimal // Only loaded for volatility sizing
+	Stats          TradeStats      // Only loaded for Kelly sizing
+}
+
+// TradeStats summarizes the closed trades of a strategy
+type TradeStats struct {
+	Wins    int
+	Losses  int
+	AvgWin  decimal.Decimal
+	AvgLoss decimal.Decimal // Positive amount
+}
+
+// Sizer calculates the quantity of a new position
+type Sizer interface {
+	// Size returns the quantity to trade, zero to skip the trade
+	Size(in Input) (decimal.Decimal, error)
+}
+
+// Config selects and configures a sizer. It is stored in a strategy's config.
+type Config struct {
+	Type string `json:"type"`
+
+	// fixed_notional: USD per position, defaults to the max position size
+	NotionalUSD float64 `json:"notional_usd,omitempty"`
+
+	// fixed_fraction, and kelly until MinTrades trades have closed:
+	// percentage of equity per position
+	FractionPercent float64 `json:"fraction_percent,omitempty"`
+
+	// risk_per_trade and volatility: percentage of equity lost if the stop
+	// is hit, or if the price moves ATRMultiple ATRs
+	RiskPercent float64 `json:"risk_percent,omitempty"`
+
+	// volatility: ATR of the latest ATRPeriod 1m candles
+	ATRPeriod   int     `json:"atr_period,omitempty"`
+	ATRMultiple float64 `json:"atr_multiple,omitempty"`
+
+	// kelly: fraction of the Kelly bet to take, capped at MaxFractionPercent of equity
+	KellyFraction      float64 `json:"kelly_fraction,omitempty"`
+	MaxFractionPercent float64 `json:"max_fraction_percent,omitempty"`
+	MinTrades          int     `json:"min_trades,omitempty"`
+}
+
+// DefaultConfig returns the sizing used before sizers were configurable
+func DefaultConfig() Config {
+	return Config{Type: TypeFixedNotional}
+}
+
+// Validate validates the sizing configuration
+func (c Config) Validate() error {
+	switch c.Type {
+	case TypeFixedNotional:
+		if c.NotionalUSD < 0 {
+			return fmt.Errorf("sizing notional_usd must not be negative")
+		}
+	case TypeFixedFraction:
+		if err := validatePercent("fraction_percent", c.FractionPercent); err != nil {
+			return err
+		}
+	case TypeRiskPerTrade:
+		if err := validatePercent("risk_percent", c.RiskPercent); err != nil {
+			return err
+		}
+	case TypeVolatility:
+		if err := validatePercent("risk_percent", c.RiskPercent); err != nil {
+			return err
+		}
+		if c.ATRPeriod < 1 || c.ATRPeriod > 500 {
+			return fmt.Errorf("sizing atr_period must be between 1 and 500")
+		}
+		if c.ATRMultiple <= 0 {
+			return fmt.Errorf("sizing atr_multiple must be positive")
+		}
+	case TypeKelly:
+		if c.KellyFraction <= 0 || c.KellyFraction > 1 {
+			return fmt.Errorf("sizing kelly_fraction must be greater than 0 and at most 1")
+		}
+		if err := validatePercent("max_fraction_percent", c.MaxFractionPercent); err != nil {
+			return err
+		}
+		if err := validatePercent("fraction_percent", c.FractionPercent); err != nil {
+			return err
+		}
+		if c.MinTrades < 1 {
+			return fmt.Errorf("sizing min_trades must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown sizing type: %s", c.Type)
+	}
+
+	return nil
+}
+
+// NeedsATR returns true if the sizer uses Input.ATR
+func (c Config) NeedsATR() bool {
+	return c.Type == TypeVolatility
+}
+
+// NeedsTradeStats returns true if the sizer uses Input.Stats
+func (c Config) NeedsTradeStats() bool {
+	return c.Type == TypeKelly
+}
+
+// New creates the sizer selected by the config
+func New(c Config) (Sizer, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch c.Type {
+	case TypeFixedFraction:
+		return &fixedFraction{percent: decimal.NewFromFloat(c.FractionPercent)}, nil
+	case TypeRiskPerTrade:
+		return &riskPerTrade{percent: decimal.NewFromFloat(c.RiskPercent)}, nil
+	case TypeVolatility:
+		return &volatility{
+			percent:  decimal.NewFromFloat(c.RiskPercent),
+			multiple: decimal.NewFromFloat(c.ATRMultiple),
+		}, nil
+	case TypeKelly:
+		return &kelly{
+			fraction:    decimal.NewFromFloat(c.KellyFraction),
+			maxPercent:  decimal.NewFromFloat(c.MaxFractionPercent),
+			minTrades:   c.MinTrades,
+			warmupSizer: &fixedFraction{percent: decimal.NewFromFloat(c.FractionPercent)},
+		}, nil
+	default:
+		return &fixedNotional{notional: decimal.NewFromFloat(c.NotionalUSD)}, nil
+	}
+}
+
+func validatePercent(name string, value float64) error {
+	if value <= 0 || value > 100 {
+		return fmt.Errorf("sizing %s must be greater than 0 and at most 100", name)
+	}
+	return nil
+}
+
+// percentOf returns percent% of value
+func percentOf(value, percent decimal.Decimal) decimal.Decimal {
+	return value.Mul(percent).Div(decimal.NewFromInt(100))
+}
@@ -1,6 +1,9 @@
 package strategy
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"math"
 
 	"github.com/shopspring/decimal"
@@ -174,3 +177,40 @@ func StdDev(prices []decimal.Decimal, period int) float64 {
 	variance = variance / float64(period)
 	return math.Sqrt(variance)
 }
+
+// LoadATR calculates the ATR of a symbol from its latest 1m candles
+func LoadATR(ctx context.Context, db *sql.DB, symbol string, period int) (decimal.Decimal, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT high, low, close FROM price_data
+		WHERE symbol = $1 AND interval = '1m'
+		ORDER BY time DESC
+		LIMIT $2
+	`, symbol, period+1)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to load candles: %w", err)
+	}
+	defer rows.Close()
+
+	var highs, lows, closes []decimal.Decimal
+	for rows.Next() {
+		var high, low, close decimal.Decimal
+		if err := rows.Scan(&high, &low, &close); err != nil {
+			return decimal.Zero, fmt.Errorf("failed to scan candle: %w", err)
+		}
+		highs = append(highs, high)
+		lows = append(lows, low)
+		closes = append(closes, close)
+	}
+	if err := rows.Err(); err != nil {
+		return decimal.Zero, err
+	}
+
+	// Candles are newest first; ATR expects chronological order
+	for i, j := 0, len(closes)-1; i < j; i, j = i+1, j-1 {
+		highs[i], highs[j] = highs[j], highs[i]
+		lows[i], lows[j] = lows[j], lows[i]
+		closes[i], closes[j] = closes[j], closes[i]
+	}
+
+	return ATR(highs, lows, closes, period), nil
+}
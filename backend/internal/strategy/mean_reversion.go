@@ -4,36 +4,51 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/crypto-trading-bot/internal/config"
 	"github.com/crypto-trading-bot/internal/events"
+	"github.com/crypto-trading-bot/internal/exchange"
 	"github.com/crypto-trading-bot/internal/models"
+	"github.com/crypto-trading-bot/internal/sizing"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 )
 
-// MeanReversionStrategy implements a mean reversion trading strategy
+// EquitySource values the account that entries are sized against
+type EquitySource interface {
+	// Equity returns the account equity in USD
+	Equity(ctx context.Context) (decimal.Decimal, error)
+}
+
+// MeanReversionStrategy implements a mean reversion trading strategy.
+// Each instance trades a single symbol with its own price history.
 type MeanReversionStrategy struct {
 	strategyID uuid.UUID
 	symbol     string
 	db         *sql.DB
-	nats       *events.NATSClient
+	bus        events.Bus
+	exchange   exchange.Exchange
+	equity     EquitySource
 	logger     *logrus.Entry
 	config     *config.Config
 
-	// Strategy parameters
-	smaPeriod     int
-	rsiPeriod     int
-	bbPeriod      int
-	bbStdDev      float64
-	rsiOversold   float64
-	rsiOverbought float64
-
-	// Price history
+	// Strategy parameters and price history, guarded by mu so parameters
+	// can be hot-reloaded while prices are being processed
+	params         MeanReversionParams
 	priceHistory   []decimal.Decimal
 	maxHistorySize int
+	mu             sync.Mutex
+
+	// active gates new entries; exits are still managed while paused
+	active atomic.Bool
+
+	// riskConfig holds the runtime risk limits of this strategy, nil until set
+	riskConfig atomic.Pointer[config.RiskConfig]
 }
 
 // NewMeanReversionStrategy creates a new mean reversion strategy
@@ -41,55 +56,133 @@ func NewMeanReversionStrategy(
 	strategyID uuid.UUID,
 	symbol string,
 	db *sql.DB,
-	natsClient *events.NATSClient,
+	bus events.Bus,
+	exch exchange.Exchange,
+	equity EquitySource,
 	cfg *config.Config,
+	params MeanReversionParams,
 	logger *logrus.Logger,
 ) *MeanReversionStrategy {
+	strategyLogger := logger.WithFields(logrus.Fields{
+		"component":   "mean-reversion-strategy",
+		"strategy_id": strategyID,
+		"symbol":      symbol,
+	})
+
 	return &MeanReversionStrategy{
 		strategyID:     strategyID,
 		symbol:         symbol,
 		db:             db,
-		nats:           natsClient,
-		logger:         logger.WithField("component", "mean-reversion-strategy"),
+		bus:            bus,
+		exchange:       exch,
+		equity:         equity,
+		logger:         strategyLogger,
 		config:         cfg,
-		smaPeriod:      20,
-		rsiPeriod:      14,
-		bbPeriod:       20,
-		bbStdDev:       2.0,
-		rsiOversold:    30.0,
-		rsiOverbought:  70.0,
-		priceHistory:   make([]decimal.Decimal, 0, 100),
-		maxHistorySize: 100,
+		params:         params,
+		priceHistory:   make([]decimal.Decimal, 0, params.historySize()),
+		maxHistorySize: params.historySize(),
 	}
 }
 
+// StrategyID returns the strategy ID of this instance
+func (mrs *MeanReversionStrategy) StrategyID() uuid.UUID {
+	return mrs.strategyID
+}
+
+// Symbol returns the symbol traded by this instance
+func (mrs *MeanReversionStrategy) Symbol() string {
+	return mrs.symbol
+}
+
+// SetActive enables or pauses new entries for this strategy
+func (mrs *MeanReversionStrategy) SetActive(active bool) {
+	if mrs.active.Swap(active) != active {
+		mrs.logger.WithField("active", active).Info("Strategy state changed")
+	}
+}
+
+// SetRiskConfig sets the risk limits used to size entries and place stops
+func (mrs *MeanReversionStrategy) SetRiskConfig(cfg *config.RiskConfig) {
+	mrs.riskConfig.Store(cfg)
+}
+
+// risk returns the runtime risk limits, or the configured ones if unset
+func (mrs *MeanReversionStrategy) risk() *config.RiskConfig {
+	if cfg := mrs.riskConfig.Load(); cfg != nil {
+		return cfg
+	}
+	return &mrs.config.Risk
+}
+
+// IsActive returns true if the strategy may open new positions
+func (mrs *MeanReversionStrategy) IsActive() bool {
+	return mrs.active.Load()
+}
+
+// Params returns the current strategy parameters
+func (mrs *MeanReversionStrategy) Params() MeanReversionParams {
+	mrs.mu.Lock()
+	defer mrs.mu.Unlock()
+
+	return mrs.params
+}
+
+// UpdateParams replaces the strategy parameters, keeping the price history
+func (mrs *MeanReversionStrategy) UpdateParams(params MeanReversionParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	mrs.mu.Lock()
+	defer mrs.mu.Unlock()
+
+	mrs.params = params
+	mrs.maxHistorySize = params.historySize()
+
+	mrs.logger.WithFields(logrus.Fields{
+		"sma_period":     params.SMAPeriod,
+		"rsi_period":     params.RSIPeriod,
+		"bb_period":      params.BBPeriod,
+		"bb_std_dev":     params.BBStdDev,
+		"rsi_oversold":   params.RSIOversold,
+		"rsi_overbought": params.RSIOverbought,
+	}).Info("Strategy parameters updated")
+
+	return nil
+}
+
 // OnPriceUpdate handles price updates and generates signals
 func (mrs *MeanReversionStrategy) OnPriceUpdate(ctx context.Context, update *events.PriceUpdateEvent) error {
 	if update.Symbol != mrs.symbol {
 		return nil // Ignore other symbols
 	}
 
-	// Add price to history
+	// Add price to history and take a snapshot to calculate indicators from
 	price := decimal.NewFromFloat(update.Price)
+
+	mrs.mu.Lock()
 	mrs.priceHistory = append(mrs.priceHistory, price)
 
 	// Keep history size manageable
-	if len(mrs.priceHistory) > mrs.maxHistorySize {
-		mrs.priceHistory = mrs.priceHistory[1:]
-	}
+	mrs.priceHistory = latestPrices(mrs.priceHistory, mrs.maxHistorySize)
+
+	params := mrs.params
+	priceHistory := make([]decimal.Decimal, len(mrs.priceHistory))
+	copy(priceHistory, mrs.priceHistory)
+	mrs.mu.Unlock()
 
 	// Need enough data to calculate indicators
-	if len(mrs.priceHistory) < mrs.bbPeriod {
+	if len(priceHistory) < params.warmupPeriod() {
 		mrs.logger.Debug("Not enough price history yet")
 		return nil
 	}
 
 	// Calculate indicators
-	sma := SMA(mrs.priceHistory, mrs.smaPeriod)
-	rsi := RSI(mrs.priceHistory, mrs.rsiPeriod)
-	upperBB, _, lowerBB := BollingerBands(mrs.priceHistory, mrs.bbPeriod, mrs.bbStdDev)
+	sma := SMA(priceHistory, params.SMAPeriod)
+	rsi := RSI(priceHistory, params.RSIPeriod)
+	upperBB, _, lowerBB := BollingerBands(priceHistory, params.BBPeriod, params.BBStdDev)
 
-	currentPrice := mrs.priceHistory[len(mrs.priceHistory)-1]
+	currentPrice := priceHistory[len(priceHistory)-1]
 
 	mrs.logger.WithFields(logrus.Fields{
 		"price":    currentPrice.String(),
@@ -100,73 +193,167 @@ func (mrs *MeanReversionStrategy) OnPriceUpdate(ctx context.Context, update *eve
 	}).Debug("Indicators calculated")
 
 	// Check if we have an open position
-	hasOpenPosition, err := mrs.hasOpenPosition(ctx)
+	position, err := mrs.openPosition(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to check open position: %w", err)
 	}
 
+	// Orders still on their way to the exchange haven't changed the
+	// position yet; wait for them rather than signal again
+	inFlight, err := mrs.ordersInFlight(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check orders in flight: %w", err)
+	}
+
 	// Generate entry signals
-	if !hasOpenPosition {
+	if position == nil {
+		// Paused strategies keep their indicators warm but don't enter
+		if !mrs.IsActive() || inFlight.entries > 0 {
+			return nil
+		}
+
 		// LONG signal: RSI < 30 AND price < lower Bollinger Band
-		if rsi < mrs.rsiOversold && currentPrice.LessThan(lowerBB) {
-			return mrs.generateLongSignal(ctx, currentPrice, sma, rsi, lowerBB, update.Price)
+		if rsi < params.RSIOversold && currentPrice.LessThan(lowerBB) {
+			reason := fmt.Sprintf("Mean reversion LONG: RSI=%.2f (< %.0f), Price=%.2f < LowerBB=%.2f",
+				rsi, params.RSIOversold, currentPrice.InexactFloat64(), lowerBB.InexactFloat64())
+			return mrs.generateEntrySignal(ctx, params, models.PositionIntentOpenLong, currentPrice, decimal.Zero, reason, map[string]float64{
+				"price":    update.Price,
+				"sma":      sma.InexactFloat64(),
+				"rsi":      rsi,
+				"upper_bb": 0, // Not needed for long
+				"lower_bb": lowerBB.InexactFloat64(),
+			})
 		}
 
-		// SHORT signal: RSI > 70 AND price > upper Bollinger Band
-		// Note: For crypto spot trading, we typically don't short
-		// This would be used for futures/margin trading
-		if rsi > mrs.rsiOverbought && currentPrice.GreaterThan(upperBB) {
-			mrs.logger.WithFields(logrus.Fields{
+		// SHORT signal: RSI > 70 AND price > upper Bollinger Band.
+		// Shorting borrows the asset, so it is opt-in per strategy.
+		if rsi > params.RSIOverbought && currentPrice.GreaterThan(upperBB) {
+			if !params.AllowShort {
+				mrs.logger.WithFields(logrus.Fields{
+					"rsi":      rsi,
+					"price":    currentPrice.String(),
+					"upper_bb": upperBB.String(),
+				}).Debug("SHORT signal detected (skipping - allow_short is disabled)")
+				return nil
+			}
+
+			reason := fmt.Sprintf("Mean reversion SHORT: RSI=%.2f (> %.0f), Price=%.2f > UpperBB=%.2f",
+				rsi, params.RSIOverbought, currentPrice.InexactFloat64(), upperBB.InexactFloat64())
+			return mrs.generateEntrySignal(ctx, params, models.PositionIntentOpenShort, currentPrice, decimal.Zero, reason, map[string]float64{
+				"price":    update.Price,
+				"sma":      sma.InexactFloat64(),
 				"rsi":      rsi,
-				"price":    currentPrice.String(),
-				"upper_bb": upperBB.String(),
-			}).Info("SHORT signal detected (skipping - spot trading only)")
+				"upper_bb": upperBB.InexactFloat64(),
+				"lower_bb": 0, // Not needed for short
+			})
 		}
 	} else {
-		// Check exit conditions for open position
-		return mrs.checkExitConditions(ctx, currentPrice, sma)
+		if inFlight.exits > 0 {
+			return nil
+		}
+
+		// Check exit conditions for open position, then whether to add to it
+		exited, err := mrs.checkExitConditions(ctx, params, position, currentPrice, sma, upperBB, lowerBB)
+		if err != nil || exited {
+			return err
+		}
+		if mrs.IsActive() && inFlight.entries == 0 {
+			return mrs.checkScaleIn(ctx, params, position, currentPrice, rsi, sma, upperBB, lowerBB)
+		}
 	}
 
 	return nil
 }
 
-// generateLongSignal generates a long entry signal
-func (mrs *MeanReversionStrategy) generateLongSignal(
+// checkScaleIn adds a tranche to the open position when the entry condition
+// holds again and the price has moved the configured step from the last
+// entry. Positions that have started taking profit aren't added to.
+func (mrs *MeanReversionStrategy) checkScaleIn(
 	ctx context.Context,
+	params MeanReversionParams,
+	position *openPosition,
 	currentPrice decimal.Decimal,
-	sma decimal.Decimal,
 	rsi float64,
-	lowerBB decimal.Decimal,
-	priceFloat float64,
+	sma, upperBB, lowerBB decimal.Decimal,
 ) error {
-	// Calculate position size
-	positionSizeUSD := decimal.NewFromFloat(mrs.config.Risk.MaxPositionSizeUSD)
-	quantity := positionSizeUSD.Div(currentPrice)
+	if position.tranches >= params.ScaleIn.MaxTranches || position.takeProfitHits > 0 {
+		return nil
+	}
 
-	// Calculate stop-loss (2% below entry)
-	stopLossPrice := currentPrice.Mul(
-		decimal.NewFromFloat(1.0 - mrs.config.Risk.StopLossPercent/100.0),
-	)
+	entryCondition := rsi < params.RSIOversold && currentPrice.LessThan(lowerBB)
+	if position.side == models.TradeSideShort {
+		entryCondition = rsi > params.RSIOverbought && currentPrice.GreaterThan(upperBB)
+	}
+	if !entryCondition || !position.lastEntryPrice.IsPositive() {
+		return nil
+	}
+
+	// Percent the price has moved against the last entry
+	adverseMove := position.lastEntryPrice.Sub(currentPrice).Div(position.lastEntryPrice).Mul(decimal.NewFromInt(100))
+	if position.side == models.TradeSideShort {
+		adverseMove = adverseMove.Neg()
+	}
+	step := decimal.NewFromFloat(params.ScaleIn.StepPercent)
+	if (step.IsPositive() && adverseMove.LessThan(step)) || (step.IsNegative() && adverseMove.GreaterThan(step)) {
+		return nil
+	}
+
+	intent := models.OpenIntent(position.side)
+	reason := fmt.Sprintf("Mean reversion %s scale-in %d/%d: RSI=%.2f, Price=%.2f, %.2f%% from last entry %.2f",
+		position.side, position.tranches+1, params.ScaleIn.MaxTranches, rsi,
+		currentPrice.InexactFloat64(), adverseMove.Neg().InexactFloat64(), position.lastEntryPrice.InexactFloat64())
+
+	return mrs.generateEntrySignal(ctx, params, intent, currentPrice, position.notional, reason, map[string]float64{
+		"price":    currentPrice.InexactFloat64(),
+		"sma":      sma.InexactFloat64(),
+		"rsi":      rsi,
+		"upper_bb": upperBB.InexactFloat64(),
+		"lower_bb": lowerBB.InexactFloat64(),
+		"tranche":  float64(position.tranches + 1),
+	})
+}
+
+// generateEntrySignal generates a signal opening a long or short position,
+// or adding a tranche to one with the given open notional
+func (mrs *MeanReversionStrategy) generateEntrySignal(
+	ctx context.Context,
+	params MeanReversionParams,
+	intent models.PositionIntent,
+	currentPrice decimal.Decimal,
+	openNotional decimal.Decimal,
+	reason string,
+	indicators map[string]float64,
+) error {
+	// Calculate stop-loss (2% below entry for longs, above for shorts)
+	stopLossOffset := mrs.risk().StopLossPercent / 100.0
+	if intent.TradeSide() == models.TradeSideShort {
+		stopLossOffset = -stopLossOffset
+	}
+	stopLossPrice := currentPrice.Mul(decimal.NewFromFloat(1.0 - stopLossOffset))
+
+	// Calculate position size
+	quantity, err := mrs.positionSize(ctx, params.Sizing, currentPrice, stopLossPrice, openNotional)
+	if err != nil {
+		return fmt.Errorf("failed to size position: %w", err)
+	}
+	if !quantity.IsPositive() {
+		mrs.logger.WithField("sizing", params.Sizing.Type).Info("Position sizer returned zero size, skipping entry")
+		return nil
+	}
 
 	// Create trade signal
 	signal := &models.TradeSignal{
 		ID:            uuid.New(),
 		StrategyID:    mrs.strategyID,
 		Symbol:        mrs.symbol,
-		Side:          models.OrderSideBuy,
+		Side:          intent.OrderSide(),
+		Intent:        intent,
 		Type:          models.OrderTypeMarket,
 		Quantity:      quantity,
 		StopLossPrice: stopLossPrice,
-		Reason: fmt.Sprintf("Mean reversion LONG: RSI=%.2f (< %.0f), Price=%.2f < LowerBB=%.2f",
-			rsi, mrs.rsiOversold, currentPrice.InexactFloat64(), lowerBB.InexactFloat64()),
-		Indicators: map[string]float64{
-			"price":    priceFloat,
-			"sma":      sma.InexactFloat64(),
-			"rsi":      rsi,
-			"upper_bb": 0, // Not needed for long
-			"lower_bb": lowerBB.InexactFloat64(),
-		},
-		Timestamp: time.Now(),
+		Reason:        reason,
+		Indicators:    indicators,
+		Timestamp:     time.Now(),
 	}
 
 	// Publish signal event
@@ -175,6 +362,7 @@ func (mrs *MeanReversionStrategy) generateLongSignal(
 		StrategyID:    signal.StrategyID.String(),
 		Symbol:        signal.Symbol,
 		Side:          string(signal.Side),
+		Intent:        string(signal.Intent),
 		Type:          string(signal.Type),
 		Quantity:      quantity.InexactFloat64(),
 		StopLossPrice: stopLossPrice.InexactFloat64(),
@@ -182,87 +370,210 @@ func (mrs *MeanReversionStrategy) generateLongSignal(
 		Indicators:    signal.Indicators,
 	}
 
-	if err := mrs.nats.Publish(events.EventTypeTradeSignal, signalEvent); err != nil {
+	if err := mrs.bus.Publish(events.EventTypeTradeSignal, signalEvent); err != nil {
 		return fmt.Errorf("failed to publish signal: %w", err)
 	}
 
 	mrs.logger.WithFields(logrus.Fields{
 		"signal_id": signal.ID,
 		"side":      signal.Side,
+		"intent":    signal.Intent,
 		"quantity":  quantity.String(),
+		"sizing":    params.Sizing.Type,
 		"stop_loss": stopLossPrice.String(),
-		"rsi":       rsi,
+		"rsi":       indicators["rsi"],
 		"price":     currentPrice.String(),
-		"lower_bb":  lowerBB.String(),
-	}).Info("LONG signal generated")
+	}).Infof("%s signal generated", intent.TradeSide())
 
 	return nil
 }
 
-// checkExitConditions checks if we should exit an open position
-func (mrs *MeanReversionStrategy) checkExitConditions(
+// positionSize sizes an entry with the configured sizer, capped at the max
+// position size and at the notional the position has left
+func (mrs *MeanReversionStrategy) positionSize(
 	ctx context.Context,
-	currentPrice decimal.Decimal,
-	sma decimal.Decimal,
-) error {
-	// Get open trade
-	var trade models.Trade
-	var metadataJSON []byte
+	sizingConfig sizing.Config,
+	price decimal.Decimal,
+	stopLossPrice decimal.Decimal,
+	openNotional decimal.Decimal,
+) (decimal.Decimal, error) {
+	sizer, err := sizing.New(sizingConfig)
+	if err != nil {
+		return decimal.Zero, err
+	}
 
-	err := mrs.db.QueryRowContext(ctx, `
-		SELECT id, strategy_id, symbol, entry_price, quantity, side, entry_time, metadata
-		FROM trades
-		WHERE strategy_id = $1 AND exit_time IS NULL
-		LIMIT 1
-	`, mrs.strategyID).Scan(
-		&trade.ID,
-		&trade.StrategyID,
-		&trade.Symbol,
-		&trade.EntryPrice,
-		&trade.Quantity,
-		&trade.Side,
-		&trade.EntryTime,
-		&metadataJSON,
+	riskConfig := mrs.risk()
+	maxNotional := decimal.Min(
+		decimal.NewFromFloat(riskConfig.MaxPositionSizeUSD),
+		decimal.NewFromFloat(riskConfig.PositionNotionalLimit()).Sub(openNotional),
 	)
+	if !maxNotional.IsPositive() {
+		return decimal.Zero, nil
+	}
+	// Equity is valued the same way the risk checks on the entry value it
+	equity, err := mrs.equity.Equity(ctx)
+	if err != nil {
+		return decimal.Zero, err
+	}
 
-	if err == sql.ErrNoRows {
-		return nil // No open position
+	input := sizing.Input{
+		Equity:         equity,
+		Price:          price,
+		StopLossPrice:  stopLossPrice,
+		MaxNotionalUSD: maxNotional,
 	}
+
+	if sizingConfig.NeedsATR() {
+		input.ATR, err = LoadATR(ctx, mrs.db, mrs.symbol, sizingConfig.ATRPeriod)
+		if err != nil {
+			return decimal.Zero, err
+		}
+	}
+
+	if sizingConfig.NeedsTradeStats() {
+		err = mrs.db.QueryRowContext(ctx, `
+			SELECT
+				COUNT(*) FILTER (WHERE pnl > 0),
+				COUNT(*) FILTER (WHERE pnl < 0),
+				COALESCE(AVG(pnl) FILTER (WHERE pnl > 0), 0),
+				COALESCE(AVG(-pnl) FILTER (WHERE pnl < 0), 0)
+			FROM trades
+			WHERE strategy_id = $1 AND exit_time IS NOT NULL
+		`, mrs.strategyID).Scan(&input.Stats.Wins, &input.Stats.Losses, &input.Stats.AvgWin, &input.Stats.AvgLoss)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("failed to get trade stats: %w", err)
+		}
+	}
+
+	quantity, err := sizer.Size(input)
 	if err != nil {
-		return fmt.Errorf("failed to get open trade: %w", err)
+		return decimal.Zero, err
+	}
+
+	if maxQuantity := maxNotional.Div(price); quantity.GreaterThan(maxQuantity) {
+		quantity = maxQuantity
 	}
 
-	// Exit condition: Price crosses SMA
-	if currentPrice.GreaterThan(sma) {
-		mrs.logger.WithFields(logrus.Fields{
-			"trade_id":      trade.ID,
-			"entry_price":   trade.EntryPrice.String(),
-			"current_price": currentPrice.String(),
-			"sma":           sma.String(),
-		}).Info("EXIT signal: Price crossed SMA")
+	return quantity, nil
+}
+
+// checkExitConditions checks whether the open position reached its next
+// take-profit target and exits the target's share of it. It reports whether
+// an exit signal was generated.
+func (mrs *MeanReversionStrategy) checkExitConditions(
+	ctx context.Context,
+	params MeanReversionParams,
+	position *openPosition,
+	currentPrice decimal.Decimal,
+	sma, upperBB, lowerBB decimal.Decimal,
+) (bool, error) {
+	targets := params.takeProfitTargets()
+	hit := position.takeProfitHits
+	if hit >= len(targets) {
+		hit = len(targets) - 1 // Targets were removed since the last hit
+	}
+	target := targets[hit]
+
+	// Exit condition: Price crosses the target (upwards for longs, downwards for shorts)
+	level := sma
+	if target.Target == TargetBand {
+		level = upperBB
+		if position.side == models.TradeSideShort {
+			level = lowerBB
+		}
+	}
+	crossed := currentPrice.GreaterThan(level)
+	if position.side == models.TradeSideShort {
+		crossed = currentPrice.LessThan(level)
+	}
+	if !crossed {
+		// Stop-loss check is handled by Risk Manager
+		// Max hold time check is handled by Risk Manager
+		return false, nil
+	}
 
-		// Generate exit signal
-		return mrs.generateExitSignal(ctx, &trade, currentPrice, "Price crossed SMA")
+	final := hit == len(targets)-1
+	quantity := position.quantity
+	if !final {
+		var err error
+		quantity, final, err = mrs.partialExitQuantity(ctx, position.quantity, target.Fraction, currentPrice)
+		if err != nil {
+			return false, err
+		}
 	}
 
-	// Stop-loss check is handled by Risk Manager
-	// Max hold time check is handled by Risk Manager
+	reason := fmt.Sprintf("Price crossed %s", strings.ToUpper(target.Target))
+	if !final {
+		reason = fmt.Sprintf("Take profit %d/%d: %s", hit+1, len(targets), reason)
+	}
 
-	return nil
+	mrs.logger.WithFields(logrus.Fields{
+		"side":           position.side,
+		"entry_notional": position.notional.String(),
+		"quantity":       quantity.String(),
+		"current_price":  currentPrice.String(),
+		"target":         target.Target,
+		"level":          level.String(),
+	}).Infof("EXIT signal: %s", reason)
+
+	// Exits at configured targets are take-profits, the default SMA exit is
+	// the strategy's signal. The order manager records the hit when a partial
+	// take-profit fills, moving the position on to the next target.
+	exitReason := models.ExitReasonSignal
+	if len(params.TakeProfit) > 0 {
+		exitReason = models.ExitReasonTakeProfit
+	}
+
+	return true, mrs.generateExitSignal(ctx, position.side, quantity, currentPrice, reason, exitReason)
+}
+
+// partialExitQuantity returns the fraction of the open quantity rounded to
+// the exchange's step size and raised to its minimum order size. It reports
+// the exit as final, closing the whole position, if what would be left is
+// below the minimum.
+func (mrs *MeanReversionStrategy) partialExitQuantity(
+	ctx context.Context,
+	openQuantity decimal.Decimal,
+	fraction float64,
+	price decimal.Decimal,
+) (decimal.Decimal, bool, error) {
+	info, err := mrs.exchange.GetSymbolInfo(ctx, mrs.symbol)
+	if err != nil {
+		return decimal.Zero, false, fmt.Errorf("failed to get symbol info: %w", err)
+	}
+
+	quantity := info.RoundQuantity(openQuantity.Mul(decimal.NewFromFloat(fraction)))
+	if quantity.LessThan(info.MinOrderSize) {
+		quantity = info.MinOrderSize
+	}
+	if info.MinNotional.IsPositive() && quantity.Mul(price).LessThan(info.MinNotional) {
+		quantity = info.MinNotional.Div(price)
+		if info.StepSize.IsPositive() {
+			quantity = quantity.Div(info.StepSize).Ceil().Mul(info.StepSize)
+		}
+	}
+
+	remaining := openQuantity.Sub(quantity)
+	if info.ValidateQuantity(quantity, price) != nil || info.ValidateQuantity(remaining, price) != nil {
+		return openQuantity, true, nil
+	}
+
+	return quantity, false, nil
 }
 
-// generateExitSignal generates an exit signal
+// generateExitSignal generates a signal closing the given quantity of the
+// open position
 func (mrs *MeanReversionStrategy) generateExitSignal(
 	ctx context.Context,
-	trade *models.Trade,
+	side models.TradeSide,
+	quantity decimal.Decimal,
 	currentPrice decimal.Decimal,
 	reason string,
+	exitReason models.ExitReason,
 ) error {
 	// Determine exit side (opposite of entry)
 	exitSide := models.OrderSideSell
-	if trade.Side == models.TradeSideLong {
-		exitSide = models.OrderSideSell
-	} else {
+	if side == models.TradeSideShort {
 		exitSide = models.OrderSideBuy
 	}
 
@@ -271,8 +582,9 @@ func (mrs *MeanReversionStrategy) generateExitSignal(
 		StrategyID: mrs.strategyID,
 		Symbol:     mrs.symbol,
 		Side:       exitSide,
+		Intent:     models.CloseIntent(side),
 		Type:       models.OrderTypeMarket,
-		Quantity:   trade.Quantity,
+		Quantity:   quantity,
 		Reason:     reason,
 		Indicators: map[string]float64{
 			"price": currentPrice.InexactFloat64(),
@@ -286,40 +598,102 @@ func (mrs *MeanReversionStrategy) generateExitSignal(
 		StrategyID: signal.StrategyID.String(),
 		Symbol:     signal.Symbol,
 		Side:       string(signal.Side),
+		Intent:     string(signal.Intent),
 		Type:       string(signal.Type),
 		Quantity:   signal.Quantity.InexactFloat64(),
 		Reason:     signal.Reason,
 		Indicators: signal.Indicators,
+		ExitReason: string(exitReason),
 	}
 
-	if err := mrs.nats.Publish(events.EventTypeTradeSignal, signalEvent); err != nil {
+	if err := mrs.bus.Publish(events.EventTypeTradeSignal, signalEvent); err != nil {
 		return fmt.Errorf("failed to publish exit signal: %w", err)
 	}
 
 	mrs.logger.WithFields(logrus.Fields{
-		"signal_id": signal.ID,
-		"trade_id":  trade.ID,
-		"exit_side": signal.Side,
-		"quantity":  signal.Quantity.String(),
-		"reason":    reason,
+		"signal_id":   signal.ID,
+		"exit_side":   signal.Side,
+		"quantity":    signal.Quantity.String(),
+		"reason":      reason,
+		"exit_reason": exitReason,
 	}).Info("EXIT signal generated")
 
 	return nil
 }
 
-// hasOpenPosition checks if there's an open position for this strategy
-func (mrs *MeanReversionStrategy) hasOpenPosition(ctx context.Context) (bool, error) {
-	var count int
+// inFlightOrders counts the strategy's orders for its symbol that are
+// PENDING or OPEN
+type inFlightOrders struct {
+	entries int
+	exits   int
+}
+
+// ordersInFlight returns the strategy's orders not yet filled or failed
+func (mrs *MeanReversionStrategy) ordersInFlight(ctx context.Context) (inFlightOrders, error) {
+	var inFlight inFlightOrders
 	err := mrs.db.QueryRowContext(ctx, `
-		SELECT COUNT(*) FROM trades 
-		WHERE strategy_id = $1 AND exit_time IS NULL
-	`, mrs.strategyID).Scan(&count)
+		SELECT COUNT(*) FILTER (WHERE intent IN ('OPEN_LONG', 'OPEN_SHORT')),
+		       COUNT(*) FILTER (WHERE intent IN ('CLOSE_LONG', 'CLOSE_SHORT'))
+		FROM orders
+		WHERE strategy_id = $1 AND symbol = $2 AND status IN ('PENDING', 'OPEN')
+	`, mrs.strategyID, mrs.symbol).Scan(&inFlight.entries, &inFlight.exits)
+	return inFlight, err
+}
+
+// openPosition is the open lots of the strategy's symbol
+type openPosition struct {
+	side           models.TradeSide
+	quantity       decimal.Decimal
+	notional       decimal.Decimal // Entry notional of the open lots
+	tranches       int             // Entry orders the lots came from
+	lastEntryPrice decimal.Decimal
+	takeProfitHits int
+}
+
+// openPosition returns the open position for this strategy and symbol, or
+// nil if there is none
+func (mrs *MeanReversionStrategy) openPosition(ctx context.Context) (*openPosition, error) {
+	position := &openPosition{}
+	err := mrs.db.QueryRowContext(ctx, `
+		SELECT side,
+		       SUM(quantity),
+		       SUM(quantity * entry_price),
+		       COUNT(DISTINCT entry_order_id),
+		       COALESCE(MAX((metadata->>'take_profit_hits')::int), 0)
+		FROM trades
+		WHERE strategy_id = $1 AND symbol = $2 AND exit_time IS NULL
+		GROUP BY side
+		ORDER BY SUM(quantity * entry_price) DESC
+		LIMIT 1
+	`, mrs.strategyID, mrs.symbol).Scan(
+		&position.side,
+		&position.quantity,
+		&position.notional,
+		&position.tranches,
+		&position.takeProfitHits,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
+	// Fill price of the latest entry; lots may have been pooled at their
+	// average entry price
+	err = mrs.db.QueryRowContext(ctx, `
+		SELECT COALESCE(o.average_fill_price, t.entry_price)
+		FROM trades t
+		LEFT JOIN orders o ON o.id = t.entry_order_id
+		WHERE t.strategy_id = $1 AND t.symbol = $2 AND t.side = $3 AND t.exit_time IS NULL
+		ORDER BY t.entry_time DESC
+		LIMIT 1
+	`, mrs.strategyID, mrs.symbol, position.side).Scan(&position.lastEntryPrice)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	return count > 0, nil
+	return position, nil
 }
 
 // LoadPriceHistory loads historical price data from database
@@ -354,9 +728,23 @@ func (mrs *MeanReversionStrategy) LoadPriceHistory(ctx context.Context, limit in
 		prices[i], prices[j] = prices[j], prices[i]
 	}
 
-	mrs.priceHistory = prices
+	mrs.mu.Lock()
+	mrs.priceHistory = latestPrices(prices, mrs.maxHistorySize)
+	count := len(mrs.priceHistory)
+	mrs.mu.Unlock()
 
-	mrs.logger.WithField("count", len(prices)).Info("Loaded price history")
+	mrs.logger.WithFields(logrus.Fields{
+		"loaded": len(prices),
+		"kept":   count,
+	}).Info("Loaded price history")
 
 	return nil
 }
+
+// latestPrices returns the last size prices of a history
+func latestPrices(history []decimal.Decimal, size int) []decimal.Decimal {
+	if len(history) > size {
+		return history[len(history)-size:]
+	}
+	return history
+}
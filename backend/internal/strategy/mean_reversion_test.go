@@ -0,0 +1,33 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestLatestPrices(t *testing.T) {
+	history := []decimal.Decimal{decimal.NewFromInt(1), decimal.NewFromInt(2), decimal.NewFromInt(3)}
+
+	tests := []struct {
+		size int
+		want []int64
+	}{
+		{2, []int64{2, 3}},
+		{3, []int64{1, 2, 3}},
+		{5, []int64{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		got := latestPrices(history, tt.size)
+		if len(got) != len(tt.want) {
+			t.Fatalf("latestPrices(%d) = %v, want %v", tt.size, got, tt.want)
+		}
+		for i, want := range tt.want {
+			if !got[i].Equal(decimal.NewFromInt(want)) {
+				t.Errorf("latestPrices(%d) = %v, want %v", tt.size, got, tt.want)
+				break
+			}
+		}
+	}
+}
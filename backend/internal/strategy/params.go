@@ -0,0 +1,181 @@
+package strategy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/crypto-trading-bot/internal/sizing"
+)
+
+// minHistorySize is the minimum number of prices kept by a strategy
+const minHistorySize = 100
+
+// maxScaleInTranches and maxTakeProfitTargets bound the scaling parameters
+const (
+	maxScaleInTranches   = 10
+	maxTakeProfitTargets = 5
+)
+
+// Take-profit targets
+const (
+	TargetSMA  = "sma"  // Price crosses the SMA
+	TargetBand = "band" // Price crosses the opposite Bollinger Band
+)
+
+// ScaleInParams controls adding to an open position in tranches. A tranche
+// is added when the entry condition holds again and the price has moved
+// StepPercent against the last entry; a negative step adds to a position
+// moving in its favor instead (pyramiding). The step is required when
+// scaling in, so tranches aren't all entered at the same price.
+type ScaleInParams struct {
+	MaxTranches int     `json:"max_tranches"` // Entries per position, 1 disables scaling in
+	StepPercent float64 `json:"step_percent"`
+}
+
+// TakeProfitTarget is a partial exit at a price target
+type TakeProfitTarget struct {
+	Target   string  `json:"target"`   // sma or band
+	Fraction float64 `json:"fraction"` // Share of the open quantity to close; the last target closes the rest
+}
+
+// MeanReversionParams holds the tunable parameters of MeanReversionStrategy.
+// They are stored in the strategies.config JSONB column.
+type MeanReversionParams struct {
+	SMAPeriod     int           `json:"sma_period"`
+	RSIPeriod     int           `json:"rsi_period"`
+	BBPeriod      int           `json:"bb_period"`
+	BBStdDev      float64       `json:"bb_std_dev"`
+	RSIOversold   float64       `json:"rsi_oversold"`
+	RSIOverbought float64       `json:"rsi_overbought"`
+	AllowShort    bool          `json:"allow_short"` // Enter shorts on overbought signals (requires margin)
+	Sizing        sizing.Config `json:"sizing"`
+
+	// Scaling in and out; by default a position is entered once and closed
+	// in full at the SMA
+	ScaleIn    ScaleInParams      `json:"scale_in"`
+	TakeProfit []TakeProfitTarget `json:"take_profit"`
+}
+
+// DefaultMeanReversionParams returns the default strategy parameters
+func DefaultMeanReversionParams() MeanReversionParams {
+	return MeanReversionParams{
+		SMAPeriod:     20,
+		RSIPeriod:     14,
+		BBPeriod:      20,
+		BBStdDev:      2.0,
+		RSIOversold:   30.0,
+		RSIOverbought: 70.0,
+		Sizing:        sizing.DefaultConfig(),
+		ScaleIn:       ScaleInParams{MaxTranches: 1},
+	}
+}
+
+// ParseMeanReversionParams parses and validates a strategy config document.
+// Missing fields take their default values; unknown fields are rejected.
+func ParseMeanReversionParams(data []byte) (MeanReversionParams, error) {
+	return DefaultMeanReversionParams().WithOverrides(data)
+}
+
+// WithOverrides returns a copy of the parameters with the fields present in
+// data replaced, validating the result
+func (p MeanReversionParams) WithOverrides(data []byte) (MeanReversionParams, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return p, p.Validate()
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	updated := p
+	if err := decoder.Decode(&updated); err != nil {
+		return p, fmt.Errorf("invalid strategy config: %w", err)
+	}
+
+	if err := updated.Validate(); err != nil {
+		return p, err
+	}
+
+	return updated, nil
+}
+
+// Validate validates the strategy parameters
+func (p MeanReversionParams) Validate() error {
+	if p.SMAPeriod < 2 || p.SMAPeriod > 500 {
+		return fmt.Errorf("sma_period must be between 2 and 500")
+	}
+	if p.RSIPeriod < 2 || p.RSIPeriod > 500 {
+		return fmt.Errorf("rsi_period must be between 2 and 500")
+	}
+	if p.BBPeriod < 2 || p.BBPeriod > 500 {
+		return fmt.Errorf("bb_period must be between 2 and 500")
+	}
+	if p.BBStdDev <= 0 || p.BBStdDev > 10 {
+		return fmt.Errorf("bb_std_dev must be greater than 0 and at most 10")
+	}
+	if p.RSIOversold <= 0 || p.RSIOversold >= 100 {
+		return fmt.Errorf("rsi_oversold must be between 0 and 100")
+	}
+	if p.RSIOverbought <= 0 || p.RSIOverbought >= 100 {
+		return fmt.Errorf("rsi_overbought must be between 0 and 100")
+	}
+	if p.RSIOversold >= p.RSIOverbought {
+		return fmt.Errorf("rsi_oversold must be below rsi_overbought")
+	}
+	if err := p.Sizing.Validate(); err != nil {
+		return err
+	}
+	if p.ScaleIn.MaxTranches < 1 || p.ScaleIn.MaxTranches > maxScaleInTranches {
+		return fmt.Errorf("scale_in.max_tranches must be between 1 and %d", maxScaleInTranches)
+	}
+	if p.ScaleIn.StepPercent <= -100 || p.ScaleIn.StepPercent >= 100 {
+		return fmt.Errorf("scale_in.step_percent must be between -100 and 100")
+	}
+	if p.ScaleIn.MaxTranches > 1 && p.ScaleIn.StepPercent == 0 {
+		return fmt.Errorf("scale_in.step_percent must not be 0 when scaling in")
+	}
+	if len(p.TakeProfit) > maxTakeProfitTargets {
+		return fmt.Errorf("take_profit must have at most %d targets", maxTakeProfitTargets)
+	}
+	for i, target := range p.TakeProfit {
+		if target.Target != TargetSMA && target.Target != TargetBand {
+			return fmt.Errorf("take_profit[%d].target must be %s or %s", i, TargetSMA, TargetBand)
+		}
+		if target.Fraction <= 0 || target.Fraction > 1 {
+			return fmt.Errorf("take_profit[%d].fraction must be greater than 0 and at most 1", i)
+		}
+	}
+
+	return nil
+}
+
+// takeProfitTargets returns the exit targets in order, a full exit at the
+// SMA unless configured
+func (p MeanReversionParams) takeProfitTargets() []TakeProfitTarget {
+	if len(p.TakeProfit) == 0 {
+		return []TakeProfitTarget{{Target: TargetSMA, Fraction: 1}}
+	}
+	return p.TakeProfit
+}
+
+// historySize returns the number of prices needed to calculate all indicators
+func (p MeanReversionParams) historySize() int {
+	size := minHistorySize
+	for _, period := range []int{p.SMAPeriod, p.RSIPeriod + 1, p.BBPeriod} {
+		if period > size {
+			size = period
+		}
+	}
+	return size
+}
+
+// warmupPeriod returns the number of prices needed before signals are generated
+func (p MeanReversionParams) warmupPeriod() int {
+	warmup := p.BBPeriod
+	for _, period := range []int{p.SMAPeriod, p.RSIPeriod + 1} {
+		if period > warmup {
+			warmup = period
+		}
+	}
+	return warmup
+}
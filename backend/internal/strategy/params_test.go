@@ -0,0 +1,104 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/crypto-trading-bot/internal/sizing"
+)
+
+func TestMeanReversionParamsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(p *MeanReversionParams)
+		wantErr bool
+	}{
+		{"defaults", func(p *MeanReversionParams) {}, false},
+		{"sma period too short", func(p *MeanReversionParams) { p.SMAPeriod = 1 }, true},
+		{"rsi period too long", func(p *MeanReversionParams) { p.RSIPeriod = 501 }, true},
+		{"bb period too short", func(p *MeanReversionParams) { p.BBPeriod = 0 }, true},
+		{"zero bb deviation", func(p *MeanReversionParams) { p.BBStdDev = 0 }, true},
+		{"oversold out of range", func(p *MeanReversionParams) { p.RSIOversold = 100 }, true},
+		{"overbought out of range", func(p *MeanReversionParams) { p.RSIOverbought = 0 }, true},
+		{"oversold above overbought", func(p *MeanReversionParams) { p.RSIOversold, p.RSIOverbought = 70, 30 }, true},
+		{"invalid sizing", func(p *MeanReversionParams) { p.Sizing = sizing.Config{Type: "martingale"} }, true},
+		{"no tranches", func(p *MeanReversionParams) { p.ScaleIn.MaxTranches = 0 }, true},
+		{"too many tranches", func(p *MeanReversionParams) { p.ScaleIn.MaxTranches = maxScaleInTranches + 1 }, true},
+		{"scaling in", func(p *MeanReversionParams) { p.ScaleIn = ScaleInParams{MaxTranches: 3, StepPercent: 2} }, false},
+		{"pyramiding", func(p *MeanReversionParams) { p.ScaleIn = ScaleInParams{MaxTranches: 3, StepPercent: -2} }, false},
+		{"scaling in without a step", func(p *MeanReversionParams) { p.ScaleIn = ScaleInParams{MaxTranches: 3} }, true},
+		{"step out of range", func(p *MeanReversionParams) { p.ScaleIn = ScaleInParams{MaxTranches: 3, StepPercent: 100} }, true},
+		{"step without scaling in", func(p *MeanReversionParams) { p.ScaleIn.StepPercent = 5 }, false},
+		{
+			name: "take-profit targets",
+			modify: func(p *MeanReversionParams) {
+				p.TakeProfit = []TakeProfitTarget{{Target: TargetSMA, Fraction: 0.5}, {Target: TargetBand, Fraction: 1}}
+			},
+		},
+		{
+			name: "too many take-profit targets",
+			modify: func(p *MeanReversionParams) {
+				p.TakeProfit = make([]TakeProfitTarget, maxTakeProfitTargets+1)
+				for i := range p.TakeProfit {
+					p.TakeProfit[i] = TakeProfitTarget{Target: TargetSMA, Fraction: 0.1}
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name:    "unknown take-profit target",
+			modify:  func(p *MeanReversionParams) { p.TakeProfit = []TakeProfitTarget{{Target: "ema", Fraction: 1}} },
+			wantErr: true,
+		},
+		{
+			name:    "zero take-profit fraction",
+			modify:  func(p *MeanReversionParams) { p.TakeProfit = []TakeProfitTarget{{Target: TargetSMA}} },
+			wantErr: true,
+		},
+		{
+			name:    "take-profit fraction over 1",
+			modify:  func(p *MeanReversionParams) { p.TakeProfit = []TakeProfitTarget{{Target: TargetBand, Fraction: 1.5}} },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := DefaultMeanReversionParams()
+			tt.modify(&p)
+			if err := p.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMeanReversionParamsWithOverrides(t *testing.T) {
+	defaults := DefaultMeanReversionParams()
+
+	p, err := defaults.WithOverrides([]byte(`{"sma_period": 30, "scale_in": {"max_tranches": 2, "step_percent": 1.5}}`))
+	if err != nil {
+		t.Fatalf("WithOverrides: %v", err)
+	}
+	if p.SMAPeriod != 30 || p.ScaleIn.MaxTranches != 2 || p.ScaleIn.StepPercent != 1.5 {
+		t.Errorf("overridden params = %+v", p)
+	}
+	if p.RSIPeriod != defaults.RSIPeriod {
+		t.Errorf("rsi_period = %d, want the default %d", p.RSIPeriod, defaults.RSIPeriod)
+	}
+
+	invalid := []string{
+		`{"sma_period": 1}`,
+		`{"unknown_field": true}`,
+		`{"scale_in": {"max_tranches": 2}}`,
+		`not json`,
+	}
+	for _, raw := range invalid {
+		if got, err := defaults.WithOverrides([]byte(raw)); err == nil {
+			t.Errorf("WithOverrides(%s) = %+v, want an error", raw, got)
+		}
+	}
+
+	if got, err := defaults.WithOverrides([]byte("  ")); err != nil || got.SMAPeriod != defaults.SMAPeriod {
+		t.Errorf("WithOverrides of an empty document = %+v, %v, want the defaults", got, err)
+	}
+}
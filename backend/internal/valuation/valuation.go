@@ -0,0 +1,432 @@
+package valuation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/crypto-trading-bot/internal/currency"
+	"github.com/crypto-trading-bot/internal/events"
+	"github.com/crypto-trading-bot/internal/models"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// snapshotInterval is how often Run records a performance snapshot
+const snapshotInterval = time.Hour
+
+// Service marks the open trades and balances to the latest prices and values
+// them in the reporting currency
+type Service struct {
+	db         *sql.DB
+	bus        events.Bus
+	converter  *currency.Converter
+	currency   string
+	tradingDay *time.Location // Timezone the daily PnL's day starts in
+	logger     *logrus.Entry
+}
+
+// NewService creates a new valuation service reporting in the given currency,
+// with trading days starting at midnight in the given timezone
+func NewService(
+	db *sql.DB,
+	bus events.Bus,
+	converter *currency.Converter,
+	reportingCurrency string,
+	tradingDay *time.Location,
+	logger *logrus.Logger,
+) *Service {
+	return &Service{
+		db:         db,
+		bus:        bus,
+		converter:  converter,
+		currency:   reportingCurrency,
+		tradingDay: tradingDay,
+		logger:     logger.WithField("component", "valuation"),
+	}
+}
+
+// Currency returns the reporting currency
+func (s *Service) Currency() string {
+	return s.currency
+}
+
+// StartOfTradingDay returns the start of the trading day containing t, the
+// same day the risk manager's daily loss limit uses
+func (s *Service) StartOfTradingDay(t time.Time) time.Time {
+	local := t.In(s.tradingDay)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.tradingDay)
+}
+
+// Run publishes the valuation at the given interval and records a
+// performance snapshot every hour until the context is done
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastSnapshot time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			valuation, err := s.Publish(ctx)
+			if err != nil {
+				s.logger.WithError(err).Error("Failed to publish portfolio valuation")
+				continue
+			}
+
+			if time.Since(lastSnapshot) >= snapshotInterval {
+				if err := s.Snapshot(ctx, valuation); err != nil {
+					s.logger.WithError(err).Error("Failed to record performance snapshot")
+					continue
+				}
+				lastSnapshot = time.Now()
+			}
+		}
+	}
+}
+
+// Publish values the portfolio and publishes the valuation
+func (s *Service) Publish(ctx context.Context) (*events.PortfolioValuationEvent, error) {
+	valuation, err := s.Value(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(valuation.Unpriced) > 0 {
+		s.logger.WithField("unpriced", valuation.Unpriced).Warn("Portfolio valued without prices for some holdings")
+	}
+
+	if err := s.bus.Publish(events.EventTypePortfolioValuation, valuation); err != nil {
+		return nil, fmt.Errorf("failed to publish valuation: %w", err)
+	}
+	return valuation, nil
+}
+
+// Value marks every open trade and balance to the latest price. Balances hold
+// the proceeds of short sales, so the market value of shorts, owed back, is
+// deducted from the portfolio value.
+func (s *Service) Value(ctx context.Context) (*events.PortfolioValuationEvent, error) {
+	v := &valuer{service: s, unpriced: make(map[string]bool)}
+
+	positions, err := v.positions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	balancesValue, cashValue, err := v.balances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	valuation := &events.PortfolioValuationEvent{
+		Currency:  s.currency,
+		Positions: make([]events.PositionValuation, 0, len(positions)),
+		ValuedAt:  time.Now(),
+	}
+
+	long, short, unrealized := decimal.Zero, decimal.Zero, decimal.Zero
+	for _, p := range positions {
+		if p.side == models.TradeSideShort {
+			short = short.Add(p.marketValue)
+		} else {
+			long = long.Add(p.marketValue)
+		}
+		unrealized = unrealized.Add(p.unrealizedPnL)
+		valuation.Positions = append(valuation.Positions, p.event())
+	}
+
+	valuation.PortfolioValue = balancesValue.Sub(short).InexactFloat64()
+	valuation.BalancesValue = balancesValue.InexactFloat64()
+	valuation.CashValue = cashValue.InexactFloat64()
+	valuation.UnrealizedPnL = unrealized.InexactFloat64()
+	valuation.LongExposure = long.InexactFloat64()
+	valuation.ShortExposure = short.InexactFloat64()
+	valuation.GrossExposure = long.Add(short).InexactFloat64()
+	valuation.NetExposure = long.Sub(short).InexactFloat64()
+
+	for name := range v.unpriced {
+		valuation.Unpriced = append(valuation.Unpriced, name)
+	}
+	sort.Strings(valuation.Unpriced)
+
+	return valuation, nil
+}
+
+// RealizedPnL returns the PnL of the trades closed since the given time, in
+// the reporting currency. PnL is recorded in the quote currency of each symbol.
+func (s *Service) RealizedPnL(ctx context.Context, since time.Time) (decimal.Decimal, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT symbol, COALESCE(SUM(pnl), 0)
+		FROM trades
+		WHERE exit_time IS NOT NULL AND exit_time >= $1
+		GROUP BY symbol
+	`, since)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get realized PnL: %w", err)
+	}
+
+	pnls := make(map[string]decimal.Decimal)
+	for rows.Next() {
+		var symbol string
+		var pnl decimal.Decimal
+		if err := rows.Scan(&symbol, &pnl); err != nil {
+			rows.Close()
+			return decimal.Zero, fmt.Errorf("failed to scan PnL: %w", err)
+		}
+		pnls[symbol] = pnl
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return decimal.Zero, err
+	}
+
+	total := decimal.Zero
+	for symbol, pnl := range pnls {
+		_, quote := currency.Split(symbol)
+		converted, err := s.converter.Convert(ctx, pnl, quote, s.currency)
+		if errors.Is(err, currency.ErrNoRate) {
+			s.logger.WithField("symbol", symbol).Warn("No rate for realized PnL, leaving it out")
+			continue
+		}
+		if err != nil {
+			return decimal.Zero, err
+		}
+		total = total.Add(converted)
+	}
+
+	return total, nil
+}
+
+// Snapshot records the valuation and trade statistics in performance_snapshots
+func (s *Service) Snapshot(ctx context.Context, valuation *events.PortfolioValuationEvent) error {
+	totalPnL, err := s.RealizedPnL(ctx, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	dailyPnL, err := s.RealizedPnL(ctx, s.StartOfTradingDay(time.Now()))
+	if err != nil {
+		return err
+	}
+
+	var totalTrades, winningTrades int
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE pnl > 0)
+		FROM trades
+		WHERE exit_time IS NOT NULL
+	`).Scan(&totalTrades, &winningTrades)
+	if err != nil {
+		return fmt.Errorf("failed to get trade counts: %w", err)
+	}
+
+	var winRate *float64
+	if totalTrades > 0 {
+		rate := float64(winningTrades) / float64(totalTrades) * 100
+		winRate = &rate
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO performance_snapshots (
+			portfolio_value, cash_balance, total_pnl, daily_pnl, open_positions, total_trades, win_rate, currency
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, valuation.PortfolioValue, valuation.CashValue, totalPnL.Round(2), dailyPnL.Round(2),
+		len(valuation.Positions), totalTrades, winRate, valuation.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to insert performance snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// position is the open lots of a strategy, symbol and side
+type position struct {
+	strategyID    string
+	symbol        string
+	side          models.TradeSide
+	quantity      decimal.Decimal
+	cost          decimal.Decimal // In the quote currency
+	markPrice     decimal.Decimal // In the quote currency
+	marketValue   decimal.Decimal
+	unrealizedPnL decimal.Decimal
+}
+
+// event returns the position as published
+func (p *position) event() events.PositionValuation {
+	entryPrice := decimal.Zero
+	if p.quantity.IsPositive() {
+		entryPrice = p.cost.Div(p.quantity)
+	}
+
+	return events.PositionValuation{
+		StrategyID:    p.strategyID,
+		Symbol:        p.symbol,
+		Side:          string(p.side),
+		Quantity:      p.quantity.InexactFloat64(),
+		EntryPrice:    entryPrice.InexactFloat64(),
+		MarkPrice:     p.markPrice.InexactFloat64(),
+		MarketValue:   p.marketValue.InexactFloat64(),
+		UnrealizedPnL: p.unrealizedPnL.InexactFloat64(),
+	}
+}
+
+// valuer collects the holdings left out of one valuation
+type valuer struct {
+	service  *Service
+	unpriced map[string]bool // Symbols and currencies without a price
+}
+
+// positions marks the open trades, grouped by strategy, symbol and side
+func (v *valuer) positions(ctx context.Context) ([]*position, error) {
+	rows, err := v.service.db.QueryContext(ctx, `
+		SELECT strategy_id, symbol, side, entry_price, quantity, entry_fees
+		FROM trades
+		WHERE exit_time IS NULL
+		ORDER BY symbol, strategy_id, side
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open trades: %w", err)
+	}
+
+	var trades []models.Trade
+	for rows.Next() {
+		var trade models.Trade
+		if err := rows.Scan(
+			&trade.StrategyID,
+			&trade.Symbol,
+			&trade.Side,
+			&trade.EntryPrice,
+			&trade.Quantity,
+			&trade.EntryFees,
+		); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan trade: %w", err)
+		}
+		trades = append(trades, trade)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var positions []*position
+	byKey := make(map[string]*position)
+	for i := range trades {
+		trade := &trades[i]
+
+		price, err := v.service.converter.Price(ctx, trade.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		if price == nil {
+			v.unpriced[trade.Symbol] = true
+			continue
+		}
+
+		_, quote := currency.Split(trade.Symbol)
+		rate, err := v.rate(ctx, quote)
+		if err != nil {
+			return nil, err
+		}
+		if rate == nil {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%s", trade.StrategyID, trade.Symbol, trade.Side)
+		p, exists := byKey[key]
+		if !exists {
+			p = &position{
+				strategyID: trade.StrategyID.String(),
+				symbol:     trade.Symbol,
+				side:       trade.Side,
+				markPrice:  *price,
+			}
+			byKey[key] = p
+			positions = append(positions, p)
+		}
+
+		// Entry fees are the only fees of an open trade
+		trade.FeesTotal = trade.EntryFees
+
+		p.quantity = p.quantity.Add(trade.Quantity)
+		p.cost = p.cost.Add(trade.EntryPrice.Mul(trade.Quantity))
+		p.marketValue = p.marketValue.Add(price.Mul(trade.Quantity).Mul(*rate))
+		p.unrealizedPnL = p.unrealizedPnL.Add(trade.CalculatePnL(*price).Mul(*rate))
+	}
+
+	return positions, nil
+}
+
+// balances returns the value of all balances and of those at par with the
+// reporting currency
+func (v *valuer) balances(ctx context.Context) (decimal.Decimal, decimal.Decimal, error) {
+	rows, err := v.service.db.QueryContext(ctx, `
+		SELECT currency, SUM(total) FROM balances GROUP BY currency ORDER BY currency
+	`)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("failed to get balances: %w", err)
+	}
+
+	type balance struct {
+		currency string
+		total    decimal.Decimal
+	}
+
+	var balances []balance
+	for rows.Next() {
+		var b balance
+		if err := rows.Scan(&b.currency, &b.total); err != nil {
+			rows.Close()
+			return decimal.Zero, decimal.Zero, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		balances = append(balances, b)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+
+	value, cash := decimal.Zero, decimal.Zero
+	for _, b := range balances {
+		if b.total.IsZero() {
+			continue
+		}
+
+		rate, err := v.rate(ctx, b.currency)
+		if err != nil {
+			return decimal.Zero, decimal.Zero, err
+		}
+		if rate == nil {
+			continue
+		}
+
+		converted := b.total.Mul(*rate)
+		value = value.Add(converted)
+		if v.service.converter.Par(b.currency, v.service.currency) {
+			cash = cash.Add(converted)
+		}
+	}
+
+	return value, cash, nil
+}
+
+// rate returns the rate from a currency to the reporting currency, or nil if
+// there is none
+func (v *valuer) rate(ctx context.Context, from string) (*decimal.Decimal, error) {
+	rate, err := v.service.converter.Rate(ctx, from, v.service.currency)
+	if errors.Is(err, currency.ErrNoRate) {
+		v.unpriced[from] = true
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
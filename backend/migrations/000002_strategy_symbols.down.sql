@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_strategies_name_symbol;
+
+ALTER TABLE strategies DROP COLUMN IF EXISTS symbol;
@@ -0,0 +1,4 @@
+-- Strategies run one instance per symbol
+ALTER TABLE strategies ADD COLUMN symbol TEXT;
+
+CREATE INDEX idx_strategies_name_symbol ON strategies(name, symbol);
@@ -0,0 +1,3 @@
+-- Allow trailing-stop orders
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_type_check;
+ALTER TABLE orders ADD CONSTRAINT orders_type_check CHECK (type IN ('MARKET', 'LIMIT', 'TRAILING_STOP'));
@@ -0,0 +1,8 @@
+-- Orders state whether they open or close a long or short position
+ALTER TABLE orders ADD COLUMN intent TEXT;
+
+UPDATE orders SET intent = CASE side WHEN 'BUY' THEN 'OPEN_LONG' ELSE 'CLOSE_LONG' END;
+
+ALTER TABLE orders ALTER COLUMN intent SET NOT NULL;
+ALTER TABLE orders ADD CONSTRAINT orders_intent_check
+    CHECK (intent IN ('OPEN_LONG', 'CLOSE_LONG', 'OPEN_SHORT', 'CLOSE_SHORT'));
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_trades_strategy_exit_time;
+ALTER TABLE strategies DROP COLUMN IF EXISTS drawdown_reset_at;
+ALTER TABLE strategies DROP COLUMN IF EXISTS pause_reason;
+ALTER TABLE strategies DROP COLUMN IF EXISTS paused_until;
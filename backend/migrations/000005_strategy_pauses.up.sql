@@ -0,0 +1,8 @@
+-- Circuit breakers pause a single strategy until paused_until
+ALTER TABLE strategies ADD COLUMN paused_until TIMESTAMPTZ;
+ALTER TABLE strategies ADD COLUMN pause_reason TEXT;
+
+-- Drawdown is measured over trades closed after the last drawdown trip
+ALTER TABLE strategies ADD COLUMN drawdown_reset_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_trades_strategy_exit_time ON trades(strategy_id, exit_time);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS risk_limit_changes;
+DELETE FROM system_config WHERE key = 'risk_limits';
+ALTER TABLE strategies DROP COLUMN IF EXISTS risk_limits;
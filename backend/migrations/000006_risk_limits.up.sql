@@ -0,0 +1,16 @@
+-- Per-strategy risk limit overrides, as a partial JSON document on top of
+-- the global limits stored in system_config under 'risk_limits'
+ALTER TABLE strategies ADD COLUMN risk_limits JSONB;
+
+-- Audit trail of risk limit changes
+CREATE TABLE risk_limit_changes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    strategy_id UUID REFERENCES strategies(id) ON DELETE CASCADE, -- NULL for global limits
+    field TEXT NOT NULL,
+    old_value JSONB,
+    new_value JSONB,
+    changed_by TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_risk_limit_changes_changed_at ON risk_limit_changes(changed_at DESC);
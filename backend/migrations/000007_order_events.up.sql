@@ -0,0 +1,13 @@
+-- Order status transitions, one row per change
+CREATE TABLE order_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status TEXT, -- NULL when the order is created
+    to_status TEXT NOT NULL,
+    source TEXT NOT NULL CHECK (source IN ('order_manager', 'exchange', 'reconciler', 'kill_switch', 'user')),
+    reason TEXT,
+    filled_quantity DECIMAL(20,8),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_order_events_order_id ON order_events(order_id, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS fee_currency;
+DROP TABLE IF EXISTS fills;
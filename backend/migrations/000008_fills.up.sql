@@ -0,0 +1,22 @@
+-- Individual executions of orders; order fill aggregates are derived from them
+CREATE TABLE fills (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    exchange_id UUID NOT NULL REFERENCES exchanges(id),
+    exchange_trade_id TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    side TEXT NOT NULL CHECK (side IN ('BUY', 'SELL')),
+    price DECIMAL(20,8) NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL,
+    fee DECIMAL(20,8) NOT NULL DEFAULT 0,
+    fee_currency TEXT NOT NULL,
+    liquidity TEXT NOT NULL CHECK (liquidity IN ('MAKER', 'TAKER')),
+    executed_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (exchange_id, symbol, exchange_trade_id)
+);
+
+CREATE INDEX idx_fills_order_id ON fills(order_id);
+CREATE INDEX idx_fills_executed_at ON fills(executed_at DESC);
+
+ALTER TABLE orders ADD COLUMN fee_currency TEXT;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_trades_open_lots;
+ALTER TABLE trades DROP COLUMN IF EXISTS entry_fees;
@@ -0,0 +1,9 @@
+-- Entry fees per lot, split proportionally on partial closes
+ALTER TABLE trades ADD COLUMN entry_fees DECIMAL(20,8) NOT NULL DEFAULT 0;
+
+UPDATE trades t
+SET entry_fees = COALESCE(o.fees, 0)
+FROM orders o
+WHERE o.id = t.entry_order_id;
+
+CREATE INDEX idx_trades_open_lots ON trades(strategy_id, symbol, side, entry_time) WHERE exit_time IS NULL;
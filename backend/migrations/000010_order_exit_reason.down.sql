@@ -0,0 +1,2 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS close_trade_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS exit_reason;
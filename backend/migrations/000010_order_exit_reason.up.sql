@@ -0,0 +1,4 @@
+-- Why a closing order was placed and the trade it closes, carried into the
+-- trade when the order fills
+ALTER TABLE orders ADD COLUMN exit_reason TEXT;
+ALTER TABLE orders ADD COLUMN close_trade_id UUID REFERENCES trades(id);
@@ -0,0 +1 @@
+ALTER TABLE performance_snapshots DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,2 @@
+-- Reporting currency the snapshot values are in
+ALTER TABLE performance_snapshots ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
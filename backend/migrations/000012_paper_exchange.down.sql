@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS paper_margin_loans;
+DROP TABLE IF EXISTS paper_fills;
+DROP TABLE IF EXISTS paper_orders;
+DROP TABLE IF EXISTS paper_balances;
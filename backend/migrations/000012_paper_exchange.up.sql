@@ -0,0 +1,61 @@
+-- State of the paper exchange, so a paper venue survives restarts
+CREATE TABLE paper_balances (
+    venue TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    available DECIMAL(20,8) NOT NULL DEFAULT 0,
+    locked DECIMAL(20,8) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (venue, currency)
+);
+
+-- Orders by the ID the venue assigned; resting trailing stops keep their
+-- trailing distance and high-water mark
+CREATE TABLE paper_orders (
+    id TEXT PRIMARY KEY,
+    venue TEXT NOT NULL,
+    client_order_id TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    side TEXT NOT NULL,
+    type TEXT NOT NULL,
+    status TEXT NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL,
+    price DECIMAL(20,8),
+    filled_quantity DECIMAL(20,8) NOT NULL DEFAULT 0,
+    average_fill_price DECIMAL(20,8),
+    fees DECIMAL(20,8) NOT NULL DEFAULT 0,
+    trailing_percent DECIMAL(20,8),
+    trailing_amount DECIMAL(20,8),
+    high_water_mark DECIMAL(20,8),
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (venue, client_order_id)
+);
+
+CREATE INDEX idx_paper_orders_venue_status ON paper_orders(venue, status);
+
+CREATE TABLE paper_fills (
+    trade_id TEXT PRIMARY KEY,
+    order_id TEXT NOT NULL REFERENCES paper_orders(id) ON DELETE CASCADE,
+    symbol TEXT NOT NULL,
+    side TEXT NOT NULL,
+    price DECIMAL(20,8) NOT NULL,
+    quantity DECIMAL(20,8) NOT NULL,
+    fee DECIMAL(20,8) NOT NULL DEFAULT 0,
+    fee_currency TEXT NOT NULL,
+    liquidity TEXT NOT NULL,
+    executed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_paper_fills_order_id ON paper_fills(order_id);
+
+-- Balances borrowed by the paper margin account to sell short
+CREATE TABLE paper_margin_loans (
+    venue TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    borrowed DECIMAL(20,8) NOT NULL,
+    collateral DECIMAL(20,8) NOT NULL,
+    accrued_interest DECIMAL(20,8) NOT NULL DEFAULT 0,
+    last_accrual TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (venue, symbol)
+);
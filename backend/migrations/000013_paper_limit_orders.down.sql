@@ -0,0 +1,2 @@
+ALTER TABLE paper_orders DROP COLUMN IF EXISTS queue_ahead;
+ALTER TABLE paper_orders DROP COLUMN IF EXISTS intent;
@@ -0,0 +1,4 @@
+-- Resting paper limit orders keep their position intent and the volume
+-- queued ahead of them
+ALTER TABLE paper_orders ADD COLUMN intent TEXT NOT NULL DEFAULT '';
+ALTER TABLE paper_orders ADD COLUMN queue_ahead DECIMAL(20,8);
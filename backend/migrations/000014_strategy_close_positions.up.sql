@@ -0,0 +1,3 @@
+-- A strategy disabled with its positions to be closed keeps the request
+-- until a bot has closed them, so a missed toggle event isn't lost
+ALTER TABLE strategies ADD COLUMN close_positions BOOLEAN NOT NULL DEFAULT false;
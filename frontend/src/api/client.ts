@@ -3,10 +3,15 @@ import type {
   Overview,
   Trade,
   Order,
+  OrderEvent,
+  Fill,
   Balance,
   Strategy,
   KillSwitchStatus,
   RiskEvent,
+  RiskLimits,
+  RiskLimitChange,
+  VaRReport,
   Log,
 } from '../types';
 
@@ -32,6 +37,16 @@ export const getOrders = async (): Promise<Order[]> => {
   return data;
 };
 
+export const getOrderHistory = async (orderId: string): Promise<OrderEvent[]> => {
+  const { data } = await api.get<OrderEvent[]>(`/orders/${orderId}/history`);
+  return data;
+};
+
+export const getOrderFills = async (orderId: string): Promise<Fill[]> => {
+  const { data } = await api.get<Fill[]>(`/orders/${orderId}/fills`);
+  return data;
+};
+
 export const getBalances = async (): Promise<Balance[]> => {
   const { data } = await api.get<Balance[]>('/balances');
   return data;
@@ -42,10 +57,58 @@ export const getStrategy = async (): Promise<Strategy> => {
   return data;
 };
 
+export const getStrategies = async (): Promise<Strategy[]> => {
+  const { data } = await api.get<Strategy[]>('/strategies');
+  return data;
+};
+
+export const updateStrategyConfig = async (
+  id: string,
+  config: Record<string, any>
+): Promise<void> => {
+  await api.put(`/strategies/${id}/config`, config);
+};
+
 export const toggleStrategy = async (enabled: boolean): Promise<void> => {
   await api.post('/strategy/toggle', { enabled });
 };
 
+export const toggleStrategyById = async (
+  id: string,
+  enabled: boolean,
+  mode: 'pause_entries' | 'close_positions' = 'pause_entries'
+): Promise<void> => {
+  await api.post(`/strategies/${id}/toggle`, { enabled, mode });
+};
+
+export const getRiskLimits = async (): Promise<RiskLimits> => {
+  const { data } = await api.get<RiskLimits>('/risk/limits');
+  return data;
+};
+
+export const updateRiskLimits = async (
+  limits: Record<string, number>,
+  strategyId?: string,
+  reset: string[] = []
+): Promise<RiskLimitChange[]> => {
+  const { data } = await api.put<{ changes: RiskLimitChange[] }>('/risk/limits', {
+    strategy_id: strategyId,
+    limits,
+    reset,
+  });
+  return data.changes;
+};
+
+export const getRiskLimitChanges = async (): Promise<RiskLimitChange[]> => {
+  const { data } = await api.get<RiskLimitChange[]>('/risk/limits/changes');
+  return data;
+};
+
+export const getVaR = async (confidence?: number): Promise<VaRReport> => {
+  const { data } = await api.get<VaRReport>('/risk/var', { params: { confidence } });
+  return data;
+};
+
 export const getKillSwitchStatus = async (): Promise<KillSwitchStatus> => {
   const { data } = await api.get<KillSwitchStatus>('/kill-switch');
   return data;
@@ -25,7 +25,7 @@ export function Dashboard() {
   const formatCurrency = (value: number) => {
     return new Intl.NumberFormat('en-US', {
       style: 'currency',
-      currency: 'USD',
+      currency: overview.currency || 'USD',
     }).format(value);
   };
 
@@ -74,10 +74,25 @@ export function Dashboard() {
         </div>
       </div>
 
+      {/* Unrealized P&L */}
+      <div className="bg-white rounded-lg shadow p-6">
+        <div className="text-sm font-medium text-gray-500 mb-1">Unrealized P&L</div>
+        <div
+          className={`text-3xl font-bold ${
+            overview.unrealized_pnl >= 0 ? 'text-green-600' : 'text-red-600'
+          }`}
+        >
+          {formatCurrency(overview.unrealized_pnl)}
+        </div>
+      </div>
+
       {/* Open Positions */}
       <div className="bg-white rounded-lg shadow p-6">
         <div className="text-sm font-medium text-gray-500 mb-1">Open Positions</div>
         <div className="text-3xl font-bold text-gray-900">{overview.open_positions}</div>
+        <div className="text-sm text-gray-500">
+          Gross {formatCurrency(overview.gross_exposure)} / Net {formatCurrency(overview.net_exposure)}
+        </div>
       </div>
 
       {/* Total Trades */}
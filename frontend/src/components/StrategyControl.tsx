@@ -95,6 +95,12 @@ export function StrategyControl() {
           Strategy is currently disabled. No trades will be placed.
         </div>
       )}
+
+      {strategy.is_active && strategy.paused_until && (
+        <div className="mt-3 text-sm text-yellow-700 bg-yellow-50 border border-yellow-200 rounded p-3">
+          Paused until {new Date(strategy.paused_until).toLocaleString()}: {strategy.pause_reason}
+        </div>
+      )}
     </div>
   );
 }
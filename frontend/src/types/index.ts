@@ -1,5 +1,12 @@
 export interface Overview {
+  currency: string;
   portfolio_value: number;
+  unrealized_pnl: number;
+  long_exposure?: number;
+  short_exposure?: number;
+  gross_exposure: number;
+  net_exposure: number;
+  unpriced?: string[] | null;
   daily_pnl: number;
   total_pnl: number;
   open_positions: number;
@@ -32,6 +39,27 @@ export interface Order {
   created_at: string;
 }
 
+export interface OrderEvent {
+  from_status: string | null;
+  to_status: string;
+  source: 'order_manager' | 'exchange' | 'reconciler' | 'kill_switch' | 'user';
+  reason: string | null;
+  filled_quantity?: string;
+  created_at: string;
+}
+
+export interface Fill {
+  exchange_trade_id: string;
+  side: string;
+  price: string;
+  quantity: string;
+  fee: string;
+  fee_currency: string;
+  liquidity: 'MAKER' | 'TAKER';
+  executed_at: string;
+  slippage_percent?: number;
+}
+
 export interface Balance {
   currency: string;
   available: number;
@@ -43,8 +71,38 @@ export interface Strategy {
   id: string;
   name: string;
   type: string;
+  symbol?: string;
   is_active: boolean;
   config: Record<string, any>;
+  paused_until?: string;
+  pause_reason?: string;
+}
+
+export interface RiskLimits {
+  global: Record<string, number>;
+  strategy_overrides: Record<string, Record<string, number>>;
+}
+
+export interface RiskLimitChange {
+  strategy_id?: string;
+  field: string;
+  old_value: number;
+  new_value: number;
+  changed_by: string;
+  changed_at: string;
+}
+
+export interface VaRReport {
+  confidence: number;
+  horizon_minutes: number;
+  samples: number;
+  gross_exposure: number;
+  historical_var: number;
+  historical_es: number;
+  parametric_var: number;
+  parametric_es: number;
+  positions: { symbol: string; notional: number }[] | null;
+  calculated_at: string;
 }
 
 export interface KillSwitchStatus {